@@ -2,25 +2,53 @@ package gocombinatorics
 
 import (
 	"errors"
+	"iter"
 	"math/big"
+
+	"github.com/natemcintosh/gocombinatorics/count"
 )
 
 // Combinations will give you the indices of all possible combinations of an input
 // slice/array of length n, choosing k elements.
 type Combinations[T any] struct {
-	data    []T
+	data    source[T]
 	n, k    int
 	isfirst bool
 	inds    []int
-	Length  *big.Int
-	buffer  []T
+	length
+	buffer []T
+}
+
+// NewCombinations creates a new combinations object. It makes its own copy of
+// input_data, unless you pass `WithNoCopy()`.
+func NewCombinations[T any](input_data []T, k int, opts ...Option) (*Combinations[T], error) {
+	return new_combinations(source_from_slice(input_data, opts), k)
+}
+
+// NewCombinationsFromFunc is like NewCombinations, for n items where at(i) gives the item
+// at index i. Nothing is stored, so n can be far larger than would fit in memory, such as
+// the integers 0, ..., 10^9 - 1. at is called for each item every time `Items()` is.
+func NewCombinationsFromFunc[T any](n int, at func(int) T, k int) (*Combinations[T], error) {
+	src, err := source_from_func(n, at)
+	if err != nil {
+		return nil, err
+	}
+	return new_combinations(src, k)
 }
 
-// // NewCombinations creates a new combinations object.
-func NewCombinations[T any](input_data []T, k int) (*Combinations[T], error) {
-	data := make([]T, len(input_data))
-	copy(data, input_data)
-	n := len(input_data)
+// NewCombinationsFromSeq is like NewCombinations, for the first n items that seq gives.
+// seq is only read the first time `Items()` is called, so going through the `Indices()`
+// alone never reads it. `Items()` panics if seq gives fewer than n items.
+func NewCombinationsFromSeq[T any](seq iter.Seq[T], n, k int) (*Combinations[T], error) {
+	src, err := source_from_seq(seq, n)
+	if err != nil {
+		return nil, err
+	}
+	return new_combinations(src, k)
+}
+
+func new_combinations[T any](data source[T], k int) (*Combinations[T], error) {
+	n := data.n
 
 	// Check for cases where we can't do combinations
 	if k > n {
@@ -32,11 +60,10 @@ func NewCombinations[T any](input_data []T, k int) (*Combinations[T], error) {
 	}
 	isfirst := true
 	inds := make([]int, k)
-	Length := nchoosek(uint64(n), uint64(k))
+	length := binomial_length(uint64(n), uint64(k))
 
 	// Make the buffer slice
 	buffer := make([]T, k)
-	fill_buffer(buffer, data, inds)
 
 	return &Combinations[T]{
 		data:    data,
@@ -44,7 +71,7 @@ func NewCombinations[T any](input_data []T, k int) (*Combinations[T], error) {
 		k:       k,
 		isfirst: isfirst,
 		inds:    inds,
-		Length:  Length,
+		length:  length,
 		buffer:  buffer,
 	}, nil
 }
@@ -95,10 +122,31 @@ func (c *Combinations[T]) Indices() []int {
 // overwritten every iteration. If you need to keep the data from each iteration, be
 // sure to make a copy.
 func (c *Combinations[T]) Items() []T {
-	fill_buffer(c.buffer, c.data, c.inds)
+	c.data.fill(c.buffer, c.inds)
 	return c.buffer
 }
 
+// Rank returns the position of the current combination in the order that `Next()`
+// generates them, starting at 0.
+func (c *Combinations[T]) Rank() *big.Int {
+	if c.isfirst {
+		return big.NewInt(0)
+	}
+	return rank_combination(c.inds, c.n)
+}
+
+// Unrank moves the Combinations to the combination at position `rank`, so that
+// `Indices()` and `Items()` reflect it. Calling `Next()` afterwards carries on from
+// there.
+func (c *Combinations[T]) Unrank(rank *big.Int) error {
+	if rank.Sign() < 0 || c.cmp(rank) <= 0 {
+		return errRankOutOfRange
+	}
+	unrank_combination(rank, c.n, c.inds)
+	c.isfirst = false
+	return nil
+}
+
 // nchoosek returns the number of combinations of n things taken k at a time.
 // nchoosek(n, k) = n! / (k! * (n-k)!) if n > k
 // nchoosek(n, k) = 0 if n < k
@@ -114,23 +162,52 @@ func nchoosek(n, k uint64) *big.Int {
 		// nchoosek(n, k) = 1 if n == k
 		return big.NewInt(1)
 	}
-	// Calculate the numerator
-	numerator := factorial(int64(n))
-
-	// Calculate the denominator
-	kfact := factorial(int64(k))
-	nminuskfact := factorial(int64(n - k))
-	denominator := big.NewInt(0)
-	denominator = denominator.Mul(kfact, nminuskfact)
-
-	// Calculate the result
-	result := big.NewInt(0)
-	return result.Div(numerator, denominator)
+	return binomial_length(n, k).BigLen()
 }
 
 // factorial returns the factorial of a number, i.e. n! = n * (n-1) * (n-2) * ... * 1
 func factorial(n int64) *big.Int {
-	fact := big.NewInt(0)
-	fact.MulRange(1, n)
-	return fact
+	return count.Factorial(int(n))
+}
+
+// rank_combination returns the position of the strictly increasing indices `inds`
+// among all combinations of n things taken len(inds) at a time, in the order that
+// `Combinations.Next()` produces them. It uses the combinatorial number system:
+// rank = nchoosek(n, k) - 1 - sum(nchoosek(n-1-inds[t], k-t) for t in range(k))
+func rank_combination(inds []int, n int) *big.Int {
+	k := len(inds)
+	rank := count.Binomial(n, k)
+	rank.Sub(rank, big.NewInt(1))
+	for t, c := range inds {
+		rank.Sub(rank, count.Binomial(n-1-c, k-t))
+	}
+	return rank
+}
+
+// unrank_combination is the inverse of rank_combination. It fills `inds` with the
+// combination of n things taken len(inds) at a time found at position `rank`. The rank
+// is assumed to be in [0, nchoosek(n, len(inds))).
+func unrank_combination(rank *big.Int, n int, inds []int) {
+	k := len(inds)
+	m := count.Binomial(n, k)
+	m.Sub(m, big.NewInt(1))
+	m.Sub(m, rank)
+
+	prev := -1
+	for t := 0; t < k; t++ {
+		// Find the smallest v with nchoosek(n-1-v, k-t) <= m. The binomial shrinks as v
+		// grows, so we can binary search for it.
+		lo, hi := prev+1, n-k+t
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if count.Binomial(n-1-mid, k-t).Cmp(m) <= 0 {
+				hi = mid
+			} else {
+				lo = mid + 1
+			}
+		}
+		inds[t] = lo
+		m.Sub(m, count.Binomial(n-1-lo, k-t))
+		prev = lo
+	}
 }
@@ -3,6 +3,7 @@ package gocombinatorics
 import (
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"log"
 	"math/big"
 	"os"
@@ -1088,3 +1089,34 @@ func csv_to_2d_int_array(path string) [][]int {
 	return int_lines
 
 }
+
+func TestRankCombination(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		for k := 1; k <= n; k++ {
+			c, _ := NewCombinations(stepped_range(0, n, 1), k)
+			inds := make([]int, k)
+			for rank := int64(0); c.Next(); rank++ {
+				if got := rank_combination(c.Indices(), n); got.Cmp(big.NewInt(rank)) != 0 {
+					t.Errorf("rank_combination(%v, %v) = %v, want %v", c.Indices(), n, got, rank)
+				}
+				unrank_combination(big.NewInt(rank), n, inds)
+				if !reflect.DeepEqual(inds, c.Indices()) {
+					t.Errorf("unrank_combination(%v, %v) = %v, want %v", rank, n, inds, c.Indices())
+				}
+			}
+		}
+	}
+}
+
+func TestCombinationsRankAndUnrank(t *testing.T) {
+	for n := 1; n <= 6; n++ {
+		for k := 1; k <= n; k++ {
+			t.Run(fmt.Sprintf("n=%v, k=%v", n, k), func(t *testing.T) {
+				check_rank_and_unrank(t, func() Rankable[int] {
+					g, _ := NewCombinations(stepped_range(0, n, 1), k)
+					return g
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/natemcintosh/gocombinatorics/group"
+)
+
+// max_symmetry_group_order is the largest symmetry group CombinationsUpToSymmetry will
+// accept, as it keeps every element of the group in memory.
+const max_symmetry_group_order = 1 << 20
+
+// CombinationsUpToSymmetry gives you one combination of length k from each class of
+// combinations that are equivalent under a group of symmetries of the input (such as the
+// rotations of a board). Two combinations are equivalent if some symmetry turns the
+// indices of one into the indices of the other.
+//
+// The combination given for each class is its canonical representative: the one whose
+// sorted indices come first in lexicographic order. They are found by orderly
+// generation: indices are added in increasing order, and a partial combination is
+// dropped as soon as some symmetry maps it to something smaller, because then nothing
+// built from it can be canonical either.
+//
+// `OrbitSize()` tells you how many combinations each representative stands for, so the
+// orbit sizes of all the representatives add up to nchoosek(n, k).
+// CombinationsUpToSymmetry meets the `CombinationLike` interface
+type CombinationsUpToSymmetry[T any] struct {
+	data     []T
+	n, k     int
+	elements [][]int
+	isfirst  bool
+	done     bool
+	inds     []int
+	image    []int
+	orbit    *big.Int
+	buffer   []T
+}
+
+// NewCombinationsUpToSymmetry creates a new CombinationsUpToSymmetry object. Each of
+// generators is a permutation of 0, ..., len(input_data)-1 that is a symmetry of the
+// input: index i is equivalent to index generators[j][i]. Together they can generate a
+// group of at most 2^20 elements.
+func NewCombinationsUpToSymmetry[T any](input_data []T, k int, generators [][]int) (*CombinationsUpToSymmetry[T], error) {
+	data := make([]T, len(input_data))
+	copy(data, input_data)
+	n := len(input_data)
+
+	// Check for cases where we can't do combinations
+	if k > n {
+		return nil, errors.New("k must be less than or equal to len(input_data)")
+	} else if n <= 0 {
+		return nil, errors.New("len(input_data) must be greater than 0")
+	} else if k <= 0 {
+		return nil, errors.New("k must be greater than 0")
+	}
+
+	if len(generators) == 0 {
+		generators = [][]int{stepped_range(0, n, 1)}
+	}
+	g, err := group.NewGroup(generators...)
+	if err != nil {
+		return nil, err
+	}
+	if g.Degree() != n {
+		return nil, errors.New("generators must be permutations of the indices of input_data")
+	}
+	if g.Order().Cmp(big.NewInt(max_symmetry_group_order)) > 0 {
+		return nil, errors.New("the symmetry group has more than 2^20 elements")
+	}
+
+	// Keep every element of the group around, as we check against all of them
+	elements := make([][]int, 0, g.Order().Int64())
+	e := g.Elements()
+	for e.Next() {
+		element := make([]int, n)
+		copy(element, e.Element())
+		elements = append(elements, element)
+	}
+
+	return &CombinationsUpToSymmetry[T]{
+		data:     data,
+		n:        n,
+		k:        k,
+		elements: elements,
+		isfirst:  true,
+		inds:     make([]int, k),
+		image:    make([]int, k),
+		orbit:    big.NewInt(0),
+		buffer:   make([]T, k),
+	}, nil
+}
+
+// Next will move on to the canonical representative of the next class, returning false
+// when there are none left.
+func (c *CombinationsUpToSymmetry[T]) Next() bool {
+	if c.done {
+		return false
+	}
+
+	// t is the position we are currently trying to fill
+	t := c.k - 1
+	if c.isfirst {
+		t = 0
+		c.inds[0] = -1
+		c.isfirst = false
+	}
+
+	for t >= 0 {
+		c.inds[t]++
+		// Leave room for the positions after this one
+		if c.inds[t] > c.n-(c.k-t) {
+			t--
+			continue
+		}
+
+		stabilizer, canonical := c.check_canonical(c.inds[:t+1])
+		if !canonical {
+			continue
+		}
+		if t == c.k-1 {
+			c.orbit.SetInt64(int64(len(c.elements) / stabilizer))
+			return true
+		}
+		t++
+		c.inds[t] = c.inds[t-1]
+	}
+	c.done = true
+	return false
+}
+
+// check_canonical reports whether the sorted indices set are the lexicographically
+// smallest among all their images under the group. If they are, it also returns how
+// many elements of the group map set onto itself.
+func (c *CombinationsUpToSymmetry[T]) check_canonical(set []int) (int, bool) {
+	stabilizer := 0
+	for _, element := range c.elements {
+		image := c.image[:len(set)]
+		for j, i := range set {
+			image[j] = element[i]
+		}
+		insertion_sort(image)
+
+		// Compare the image with the set
+		same := true
+		for j := range set {
+			if image[j] != set[j] {
+				if image[j] < set[j] {
+					return 0, false
+				}
+				same = false
+				break
+			}
+		}
+		if same {
+			stabilizer++
+		}
+	}
+	return stabilizer, true
+}
+
+// insertion_sort sorts a short slice of ints in place without allocating
+func insertion_sort(s []int) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j] < s[j-1]; j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+}
+
+func (c *CombinationsUpToSymmetry[T]) LenInds() int {
+	return c.k
+}
+
+// Indices gives the sorted indices of the canonical representative
+func (c *CombinationsUpToSymmetry[T]) Indices() []int {
+	return c.inds
+}
+
+// Items is how you get the items in this combination. You iterate with `c.Next()`, and
+// then get the combination with `c.Items()`. The data in the slice returned will be
+// overwritten every iteration. If you need to keep the data from each iteration, be
+// sure to make a copy.
+func (c *CombinationsUpToSymmetry[T]) Items() []T {
+	fill_buffer(c.buffer, c.data, c.inds)
+	return c.buffer
+}
+
+// OrbitSize tells you how many combinations are equivalent to the current one
+// (including itself). The value returned will be overwritten every iteration.
+func (c *CombinationsUpToSymmetry[T]) OrbitSize() *big.Int {
+	return c.orbit
+}
@@ -0,0 +1,161 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// square_board_symmetries returns the rotation and reflection of a side x side board,
+// with cells numbered row by row
+func square_board_symmetries(side int) [][]int {
+	rotate := make([]int, side*side)
+	reflect := make([]int, side*side)
+	for r := 0; r < side; r++ {
+		for c := 0; c < side; c++ {
+			rotate[r*side+c] = c*side + (side - 1 - r)
+			reflect[r*side+c] = r*side + (side - 1 - c)
+		}
+	}
+	return [][]int{rotate, reflect}
+}
+
+// orbits_by_brute_force splits all the combinations into classes by applying the
+// generators until nothing new turns up, and returns the smallest member of each class
+// along with its size
+func orbits_by_brute_force(n, k int, generators [][]int) map[string]int {
+	class_of := make(map[string]string)
+	sizes := make(map[string]int)
+	c, _ := NewCombinations(stepped_range(0, n, 1), k)
+	for c.Next() {
+		key := fmt.Sprint(c.Indices())
+		if _, ok := class_of[key]; ok {
+			continue
+		}
+		// The first member we meet is the smallest, as combinations come in order
+		class_of[key] = key
+		queue := [][]int{append([]int{}, c.Indices()...)}
+		for len(queue) > 0 {
+			set := queue[0]
+			queue = queue[1:]
+			sizes[key]++
+			for _, gen := range generators {
+				image := make([]int, k)
+				for j, i := range set {
+					image[j] = gen[i]
+				}
+				insertion_sort(image)
+				image_key := fmt.Sprint(image)
+				if _, ok := class_of[image_key]; !ok {
+					class_of[image_key] = key
+					queue = append(queue, image)
+				}
+			}
+		}
+	}
+	return sizes
+}
+
+func TestNewCombinationsUpToSymmetryErrors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		n    int
+		k    int
+		gens [][]int
+	}{
+		{desc: "n <= 0", n: 0, k: 1},
+		{desc: "k <= 0", n: 3, k: 0},
+		{desc: "k > n", n: 3, k: 4},
+		{desc: "not a permutation", n: 3, k: 1, gens: [][]int{{0, 0, 1}}},
+		{desc: "wrong length", n: 3, k: 1, gens: [][]int{{1, 0}}},
+		{desc: "group too big", n: 12, k: 1, gens: [][]int{{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 0}, {1, 0, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			data := stepped_range(0, tC.n, 1)
+			if _, err := NewCombinationsUpToSymmetry(data, tC.k, tC.gens); err == nil {
+				t.Errorf("NewCombinationsUpToSymmetry() did not return an error")
+			}
+		})
+	}
+}
+
+func TestCombinationsUpToSymmetryNext(t *testing.T) {
+	// Pairs of corners of a square, up to rotation: neighbours, or opposite corners
+	data := []string{"a", "b", "c", "d"}
+	rotate := []int{1, 2, 3, 0}
+	c, err := NewCombinationsUpToSymmetry(data, 2, [][]int{rotate})
+	if err != nil {
+		t.Fatalf("NewCombinationsUpToSymmetry() = %v, want nil", err)
+	}
+	want_items := [][]string{{"a", "b"}, {"a", "c"}}
+	want_orbits := []int64{4, 2}
+	got_items := make([][]string, 0)
+	got_orbits := make([]int64, 0)
+	for c.Next() {
+		items := make([]string, 2)
+		copy(items, c.Items())
+		got_items = append(got_items, items)
+		got_orbits = append(got_orbits, c.OrbitSize().Int64())
+	}
+	if !reflect.DeepEqual(got_items, want_items) {
+		t.Errorf("CombinationsUpToSymmetry = %v, want %v", got_items, want_items)
+	}
+	if !reflect.DeepEqual(got_orbits, want_orbits) {
+		t.Errorf("OrbitSize() = %v, want %v", got_orbits, want_orbits)
+	}
+	if c.Next() {
+		t.Errorf("Next() returned true after the end")
+	}
+}
+
+func TestCombinationsUpToSymmetryAgainstBruteForce(t *testing.T) {
+	testCases := []struct {
+		desc string
+		n    int
+		gens [][]int
+	}{
+		{desc: "no symmetry", n: 6, gens: nil},
+		{desc: "necklace of 8", n: 8, gens: [][]int{{1, 2, 3, 4, 5, 6, 7, 0}}},
+		{desc: "bracelet of 9", n: 9, gens: [][]int{{1, 2, 3, 4, 5, 6, 7, 8, 0}, {0, 8, 7, 6, 5, 4, 3, 2, 1}}},
+		{desc: "3x3 board", n: 9, gens: square_board_symmetries(3)},
+		{desc: "4x4 board", n: 16, gens: square_board_symmetries(4)},
+	}
+	for _, tC := range testCases {
+		for k := 1; k <= tC.n && k <= 6; k++ {
+			run_name := fmt.Sprintf("%v, k=%v", tC.desc, k)
+			t.Run(run_name, func(t *testing.T) {
+				want := orbits_by_brute_force(tC.n, k, tC.gens)
+				c, err := NewCombinationsUpToSymmetry(stepped_range(0, tC.n, 1), k, tC.gens)
+				if err != nil {
+					t.Fatalf("NewCombinationsUpToSymmetry() = %v, want nil", err)
+				}
+				got := make(map[string]int)
+				total := big.NewInt(0)
+				for c.Next() {
+					got[fmt.Sprint(c.Indices())] = int(c.OrbitSize().Int64())
+					total.Add(total, c.OrbitSize())
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("CombinationsUpToSymmetry = %v, want %v", got, want)
+				}
+				if total.Cmp(nchoosek(uint64(tC.n), uint64(k))) != 0 {
+					t.Errorf("Orbit sizes add up to %v, want %v", total, nchoosek(uint64(tC.n), uint64(k)))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkCombinationsUpToSymmetry(b *testing.B) {
+	gens := square_board_symmetries(5)
+	for i := 0; i < b.N; i++ {
+		c, err := NewCombinationsUpToSymmetry(stepped_range(0, 25, 1), 4, gens)
+		if err != nil {
+			b.Errorf("NewCombinationsUpToSymmetry() = %v, want nil", err)
+		}
+		for c.Next() {
+		}
+	}
+}
@@ -2,25 +2,51 @@ package gocombinatorics
 
 import (
 	"errors"
+	"iter"
 	"math/big"
 )
 
 // CombinationsWithReplacement will give you the indices of all possible combinations
 // with replacement of an input slice/array of length n, choosing k elements.
 type CombinationsWithReplacement[T any] struct {
-	data    []T
-	n, k    int
-	Length  *big.Int
+	data source[T]
+	n, k int
+	length
 	inds    []int
 	isfirst bool
 	buffer  []T
 }
 
-// NewCombinationsWithReplacement creates a new instance of CombinationsWithReplacement
-func NewCombinationsWithReplacement[T any](input_data []T, k int) (*CombinationsWithReplacement[T], error) {
-	data := make([]T, len(input_data))
-	copy(data, input_data)
-	n := len(input_data)
+// NewCombinationsWithReplacement creates a new instance of CombinationsWithReplacement.
+// It makes its own copy of input_data, unless you pass `WithNoCopy()`.
+func NewCombinationsWithReplacement[T any](input_data []T, k int, opts ...Option) (*CombinationsWithReplacement[T], error) {
+	return new_combinations_w_replacement(source_from_slice(input_data, opts), k)
+}
+
+// NewCombinationsWithReplacementFromFunc is like NewCombinationsWithReplacement, for n
+// items where at(i) gives the item at index i. Nothing is stored for the items, and at is
+// called for each item every time `Items()` is.
+func NewCombinationsWithReplacementFromFunc[T any](n int, at func(int) T, k int) (*CombinationsWithReplacement[T], error) {
+	src, err := source_from_func(n, at)
+	if err != nil {
+		return nil, err
+	}
+	return new_combinations_w_replacement(src, k)
+}
+
+// NewCombinationsWithReplacementFromSeq is like NewCombinationsWithReplacement, for the
+// first n items that seq gives. seq is only read the first time `Items()` is called,
+// which panics if seq gives fewer than n items.
+func NewCombinationsWithReplacementFromSeq[T any](seq iter.Seq[T], n, k int) (*CombinationsWithReplacement[T], error) {
+	src, err := source_from_seq(seq, n)
+	if err != nil {
+		return nil, err
+	}
+	return new_combinations_w_replacement(src, k)
+}
+
+func new_combinations_w_replacement[T any](data source[T], k int) (*CombinationsWithReplacement[T], error) {
+	n := data.n
 
 	// Check for cases where we can't do combinations with replacement
 	if n <= 0 {
@@ -29,19 +55,18 @@ func NewCombinationsWithReplacement[T any](input_data []T, k int) (*Combinations
 		return nil, errors.New("k must be greater than 0")
 	}
 
-	len := num_combinations_w_replacement(n, k)
+	len := binomial_length(uint64(n+k-1), uint64(k))
 	inds := make([]int, k)
 	isfirst := true
 
 	// Create the buffer
 	buffer := make([]T, k)
-	fill_buffer(buffer, data, inds)
 
 	return &CombinationsWithReplacement[T]{
 		data:    data,
 		n:       n,
 		k:       k,
-		Length:  len,
+		length:  len,
 		inds:    inds,
 		isfirst: isfirst,
 		buffer:  buffer,
@@ -92,30 +117,36 @@ func (c *CombinationsWithReplacement[T]) Indices() []int {
 // overwritten every iteration. If you need to keep the data from each iteration, be
 // sure to make a copy.
 func (c *CombinationsWithReplacement[T]) Items() []T {
-	fill_buffer(c.buffer, c.data, c.inds)
+	c.data.fill(c.buffer, c.inds)
 	return c.buffer
 }
 
-// num_combinations_w_replacement returns (n+k-1)! / (k! * (n-1)!)
-func num_combinations_w_replacement(n, k int) *big.Int {
-	numerator := factorial(int64(n + k - 1))
-	k_fact := factorial(int64(k))
-	n_minus_1_fact := factorial(int64(n - 1))
-	denominator := new(big.Int).Mul(k_fact, n_minus_1_fact)
-	result := new(big.Int)
-	return result.Div(numerator, denominator)
+// Rank returns the position of the current combination in the order that `Next()`
+// generates them, starting at 0. Adding i to the ith index turns the combinations with
+// replacement of k from n into the combinations of k from n+k-1 (stars and bars), in the
+// same order, so the rank is that of the combination.
+func (c *CombinationsWithReplacement[T]) Rank() *big.Int {
+	if c.isfirst {
+		return big.NewInt(0)
+	}
+	spread := make([]int, c.k)
+	for i, v := range c.inds {
+		spread[i] = v + i
+	}
+	return rank_combination(spread, c.n+c.k-1)
 }
 
-// elts_in_combo_w_replacement is how many times we expect to see a given element when
-// carrying out combinations with replacement. In python style code, it is:
-// sum((k-(i-1)*num_combinations_w_replacement(n-1, i-1)) for i in range(1,k+1))
-func elts_in_combo_w_replacement(n, k int) *big.Int {
-	sum := big.NewInt(0)
-	for i := 1; i <= k; i++ {
-		n_cols := big.NewInt(int64(k - (i - 1)))
-		n_rows := num_combinations_w_replacement(n-1, i-1)
-		this_num := new(big.Int).Mul(n_cols, n_rows)
-		sum.Add(sum, this_num)
+// Unrank moves the CombinationsWithReplacement to the combination at position `rank`,
+// so that `Indices()` and `Items()` reflect it. Calling `Next()` afterwards carries on
+// from there.
+func (c *CombinationsWithReplacement[T]) Unrank(rank *big.Int) error {
+	if rank.Sign() < 0 || c.cmp(rank) <= 0 {
+		return errRankOutOfRange
+	}
+	unrank_combination(rank, c.n+c.k-1, c.inds)
+	for i := range c.inds {
+		c.inds[i] -= i
 	}
-	return sum
+	c.isfirst = false
+	return nil
 }
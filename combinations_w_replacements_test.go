@@ -2,6 +2,7 @@ package gocombinatorics
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -172,3 +173,16 @@ func BenchmarkCombinationsWithReplacementNext(b *testing.B) {
 		})
 	}
 }
+
+func TestCombinationsWithReplacementRankAndUnrank(t *testing.T) {
+	for n := 1; n <= 6; n++ {
+		for k := 1; k <= n+1; k++ {
+			t.Run(fmt.Sprintf("n=%v, k=%v", n, k), func(t *testing.T) {
+				check_rank_and_unrank(t, func() Rankable[int] {
+					g, _ := NewCombinationsWithReplacement(stepped_range(0, n, 1), k)
+					return g
+				})
+			})
+		}
+	}
+}
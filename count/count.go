@@ -0,0 +1,136 @@
+// Package count has the numbers that count common combinatorial objects: binomial and
+// multinomial coefficients, factorials, Stirling, Bell, Catalan, Fubini and Lah numbers,
+// derangements and integer partitions. Every result is an exact *big.Int that belongs
+// to the caller.
+//
+// The numbers defined by recurrences (Stirling, Bell, Fubini, subfactorials and
+// partition numbers) build up a table of smaller values on the way. The functions in
+// this package throw that table away when they return. To keep it between calls, which
+// makes asking for many values much cheaper, use a `Table` instead.
+//
+// Arguments that are out of range (such as k > n, or anything negative) give 0 rather
+// than an error, as there are no objects of that kind to count.
+package count
+
+import "math/big"
+
+// Factorial returns n! = 1 * 2 * ... * n, with 0! = 1
+func Factorial(n int) *big.Int {
+	if n < 0 {
+		return big.NewInt(0)
+	}
+	return new(big.Int).MulRange(1, int64(n))
+}
+
+// Binomial returns n choose k: how many ways there are to pick k things out of n,
+// ignoring their order
+func Binomial(n, k int) *big.Int {
+	if n < 0 || k < 0 || k > n {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Binomial(int64(n), int64(k))
+}
+
+// Multinomial returns (k1 + k2 + ...)! / (k1! k2! ...): how many ways there are to put
+// things into groups of sizes k1, k2, ..., where the groups are told apart but the
+// order within each group is not. It is built up as a product of binomials, so no
+// factorials are ever computed.
+func Multinomial(ks ...int) *big.Int {
+	result := big.NewInt(1)
+	total := 0
+	for _, k := range ks {
+		if k < 0 {
+			return big.NewInt(0)
+		}
+		total += k
+		result.Mul(result, Binomial(total, k))
+	}
+	return result
+}
+
+// FallingFactorial returns n * (n-1) * ... * (n-k+1): how many ways there are to pick k
+// things out of n in order
+func FallingFactorial(n, k int) *big.Int {
+	if n < 0 || k < 0 || k > n {
+		return big.NewInt(0)
+	} else if k == 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).MulRange(int64(n-k+1), int64(n))
+}
+
+// RisingFactorial returns n * (n+1) * ... * (n+k-1)
+func RisingFactorial(n, k int) *big.Int {
+	if n < 0 || k < 0 {
+		return big.NewInt(0)
+	} else if k == 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).MulRange(int64(n), int64(n+k-1))
+}
+
+// Catalan returns the nth Catalan number, (2n choose n) / (n+1): the number of binary
+// trees with n nodes, balanced strings of n pairs of brackets, and many more
+func Catalan(n int) *big.Int {
+	if n < 0 {
+		return big.NewInt(0)
+	}
+	c := Binomial(2*n, n)
+	return c.Quo(c, big.NewInt(int64(n+1)))
+}
+
+// Lah returns the unsigned Lah number L(n, k): how many ways there are to split n
+// things into k non-empty lists, where the order of the lists does not matter. It is
+// (n-1 choose k-1) * n! / k!.
+func Lah(n, k int) *big.Int {
+	if n < 0 || k < 0 || k > n {
+		return big.NewInt(0)
+	} else if n == 0 {
+		return big.NewInt(1)
+	} else if k == 0 {
+		return big.NewInt(0)
+	}
+	// n! / k! is the rising factorial (k+1) * ... * n
+	result := Binomial(n-1, k-1)
+	if k < n {
+		result.Mul(result, new(big.Int).MulRange(int64(k+1), int64(n)))
+	}
+	return result
+}
+
+// Stirling1 returns the unsigned Stirling number of the first kind: how many
+// permutations of n things have exactly k cycles
+func Stirling1(n, k int) *big.Int {
+	return NewTable().Stirling1(n, k)
+}
+
+// Stirling2 returns the Stirling number of the second kind: how many ways there are to
+// split n things into k non-empty sets
+func Stirling2(n, k int) *big.Int {
+	return NewTable().Stirling2(n, k)
+}
+
+// Bell returns the nth Bell number: how many ways there are to split n things into
+// non-empty sets
+func Bell(n int) *big.Int {
+	return NewTable().Bell(n)
+}
+
+// Fubini returns the nth Fubini number (ordered Bell number): how many ways there are
+// to split n things into a list of non-empty sets, or equivalently how many ways n
+// runners can finish a race when ties are allowed
+func Fubini(n int) *big.Int {
+	return NewTable().Fubini(n)
+}
+
+// Subfactorial returns !n, the number of derangements of n things: permutations that
+// leave nothing where it was
+func Subfactorial(n int) *big.Int {
+	return NewTable().Subfactorial(n)
+}
+
+// PartitionNumber returns p(n), the number of ways to write n as a sum of positive
+// integers, ignoring their order
+func PartitionNumber(n int) *big.Int {
+	return NewTable().PartitionNumber(n)
+}
@@ -0,0 +1,190 @@
+package count
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+)
+
+func ints(values ...int64) []*big.Int {
+	result := make([]*big.Int, len(values))
+	for i, v := range values {
+		result[i] = big.NewInt(v)
+	}
+	return result
+}
+
+func TestSequences(t *testing.T) {
+	testCases := []struct {
+		desc string
+		f    func(n int) *big.Int
+		want []*big.Int
+	}{
+		{desc: "Factorial", f: Factorial, want: ints(1, 1, 2, 6, 24, 120, 720)},
+		{desc: "Catalan", f: Catalan, want: ints(1, 1, 2, 5, 14, 42, 132, 429)},
+		{desc: "Bell", f: Bell, want: ints(1, 1, 2, 5, 15, 52, 203, 877, 4140)},
+		{desc: "Fubini", f: Fubini, want: ints(1, 1, 3, 13, 75, 541, 4683, 47293)},
+		{desc: "Subfactorial", f: Subfactorial, want: ints(1, 0, 1, 2, 9, 44, 265, 1854)},
+		{desc: "PartitionNumber", f: PartitionNumber, want: ints(1, 1, 2, 3, 5, 7, 11, 15, 22, 30, 42, 56)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			for n, want := range tC.want {
+				if got := tC.f(n); got.Cmp(want) != 0 {
+					t.Errorf("%v(%v) = %v, want %v", tC.desc, n, got, want)
+				}
+			}
+			if got := tC.f(-1); got.Sign() != 0 {
+				t.Errorf("%v(-1) = %v, want 0", tC.desc, got)
+			}
+		})
+	}
+}
+
+func TestTriangles(t *testing.T) {
+	testCases := []struct {
+		desc string
+		f    func(n, k int) *big.Int
+		n    int
+		want []*big.Int
+	}{
+		{desc: "Binomial", f: Binomial, n: 6, want: ints(1, 6, 15, 20, 15, 6, 1)},
+		{desc: "FallingFactorial", f: FallingFactorial, n: 5, want: ints(1, 5, 20, 60, 120, 120)},
+		{desc: "RisingFactorial", f: RisingFactorial, n: 3, want: ints(1, 3, 12, 60, 360, 2520)},
+		{desc: "Stirling1", f: Stirling1, n: 5, want: ints(0, 24, 50, 35, 10, 1)},
+		{desc: "Stirling2", f: Stirling2, n: 5, want: ints(0, 1, 15, 25, 10, 1)},
+		{desc: "Lah", f: Lah, n: 5, want: ints(0, 120, 240, 120, 20, 1)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			for k, want := range tC.want {
+				if got := tC.f(tC.n, k); got.Cmp(want) != 0 {
+					t.Errorf("%v(%v, %v) = %v, want %v", tC.desc, tC.n, k, got, want)
+				}
+			}
+			if got := tC.f(tC.n, -1); got.Sign() != 0 {
+				t.Errorf("%v(%v, -1) = %v, want 0", tC.desc, tC.n, got)
+			}
+		})
+	}
+}
+
+func TestMultinomial(t *testing.T) {
+	testCases := []struct {
+		desc string
+		ks   []int
+		want int64
+	}{
+		{desc: "nothing", ks: []int{}, want: 1},
+		{desc: "one group", ks: []int{5}, want: 1},
+		{desc: "a binomial", ks: []int{2, 3}, want: 10},
+		{desc: "MISSISSIPPI", ks: []int{1, 4, 4, 2}, want: 34650},
+		{desc: "negative", ks: []int{2, -1}, want: 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := Multinomial(tC.ks...); got.Cmp(big.NewInt(tC.want)) != 0 {
+				t.Errorf("Multinomial(%v) = %v, want %v", tC.ks, got, tC.want)
+			}
+		})
+	}
+}
+
+func TestIdentities(t *testing.T) {
+	table := NewTable()
+	for n := 0; n <= 30; n++ {
+		stirling1_sum, stirling2_sum, ordered := big.NewInt(0), big.NewInt(0), big.NewInt(0)
+		for k := 0; k <= n; k++ {
+			stirling1_sum.Add(stirling1_sum, table.Stirling1(n, k))
+			s2 := table.Stirling2(n, k)
+			stirling2_sum.Add(stirling2_sum, s2)
+			ordered.Add(ordered, s2.Mul(s2, Factorial(k)))
+		}
+		if stirling1_sum.Cmp(Factorial(n)) != 0 {
+			t.Errorf("Stirling1(%v, k) adds up to %v, want %v!", n, stirling1_sum, n)
+		}
+		if bell := table.Bell(n); stirling2_sum.Cmp(bell) != 0 {
+			t.Errorf("Stirling2(%v, k) adds up to %v, want Bell(%v) = %v", n, stirling2_sum, n, bell)
+		}
+		if fubini := table.Fubini(n); ordered.Cmp(fubini) != 0 {
+			t.Errorf("k! Stirling2(%v, k) adds up to %v, want Fubini(%v) = %v", n, ordered, n, fubini)
+		}
+		// Every permutation deranges the things it moves: n! = sum of (n choose k) !k
+		total := big.NewInt(0)
+		for k := 0; k <= n; k++ {
+			total.Add(total, new(big.Int).Mul(Binomial(n, k), table.Subfactorial(k)))
+		}
+		if total.Cmp(Factorial(n)) != 0 {
+			t.Errorf("sum of (%v choose k) !k = %v, want %v!", n, total, n)
+		}
+	}
+}
+
+func TestLahSums(t *testing.T) {
+	// The number of ways to split n things into any number of lists
+	want := ints(1, 1, 3, 13, 73, 501, 4051, 37633)
+	for n, w := range want {
+		sum := big.NewInt(0)
+		for k := 0; k <= n; k++ {
+			sum.Add(sum, Lah(n, k))
+		}
+		if sum.Cmp(w) != 0 {
+			t.Errorf("Lah(%v, k) adds up to %v, want %v", n, sum, w)
+		}
+	}
+}
+
+func TestPartitionNumberLarge(t *testing.T) {
+	want, _ := new(big.Int).SetString("190569292", 10)
+	if got := PartitionNumber(100); got.Cmp(want) != 0 {
+		t.Errorf("PartitionNumber(100) = %v, want %v", got, want)
+	}
+	want, _ = new(big.Int).SetString("24061467864032622473692149727991", 10)
+	if got := PartitionNumber(1000); got.Cmp(want) != 0 {
+		t.Errorf("PartitionNumber(1000) = %v, want %v", got, want)
+	}
+}
+
+func TestTableResultsAreCopies(t *testing.T) {
+	table := NewTable()
+	table.Bell(5).SetInt64(0)
+	table.Stirling2(5, 2).SetInt64(0)
+	if got := table.Bell(5); got.Cmp(big.NewInt(52)) != 0 {
+		t.Errorf("Bell(5) after changing an earlier result = %v, want 52", got)
+	}
+	if got := table.Stirling2(5, 2); got.Cmp(big.NewInt(15)) != 0 {
+		t.Errorf("Stirling2(5, 2) after changing an earlier result = %v, want 15", got)
+	}
+}
+
+func TestTableConcurrent(t *testing.T) {
+	table := NewTable()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := g; n < 60; n += 3 {
+				table.Stirling2(n, n/2)
+				table.PartitionNumber(n * 10)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if got, want := table.Stirling2(40, 20), Stirling2(40, 20); got.Cmp(want) != 0 {
+		t.Errorf("Stirling2(40, 20) = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkStirling2(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Stirling2(200, 100)
+	}
+}
+
+func BenchmarkTableStirling2(b *testing.B) {
+	table := NewTable()
+	for i := 0; i < b.N; i++ {
+		table.Stirling2(200, 100)
+	}
+}
@@ -0,0 +1,415 @@
+package count
+
+import (
+	"errors"
+	"math/bits"
+	"sync"
+)
+
+// max_mod_table_size is the largest prime power BinomialMod makes a table of units for.
+// Bigger prime powers are handled without a table, which is slower.
+const max_mod_table_size = 1 << 20
+
+var errZeroModulus = errors.New("m must be greater than 0")
+
+// BinomialMod returns n choose k modulo m, for any n up to 2^64 - 1, without working out
+// n choose k itself.
+//
+// m is split into prime powers p^e, and the results for each are put back together
+// with the Chinese remainder theorem. For each p^e, the power of p dividing n choose k
+// is found by counting carries (Kummer's theorem), and what is left is a product of
+// factorials with the multiples of p taken out. Those are worked out from a table of the
+// products of the units below p^e, as in Granville's extension of Lucas' theorem. This
+// takes about p^e + e * log_p(n) steps. The last few tables are kept, so more binomials
+// modulo the same m only take the e * log_p(n) part.
+//
+// If p^e is bigger than 2^20, no table is made. For a prime, Lucas' theorem splits n
+// and k into base p digits, and each digit's binomial is worked out as a product of
+// min(k_i, n_i-k_i) terms, which can be up to p/2 for each digit. For a bigger power of a
+// prime, the whole product of min(k, n-k) terms is worked out. So for a large prime,
+// this can be slow even when k is small: BinomialMod(10^18, 123456789, 998244353) takes
+// seconds. For many binomials modulo one prime, a `ModTable` of size p-1 takes a couple
+// of multiplications for each base p digit instead, if a table that size fits in memory.
+func BinomialMod(n, k, m uint64) (uint64, error) {
+	if m == 0 {
+		return 0, errZeroModulus
+	}
+	if k > n || m == 1 {
+		return 0, nil
+	}
+
+	// Put the result for each prime power together with the Chinese remainder theorem.
+	// x is the answer so far modulo M, the product of the prime powers done so far.
+	x, M := uint64(0), uint64(1)
+	for _, f := range factorize(m) {
+		r := binomial_prime_power(n, k, f)
+		// Find t with x + M*t = r (mod q)
+		diff := (r + f.q - x%f.q) % f.q
+		t := mul_mod(diff, f.inverse(M%f.q), f.q)
+		x += M * t
+		M *= f.q
+	}
+	return x, nil
+}
+
+// MultinomialMod returns the multinomial coefficient (k1 + k2 + ...)! / (k1! k2! ...)
+// modulo m, as a product of binomials from BinomialMod. The sum of ks must fit in a
+// uint64.
+func MultinomialMod(m uint64, ks ...uint64) (uint64, error) {
+	if m == 0 {
+		return 0, errZeroModulus
+	}
+	result, total := uint64(1)%m, uint64(0)
+	for _, k := range ks {
+		var carry uint64
+		total, carry = bits.Add64(total, k, 0)
+		if carry != 0 {
+			return 0, errors.New("the sum of ks must fit in a uint64")
+		}
+		b, _ := BinomialMod(total, k, m)
+		result = mul_mod(result, b, m)
+	}
+	return result, nil
+}
+
+// prime_power is one factor p^e of a modulus, with q = p^e
+type prime_power struct {
+	p, q uint64
+	e    int
+}
+
+// inverse returns the inverse of a modulo q, which must not be a multiple of p. By
+// Euler's theorem it is a^(phi(q) - 1), where phi(q) = q / p * (p - 1).
+func (f prime_power) inverse(a uint64) uint64 {
+	return pow_mod(a, f.q/f.p*(f.p-1)-1, f.q)
+}
+
+// binomial_prime_power returns n choose k modulo p^e, for k <= n
+func binomial_prime_power(n, k uint64, f prime_power) uint64 {
+	// Kummer: the power of p dividing n choose k is the number of carries when adding k
+	// and n-k in base p
+	v := 0
+	for a, b, c := n/f.p, k/f.p, (n-k)/f.p; a > 0; a, b, c = a/f.p, b/f.p, c/f.p {
+		v += int(a - b - c)
+	}
+	if v >= f.e {
+		return 0
+	}
+	p_v := pow_mod(f.p, uint64(v), f.q)
+
+	if f.q <= max_mod_table_size {
+		units := units_table(f)
+		num := factorial_without_p(n, f, units)
+		den := mul_mod(factorial_without_p(k, f, units), factorial_without_p(n-k, f, units), f.q)
+		return mul_mod(mul_mod(num, f.inverse(den), f.q), p_v, f.q)
+	}
+
+	if f.e == 1 {
+		// Lucas: multiply the binomials of the base p digits
+		result := uint64(1)
+		for ; n > 0 && result != 0; n, k = n/f.p, k/f.p {
+			result = mul_mod(result, binomial_by_product(n%f.p, k%f.p, f), f.q)
+		}
+		return result
+	}
+	return binomial_by_product(n, k, f)
+}
+
+// units_cache keeps the most recently used units tables, so that asking for many
+// binomials modulo the same m does not build them again every time
+var units_cache = struct {
+	sync.Mutex
+	tables map[uint64][]uint32
+}{tables: make(map[uint64][]uint32)}
+
+// max_cached_units_tables is how many units tables units_cache keeps
+const max_cached_units_tables = 4
+
+// units_table returns t where t[i] is the product of the numbers in 1, ..., i that are
+// not multiples of p, modulo q. As q is at most 2^20, the entries fit in a uint32.
+func units_table(f prime_power) []uint32 {
+	units_cache.Lock()
+	defer units_cache.Unlock()
+	if t, ok := units_cache.tables[f.q]; ok {
+		return t
+	}
+
+	t := make([]uint32, f.q)
+	t[0] = uint32(1 % f.q)
+	for i := uint64(1); i < f.q; i++ {
+		t[i] = t[i-1]
+		if i%f.p != 0 {
+			t[i] = uint32(uint64(t[i]) * i % f.q)
+		}
+	}
+	if len(units_cache.tables) >= max_cached_units_tables {
+		for q := range units_cache.tables {
+			delete(units_cache.tables, q)
+			break
+		}
+	}
+	units_cache.tables[f.q] = t
+	return t
+}
+
+// factorial_without_p returns x! with every factor of p taken out, modulo q. The
+// numbers up to x that are not multiples of p come in whole blocks of q, each with the
+// same product, and then a partial block. The multiples of p, divided by p, are the
+// numbers up to x/p, so the rest is the same problem for x/p.
+func factorial_without_p(x uint64, f prime_power, units []uint32) uint64 {
+	block := uint64(units[f.q-1])
+	result := 1 % f.q
+	for ; x > 0; x /= f.p {
+		result = mul_mod(result, pow_mod(block, x/f.q, f.q), f.q)
+		result = mul_mod(result, uint64(units[x%f.q]), f.q)
+	}
+	return result
+}
+
+// binomial_by_product returns n choose k modulo p^e by multiplying up
+// (n-k+1)/1 * (n-k+2)/2 * ..., keeping the factors of p to one side
+func binomial_by_product(n, k uint64, f prime_power) uint64 {
+	if k > n {
+		return 0
+	}
+	k = min(k, n-k)
+	num, den := 1%f.q, 1%f.q
+	v := 0
+	for i := uint64(1); i <= k; i++ {
+		a, b := n-k+i, i
+		for a%f.p == 0 {
+			a /= f.p
+			v++
+		}
+		for b%f.p == 0 {
+			b /= f.p
+			v--
+		}
+		num = mul_mod(num, a%f.q, f.q)
+		den = mul_mod(den, b%f.q, f.q)
+	}
+	if v >= f.e {
+		return 0
+	}
+	result := mul_mod(num, f.inverse(den), f.q)
+	return mul_mod(result, pow_mod(f.p, uint64(v), f.q), f.q)
+}
+
+func mul_mod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+func pow_mod(a, e, m uint64) uint64 {
+	result := 1 % m
+	a %= m
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result = mul_mod(result, a, m)
+		}
+		a = mul_mod(a, a, m)
+	}
+	return result
+}
+
+// factorize splits m into its prime powers, in increasing order of the primes
+func factorize(m uint64) []prime_power {
+	primes := make(map[uint64]int)
+	var split func(m uint64)
+	split = func(m uint64) {
+		if m == 1 {
+			return
+		} else if is_prime(m) {
+			primes[m]++
+			return
+		}
+		d := pollard_rho(m)
+		split(d)
+		split(m / d)
+	}
+
+	// Take out the small primes directly
+	for p := uint64(2); p < 1000 && p*p <= m; p++ {
+		for m%p == 0 {
+			primes[p]++
+			m /= p
+		}
+	}
+	split(m)
+
+	result := make([]prime_power, 0, len(primes))
+	for p, e := range primes {
+		q := uint64(1)
+		for i := 0; i < e; i++ {
+			q *= p
+		}
+		result = append(result, prime_power{p: p, q: q, e: e})
+	}
+	// Insertion sort, as there are at most 15 distinct primes
+	for i := 1; i < len(result); i++ {
+		for j := i; j > 0 && result[j].p < result[j-1].p; j-- {
+			result[j], result[j-1] = result[j-1], result[j]
+		}
+	}
+	return result
+}
+
+// is_prime is the Miller-Rabin test with a set of bases that is known to give the right
+// answer for every n < 2^64
+func is_prime(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	bases := []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+	for _, p := range bases {
+		if n%p == 0 {
+			return n == p
+		}
+	}
+	d, s := n-1, 0
+	for d%2 == 0 {
+		d /= 2
+		s++
+	}
+	for _, a := range bases {
+		x := pow_mod(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		composite := true
+		for i := 1; i < s && composite; i++ {
+			x = mul_mod(x, x, n)
+			composite = x != n-1
+		}
+		if composite {
+			return false
+		}
+	}
+	return true
+}
+
+// pollard_rho finds a factor of the composite n, other than 1 and n, using Pollard's
+// rho method with Brent's cycle finding
+func pollard_rho(n uint64) uint64 {
+	if n%2 == 0 {
+		return 2
+	}
+	for c := uint64(1); ; c++ {
+		f := func(x uint64) uint64 { return (mul_mod(x, x, n) + c) % n }
+		x, y, d := uint64(2), uint64(2), uint64(1)
+		power, steps := 1, 0
+		for d == 1 {
+			if steps == power {
+				// Move the tortoise up to the hare, and double how far the hare can go
+				x, power, steps = y, power*2, 0
+			}
+			y = f(y)
+			steps++
+			d = gcd(max(x, y)-min(x, y), n)
+		}
+		if d != n {
+			return d
+		}
+	}
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// ModTable holds the factorials and their inverses modulo a prime m, so that binomial
+// and multinomial coefficients modulo m of numbers up to its size take a couple of
+// multiplications each. Create it with `NewModTable`. A table of size m-1 gives the
+// binomials of every n, with Lucas' theorem.
+type ModTable struct {
+	m        uint64
+	fact     []uint64
+	inv_fact []uint64
+}
+
+// NewModTable makes a table of the factorials 0!, ..., size! modulo m and their
+// inverses. m must be a prime bigger than size, so that every factorial has an inverse.
+func NewModTable(size int, m uint64) (*ModTable, error) {
+	if size < 0 {
+		return nil, errors.New("size must be greater than or equal to 0")
+	} else if !is_prime(m) {
+		return nil, errors.New("m must be prime")
+	} else if uint64(size) >= m {
+		return nil, errors.New("size must be less than m")
+	}
+	fact := make([]uint64, size+1)
+	inv_fact := make([]uint64, size+1)
+	fact[0] = 1
+	for i := 1; i <= size; i++ {
+		fact[i] = mul_mod(fact[i-1], uint64(i), m)
+	}
+	// Only one inverse is needed: 1/(i-1)! = i / i!
+	inv_fact[size] = pow_mod(fact[size], m-2, m)
+	for i := size; i > 0; i-- {
+		inv_fact[i-1] = mul_mod(inv_fact[i], uint64(i), m)
+	}
+	return &ModTable{m: m, fact: fact, inv_fact: inv_fact}, nil
+}
+
+var errBeyondModTable = errors.New("n must be at most the size of the table")
+
+// Factorial returns n! modulo m. n must be at most the size of the table.
+func (t *ModTable) Factorial(n int) (uint64, error) {
+	if n < 0 || n >= len(t.fact) {
+		return 0, errBeyondModTable
+	}
+	return t.fact[n], nil
+}
+
+// InverseFactorial returns the inverse of n! modulo m. n must be at most the size of
+// the table.
+func (t *ModTable) InverseFactorial(n int) (uint64, error) {
+	if n < 0 || n >= len(t.fact) {
+		return 0, errBeyondModTable
+	}
+	return t.inv_fact[n], nil
+}
+
+// Binomial returns n choose k modulo m. If n is at least m, Lucas' theorem splits n and
+// k into base m digits, and multiplies the binomials of the digits. Each digit of n must
+// be at most the size of the table, which a table of size m-1 always allows.
+func (t *ModTable) Binomial(n, k int) (uint64, error) {
+	if n < 0 {
+		return 0, errBeyondModTable
+	} else if k < 0 || k > n {
+		return 0, nil
+	}
+	result := uint64(1)
+	for a, b := uint64(n), uint64(k); a > 0; a, b = a/t.m, b/t.m {
+		a_i, b_i := a%t.m, b%t.m
+		if a_i >= uint64(len(t.fact)) {
+			return 0, errBeyondModTable
+		} else if b_i > a_i {
+			return 0, nil
+		}
+		digit := mul_mod(t.inv_fact[b_i], t.inv_fact[a_i-b_i], t.m)
+		result = mul_mod(result, mul_mod(t.fact[a_i], digit, t.m), t.m)
+	}
+	return result, nil
+}
+
+// Multinomial returns (k1 + k2 + ...)! / (k1! k2! ...) modulo m. The sum of ks must be
+// at most the size of the table.
+func (t *ModTable) Multinomial(ks ...int) (uint64, error) {
+	total := 0
+	result := uint64(1)
+	for _, k := range ks {
+		if k < 0 {
+			return 0, nil
+		}
+		total += k
+		if total >= len(t.fact) {
+			return 0, errBeyondModTable
+		}
+		result = mul_mod(result, t.inv_fact[k], t.m)
+	}
+	return mul_mod(result, t.fact[total], t.m), nil
+}
@@ -0,0 +1,207 @@
+package count
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func big_binomial_mod(n, k, m uint64) uint64 {
+	b := new(big.Int).Binomial(int64(n), int64(k))
+	if k > n {
+		b.SetInt64(0)
+	}
+	return b.Mod(b, new(big.Int).SetUint64(m)).Uint64()
+}
+
+// moduli covers small and large primes, prime powers (both with and without a table)
+// and composites
+var moduli = []uint64{
+	1, 2, 3, 4, 8, 16, 1024, 9, 27, 25, 97, 6, 12, 30, 100, 360, 1_000_000_007, 998_244_353,
+	1<<61 - 1, 1_000_003 * 1_000_003, 7 * 7 * 7 * 1_000_003, 3 * 1 << 20, 1<<64 - 1, 1<<63 + 1,
+}
+
+func TestBinomialModSmall(t *testing.T) {
+	for _, m := range moduli {
+		for n := uint64(0); n <= 40; n++ {
+			for k := uint64(0); k <= n+1; k++ {
+				got, err := BinomialMod(n, k, m)
+				if err != nil {
+					t.Fatalf("BinomialMod(%v, %v, %v) = %v, want nil", n, k, m, err)
+				}
+				if want := big_binomial_mod(n, k, m); got != want {
+					t.Errorf("BinomialMod(%v, %v, %v) = %v, want %v", n, k, m, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestBinomialModHugeN(t *testing.T) {
+	// With k small, big.Int can still work out n choose k directly
+	ns := []uint64{1_000_000_000_000_000_000, 1<<64 - 1, 123_456_789_012_345_678, 1 << 40}
+	for _, m := range moduli {
+		for _, n := range ns {
+			for _, k := range []uint64{0, 1, 2, 3, 7, 20} {
+				got, _ := BinomialMod(n, k, m)
+				want := new(big.Int).SetInt64(1)
+				for i := uint64(0); i < k; i++ {
+					want.Mul(want, new(big.Int).SetUint64(n-i))
+				}
+				want.Quo(want, new(big.Int).MulRange(1, int64(k)))
+				want.Mod(want, new(big.Int).SetUint64(m))
+				if got != want.Uint64() {
+					t.Errorf("BinomialMod(%v, %v, %v) = %v, want %v", n, k, m, got, want)
+				}
+				// n choose k is the same as n choose n-k
+				if got2, _ := BinomialMod(n, n-k, m); got2 != got {
+					t.Errorf("BinomialMod(%v, %v, %v) = %v, want %v", n, n-k, m, got2, got)
+				}
+			}
+		}
+	}
+}
+
+func TestBinomialModLucas(t *testing.T) {
+	// Lucas' theorem: n choose k mod p is the product of the binomials of the base p digits
+	for _, p := range []uint64{2, 3, 5, 13, 101} {
+		for _, n := range []uint64{1_000_000_000_000_000_000, 987_654_321_987_654_321} {
+			for _, k := range []uint64{12_345_678_901_234, 500_000_000_000_000_000, 3} {
+				want := uint64(1)
+				for a, b := n, k; a > 0; a, b = a/p, b/p {
+					want = want * big_binomial_mod(a%p, b%p, p) % p
+				}
+				if got, _ := BinomialMod(n, k, p); got != want {
+					t.Errorf("BinomialMod(%v, %v, %v) = %v, want %v", n, k, p, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestBinomialModErrors(t *testing.T) {
+	if _, err := BinomialMod(5, 2, 0); err == nil {
+		t.Errorf("BinomialMod() with m = 0 did not return an error")
+	}
+	if _, err := MultinomialMod(0, 1, 2); err == nil {
+		t.Errorf("MultinomialMod() with m = 0 did not return an error")
+	}
+	if _, err := MultinomialMod(7, math.MaxUint64, 1); err == nil {
+		t.Errorf("MultinomialMod() with an overflowing sum did not return an error")
+	}
+}
+
+func TestMultinomialMod(t *testing.T) {
+	for _, m := range moduli {
+		ks := []uint64{3, 5, 0, 7, 2}
+		want := Multinomial(3, 5, 0, 7, 2)
+		want.Mod(want, new(big.Int).SetUint64(m))
+		if got, _ := MultinomialMod(m, ks...); got != want.Uint64() {
+			t.Errorf("MultinomialMod(%v, %v) = %v, want %v", m, ks, got, want)
+		}
+	}
+}
+
+func TestFactorize(t *testing.T) {
+	for _, m := range moduli[1:] {
+		product := uint64(1)
+		for _, f := range factorize(m) {
+			if !is_prime(f.p) {
+				t.Errorf("factorize(%v) has %v, which is not prime", m, f.p)
+			}
+			product *= f.q
+		}
+		if product != m {
+			t.Errorf("factorize(%v) multiplies back up to %v", m, product)
+		}
+	}
+}
+
+func TestModTable(t *testing.T) {
+	const m = 1_000_000_007
+	table, err := NewModTable(1000, m)
+	if err != nil {
+		t.Fatalf("NewModTable() = %v, want nil", err)
+	}
+	for _, nk := range [][2]int{{0, 0}, {10, 3}, {1000, 500}, {999, 1}, {5, 6}} {
+		got, err := table.Binomial(nk[0], nk[1])
+		if err != nil {
+			t.Fatalf("Binomial(%v, %v) = %v, want nil", nk[0], nk[1], err)
+		}
+		if want := big_binomial_mod(uint64(nk[0]), uint64(nk[1]), m); got != want {
+			t.Errorf("Binomial(%v, %v) = %v, want %v", nk[0], nk[1], got, want)
+		}
+	}
+
+	f, _ := table.Factorial(500)
+	inv, _ := table.InverseFactorial(500)
+	if mul_mod(f, inv, m) != 1 {
+		t.Errorf("Factorial(500) * InverseFactorial(500) = %v, want 1", mul_mod(f, inv, m))
+	}
+
+	want := Multinomial(100, 200, 300)
+	want.Mod(want, big.NewInt(m))
+	if got, _ := table.Multinomial(100, 200, 300); got != want.Uint64() {
+		t.Errorf("Multinomial(100, 200, 300) = %v, want %v", got, want)
+	}
+
+	if _, err := table.Binomial(1001, 3); err == nil {
+		t.Errorf("Binomial() beyond the table did not return an error")
+	}
+	if _, err := table.Multinomial(600, 600); err == nil {
+		t.Errorf("Multinomial() beyond the table did not return an error")
+	}
+}
+
+func TestModTableLucas(t *testing.T) {
+	// A table of size p-1 gives every binomial modulo p
+	for _, p := range []uint64{2, 13, 101} {
+		table, err := NewModTable(int(p-1), p)
+		if err != nil {
+			t.Fatalf("NewModTable(%v, %v) = %v, want nil", p-1, p, err)
+		}
+		for _, nk := range [][2]int{{0, 0}, {int(p), 1}, {1_000_000, 3_456}, {987_654_321, 123_456_789}, {5_000, 6_000}} {
+			got, err := table.Binomial(nk[0], nk[1])
+			if err != nil {
+				t.Fatalf("Binomial(%v, %v) = %v, want nil", nk[0], nk[1], err)
+			}
+			if want, _ := BinomialMod(uint64(nk[0]), uint64(nk[1]), p); got != want {
+				t.Errorf("NewModTable(%v, %v).Binomial(%v, %v) = %v, want %v", p-1, p, nk[0], nk[1], got, want)
+			}
+		}
+	}
+
+	// A smaller table works as long as every base p digit of n fits in it
+	table, _ := NewModTable(5, 101)
+	if got, err := table.Binomial(3*101*101+4, 2*101+1); err != nil || got != big_binomial_mod(3*101*101+4, 2*101+1, 101) {
+		t.Errorf("Binomial() with small digits = %v, %v, want %v, nil", got, err, big_binomial_mod(3*101*101+4, 2*101+1, 101))
+	}
+	if _, err := table.Binomial(6*101+4, 3); err == nil {
+		t.Errorf("Binomial() with a digit beyond the table did not return an error")
+	}
+}
+
+func TestNewModTableErrors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		size int
+		m    uint64
+	}{
+		{desc: "not prime", size: 10, m: 15},
+		{desc: "too big for the prime", size: 13, m: 13},
+		{desc: "negative size", size: -1, m: 13},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := NewModTable(tC.size, tC.m); err == nil {
+				t.Errorf("NewModTable() did not return an error")
+			}
+		})
+	}
+}
+
+func BenchmarkBinomialModPrime(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		BinomialMod(1_000_000_000_000_000_000, 123_456_789_123, 1_000_003)
+	}
+}
@@ -0,0 +1,181 @@
+package count
+
+import (
+	"math/big"
+	"sync"
+)
+
+// Table remembers the values it has worked out for the numbers defined by recurrences,
+// so asking for them again, or for smaller ones, is cheap. Create it with `NewTable`. A
+// Table is safe to use from several goroutines at once.
+//
+// The memory used grows with the largest n asked for: the Stirling tables keep every
+// row up to n, so they hold about n^2 / 2 numbers.
+type Table struct {
+	mu        sync.Mutex
+	stirling1 [][]*big.Int
+	stirling2 [][]*big.Int
+	bell      []*big.Int
+	fubini    []*big.Int
+	derange   []*big.Int
+	partition []*big.Int
+}
+
+// NewTable creates an empty Table
+func NewTable() *Table {
+	return &Table{}
+}
+
+// Stirling1 is like the package level Stirling1, but remembers the rows it works out
+func (t *Table) Stirling1(n, k int) *big.Int {
+	if n < 0 || k < 0 || k > n {
+		return big.NewInt(0)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// c(i, j) = c(i-1, j-1) + (i-1) c(i-1, j)
+	t.stirling1 = extend_triangle(t.stirling1, n, func(prev []*big.Int, i, j int) *big.Int {
+		v := new(big.Int).Mul(big.NewInt(int64(i-1)), prev[j])
+		return v.Add(v, prev[j-1])
+	})
+	return new(big.Int).Set(t.stirling1[n][k])
+}
+
+// Stirling2 is like the package level Stirling2, but remembers the rows it works out
+func (t *Table) Stirling2(n, k int) *big.Int {
+	if n < 0 || k < 0 || k > n {
+		return big.NewInt(0)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// S(i, j) = j S(i-1, j) + S(i-1, j-1)
+	t.stirling2 = extend_triangle(t.stirling2, n, func(prev []*big.Int, i, j int) *big.Int {
+		v := new(big.Int).Mul(big.NewInt(int64(j)), prev[j])
+		return v.Add(v, prev[j-1])
+	})
+	return new(big.Int).Set(t.stirling2[n][k])
+}
+
+// extend_triangle adds rows to a triangle of numbers, where row i has entries 0, ..., i,
+// until it has row n. Row 0 is [1], entry 0 of every later row is 0, and next works out
+// entry j of row i (for 1 <= j <= i) from the row before, which has a 0 added at the end.
+func extend_triangle(rows [][]*big.Int, n int, next func(prev []*big.Int, i, j int) *big.Int) [][]*big.Int {
+	if len(rows) == 0 {
+		rows = append(rows, []*big.Int{big.NewInt(1)})
+	}
+	for i := len(rows); i <= n; i++ {
+		prev := append(rows[i-1][:i:i], big.NewInt(0))
+		row := make([]*big.Int, i+1)
+		row[0] = big.NewInt(0)
+		for j := 1; j <= i; j++ {
+			row[j] = next(prev, i, j)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Bell is like the package level Bell, but remembers the values it works out
+func (t *Table) Bell(n int) *big.Int {
+	if n < 0 {
+		return big.NewInt(0)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// B(i) = sum over j < i of (i-1 choose j) B(j): the set holding the last thing has i-1-j
+	// of the others with it
+	t.bell = extend_sequence(t.bell, n, func(seq []*big.Int, i int) *big.Int {
+		if i == 0 {
+			return big.NewInt(1)
+		}
+		sum := big.NewInt(0)
+		for j, b := range seq {
+			sum.Add(sum, new(big.Int).Mul(Binomial(i-1, j), b))
+		}
+		return sum
+	})
+	return new(big.Int).Set(t.bell[n])
+}
+
+// Fubini is like the package level Fubini, but remembers the values it works out
+func (t *Table) Fubini(n int) *big.Int {
+	if n < 0 {
+		return big.NewInt(0)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// a(i) = sum over j >= 1 of (i choose j) a(i-j): the first set in the list has j things
+	t.fubini = extend_sequence(t.fubini, n, func(seq []*big.Int, i int) *big.Int {
+		if i == 0 {
+			return big.NewInt(1)
+		}
+		sum := big.NewInt(0)
+		for j := 1; j <= i; j++ {
+			sum.Add(sum, new(big.Int).Mul(Binomial(i, j), seq[i-j]))
+		}
+		return sum
+	})
+	return new(big.Int).Set(t.fubini[n])
+}
+
+// Subfactorial is like the package level Subfactorial, but remembers the values it
+// works out
+func (t *Table) Subfactorial(n int) *big.Int {
+	if n < 0 {
+		return big.NewInt(0)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// !i = i * !(i-1) + (-1)^i
+	t.derange = extend_sequence(t.derange, n, func(seq []*big.Int, i int) *big.Int {
+		if i == 0 {
+			return big.NewInt(1)
+		}
+		v := new(big.Int).Mul(big.NewInt(int64(i)), seq[i-1])
+		if i%2 == 0 {
+			return v.Add(v, big.NewInt(1))
+		}
+		return v.Sub(v, big.NewInt(1))
+	})
+	return new(big.Int).Set(t.derange[n])
+}
+
+// PartitionNumber is like the package level PartitionNumber, but remembers the values
+// it works out
+func (t *Table) PartitionNumber(n int) *big.Int {
+	if n < 0 {
+		return big.NewInt(0)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Euler's pentagonal number theorem: p(i) = sum over j >= 1 of
+	// (-1)^(j+1) (p(i - j(3j-1)/2) + p(i - j(3j+1)/2))
+	t.partition = extend_sequence(t.partition, n, func(seq []*big.Int, i int) *big.Int {
+		if i == 0 {
+			return big.NewInt(1)
+		}
+		sum := big.NewInt(0)
+		for j := 1; j*(3*j-1)/2 <= i; j++ {
+			term := new(big.Int).Set(seq[i-j*(3*j-1)/2])
+			if second := i - j*(3*j+1)/2; second >= 0 {
+				term.Add(term, seq[second])
+			}
+			if j%2 == 1 {
+				sum.Add(sum, term)
+			} else {
+				sum.Sub(sum, term)
+			}
+		}
+		return sum
+	})
+	return new(big.Int).Set(t.partition[n])
+}
+
+// extend_sequence adds values to seq, each worked out by next from the ones before it,
+// until it has value n
+func extend_sequence(seq []*big.Int, n int, next func(seq []*big.Int, i int) *big.Int) []*big.Int {
+	for i := len(seq); i <= n; i++ {
+		seq = append(seq, next(seq, i))
+	}
+	return seq
+}
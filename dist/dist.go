@@ -0,0 +1,146 @@
+// Package dist has probability distributions that come from counting: the
+// hypergeometric distribution and its relatives, where items are drawn without
+// replacement, and Fisher's exact test, which is built on them.
+//
+// Every distribution has two sets of functions. The float64 ones (`PMF`, `CDF`,
+// `Survival` and `Quantile`) work with logarithms of binomial coefficients from
+// math.Lgamma, so they are fast for any size of population. The exact ones (`PMFExact`,
+// `CDFExact`, `SurvivalExact` and `QuantileExact`) count with *big.Int binomial
+// coefficients and give a *big.Rat. They are slower, but can be used to check the
+// float64 results, or when a p-value needs to be compared exactly.
+package dist
+
+import (
+	"errors"
+	"math"
+	"math/big"
+)
+
+var errProbabilityRange = errors.New("p must be in the range [0, 1]")
+
+// discrete is a distribution on the integers lo, ..., hi, where the probability of k is
+// count(k) / total. Embedding it gives a distribution all of its PMF, CDF and quantile
+// methods.
+type discrete struct {
+	lo, hi int
+	// count returns how many of the equally likely outcomes give k, for lo <= k <= hi
+	count func(k int) *big.Int
+	// log_count returns the natural log of count(k), for lo <= k <= hi
+	log_count func(k int) float64
+	total     *big.Int
+	log_total float64
+}
+
+// Support returns the smallest and largest values that have a probability above 0
+func (d discrete) Support() (int, int) {
+	return d.lo, d.hi
+}
+
+// PMF returns the probability of k
+func (d discrete) PMF(k int) float64 {
+	if k < d.lo || k > d.hi {
+		return 0
+	}
+	return math.Exp(d.log_count(k) - d.log_total)
+}
+
+// CDF returns the probability of at most k
+func (d discrete) CDF(k int) float64 {
+	if k < d.lo {
+		return 0
+	} else if k >= d.hi {
+		return 1
+	}
+	// Add up the lower tail from the far end, where the terms are smallest, so small
+	// tails keep their precision
+	sum := 0.0
+	for j := d.lo; j <= k; j++ {
+		sum += d.PMF(j)
+	}
+	return min(sum, 1)
+}
+
+// Survival returns the probability of more than k, 1 - CDF(k). It adds up the upper
+// tail itself, from the far end, so it keeps its precision when it is small.
+func (d discrete) Survival(k int) float64 {
+	if k < d.lo {
+		return 1
+	} else if k >= d.hi {
+		return 0
+	}
+	sum := 0.0
+	for j := d.hi; j > k; j-- {
+		sum += d.PMF(j)
+	}
+	return min(sum, 1)
+}
+
+// Quantile returns the smallest k with CDF(k) >= p
+func (d discrete) Quantile(p float64) (int, error) {
+	if !(p >= 0 && p <= 1) {
+		return 0, errProbabilityRange
+	}
+	sum := 0.0
+	for k := d.lo; k < d.hi; k++ {
+		sum += d.PMF(k)
+		if sum >= p {
+			return k, nil
+		}
+	}
+	// Rounding can leave the sum just below 1
+	return d.hi, nil
+}
+
+// PMFExact returns the probability of k, exactly
+func (d discrete) PMFExact(k int) *big.Rat {
+	if k < d.lo || k > d.hi {
+		return new(big.Rat)
+	}
+	return new(big.Rat).SetFrac(d.count(k), d.total)
+}
+
+// CDFExact returns the probability of at most k, exactly
+func (d discrete) CDFExact(k int) *big.Rat {
+	return new(big.Rat).SetFrac(d.count_range(d.lo, k), d.total)
+}
+
+// SurvivalExact returns the probability of more than k, exactly
+func (d discrete) SurvivalExact(k int) *big.Rat {
+	return new(big.Rat).SetFrac(d.count_range(k+1, d.hi), d.total)
+}
+
+// QuantileExact returns the smallest k with CDFExact(k) >= p
+func (d discrete) QuantileExact(p *big.Rat) (int, error) {
+	if p.Sign() < 0 || p.Cmp(big.NewRat(1, 1)) > 0 {
+		return 0, errProbabilityRange
+	}
+	// Compare the running count with p * total, rather than dividing every sum by total
+	target := new(big.Rat).Mul(p, new(big.Rat).SetInt(d.total))
+	sum := new(big.Rat)
+	for k := d.lo; k < d.hi; k++ {
+		sum.Add(sum, new(big.Rat).SetInt(d.count(k)))
+		if sum.Cmp(target) >= 0 {
+			return k, nil
+		}
+	}
+	return d.hi, nil
+}
+
+// count_range adds up count(k) for lo <= k <= hi, skipping any outside the support
+func (d discrete) count_range(lo, hi int) *big.Int {
+	sum := big.NewInt(0)
+	for k := max(lo, d.lo); k <= min(hi, d.hi); k++ {
+		sum.Add(sum, d.count(k))
+	}
+	return sum
+}
+
+// log_binomial returns the natural log of n choose k, for 0 <= k <= n
+func log_binomial(n, k int) float64 {
+	return log_factorial(n) - log_factorial(k) - log_factorial(n-k)
+}
+
+func log_factorial(n int) float64 {
+	v, _ := math.Lgamma(float64(n) + 1)
+	return v
+}
@@ -0,0 +1,97 @@
+package dist
+
+import (
+	"errors"
+	"math/big"
+)
+
+// FisherResult holds the p-values of Fisher's exact test on a 2x2 table
+type FisherResult struct {
+	// Less is the probability of a top left count at most the one seen, which tests for
+	// an odds ratio below 1
+	Less float64
+	// Greater is the probability of a top left count at least the one seen, which tests
+	// for an odds ratio above 1
+	Greater float64
+	// TwoSided is the probability of a table at most as likely as the one seen
+	TwoSided float64
+	// OddsRatio is the sample odds ratio a*d / (b*c). It is +Inf if b*c is 0, and NaN if
+	// a*d is 0 as well.
+	OddsRatio float64
+}
+
+// FisherResultExact is like `FisherResult`, with exact p-values
+type FisherResultExact struct {
+	Less, Greater, TwoSided *big.Rat
+}
+
+// two_sided_tolerance is how much more likely than the table seen another table may be
+// and still count towards the two sided p-value, to allow for rounding. It is the same
+// as R's fisher.test uses.
+const two_sided_tolerance = 1e-7
+
+// fisher_distribution checks the table
+//
+//	a b
+//	c d
+//
+// and returns the distribution of its top left count, given the row and column sums
+func fisher_distribution(a, b, c, d int) (*Hypergeometric, error) {
+	if a < 0 || b < 0 || c < 0 || d < 0 {
+		return nil, errors.New("every count in the table must be greater than or equal to 0")
+	}
+	return NewHypergeometric(a+b+c+d, a+b, a+c)
+}
+
+// FisherExact carries out Fisher's exact test on the 2x2 table
+//
+//	a b
+//	c d
+//
+// which tests whether the rows and columns are independent. With the row and column
+// sums fixed, the top left count has a hypergeometric distribution, and the p-values are
+// its tails.
+func FisherExact(a, b, c, d int) (FisherResult, error) {
+	h, err := fisher_distribution(a, b, c, d)
+	if err != nil {
+		return FisherResult{}, err
+	}
+	result := FisherResult{
+		Less:      h.CDF(a),
+		Greater:   h.Survival(a - 1),
+		OddsRatio: float64(a) * float64(d) / (float64(b) * float64(c)),
+	}
+
+	seen := h.PMF(a) * (1 + two_sided_tolerance)
+	lo, hi := h.Support()
+	for k := lo; k <= hi; k++ {
+		if p := h.PMF(k); p <= seen {
+			result.TwoSided += p
+		}
+	}
+	result.TwoSided = min(result.TwoSided, 1)
+	return result, nil
+}
+
+// FisherExactRat is like `FisherExact`, but works out the p-values exactly. As nothing
+// is rounded, the two sided p-value only counts tables that are at most exactly as
+// likely as the one seen.
+func FisherExactRat(a, b, c, d int) (FisherResultExact, error) {
+	h, err := fisher_distribution(a, b, c, d)
+	if err != nil {
+		return FisherResultExact{}, err
+	}
+	seen := h.count(a)
+	sum := big.NewInt(0)
+	lo, hi := h.Support()
+	for k := lo; k <= hi; k++ {
+		if c := h.count(k); c.Cmp(seen) <= 0 {
+			sum.Add(sum, c)
+		}
+	}
+	return FisherResultExact{
+		Less:     h.CDFExact(a),
+		Greater:  h.SurvivalExact(a - 1),
+		TwoSided: new(big.Rat).SetFrac(sum, h.total),
+	}, nil
+}
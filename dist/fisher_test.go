@@ -0,0 +1,86 @@
+package dist
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestFisherExact(t *testing.T) {
+	testCases := []struct {
+		desc                     string
+		a, b, c, d               int
+		less, greater, two_sided *big.Rat
+	}{
+		{
+			desc: "lady tasting tea", a: 3, b: 1, c: 1, d: 3,
+			less: big.NewRat(69, 70), greater: big.NewRat(17, 70), two_sided: big.NewRat(17, 35),
+		},
+		{
+			desc: "small table", a: 8, b: 2, c: 1, d: 5,
+			less: big.NewRat(1143, 1144), greater: big.NewRat(7, 286), two_sided: big.NewRat(5, 143),
+		},
+		{
+			desc: "strong association", a: 10, b: 20, c: 30, d: 5,
+			less:      big.NewRat(10738667, 663830461014),
+			greater:   big.NewRat(663829657657, 663830461014),
+			two_sided: big.NewRat(111975301, 4314897996591),
+		},
+		{
+			desc: "empty table", a: 0, b: 0, c: 0, d: 0,
+			less: big.NewRat(1, 1), greater: big.NewRat(1, 1), two_sided: big.NewRat(1, 1),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			exact, err := FisherExactRat(tC.a, tC.b, tC.c, tC.d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exact.Less.Cmp(tC.less) != 0 || exact.Greater.Cmp(tC.greater) != 0 || exact.TwoSided.Cmp(tC.two_sided) != 0 {
+				t.Errorf("FisherExactRat() = %v, %v, %v, want %v, %v, %v",
+					exact.Less, exact.Greater, exact.TwoSided, tC.less, tC.greater, tC.two_sided)
+			}
+
+			got, err := FisherExact(tC.a, tC.b, tC.c, tC.d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !close_to(got.Less, tC.less, 1e-9) || !close_to(got.Greater, tC.greater, 1e-9) || !close_to(got.TwoSided, tC.two_sided, 1e-9) {
+				t.Errorf("FisherExact() = %v, %v, %v, want %v, %v, %v", got.Less, got.Greater, got.TwoSided,
+					tC.less.FloatString(15), tC.greater.FloatString(15), tC.two_sided.FloatString(15))
+			}
+		})
+	}
+}
+
+func TestFisherExactOddsRatio(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		a, b, c, d int
+		want       float64
+	}{
+		{desc: "finite", a: 3, b: 1, c: 1, d: 3, want: 9},
+		{desc: "infinite", a: 3, b: 0, c: 1, d: 3, want: math.Inf(1)},
+		{desc: "zero", a: 0, b: 2, c: 1, d: 3, want: 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got, _ := FisherExact(tC.a, tC.b, tC.c, tC.d); got.OddsRatio != tC.want {
+				t.Errorf("OddsRatio = %v, want %v", got.OddsRatio, tC.want)
+			}
+		})
+	}
+	if got, _ := FisherExact(0, 2, 0, 3); !math.IsNaN(got.OddsRatio) {
+		t.Errorf("OddsRatio = %v, want NaN", got.OddsRatio)
+	}
+}
+
+func TestFisherExactNegativeCount(t *testing.T) {
+	if _, err := FisherExact(1, -1, 2, 3); err == nil {
+		t.Errorf("FisherExact() with a negative count gave no error")
+	}
+	if _, err := FisherExactRat(1, 2, 3, -4); err == nil {
+		t.Errorf("FisherExactRat() with a negative count gave no error")
+	}
+}
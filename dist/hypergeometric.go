@@ -0,0 +1,236 @@
+package dist
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/natemcintosh/gocombinatorics/count"
+	"github.com/natemcintosh/gocombinatorics/genfunc"
+)
+
+// Hypergeometric is the number of successes in draws taken without replacement from a
+// population with a given number of successes in it. Create it with
+// `NewHypergeometric`.
+type Hypergeometric struct {
+	population, successes, draws int
+	discrete
+}
+
+// NewHypergeometric creates the distribution of the number of successes in draws taken
+// from population items, of which successes are successes. The probability of k is
+// (successes choose k) * (population-successes choose draws-k) / (population choose draws).
+func NewHypergeometric(population, successes, draws int) (*Hypergeometric, error) {
+	if population < 0 {
+		return nil, errors.New("population must be greater than or equal to 0")
+	} else if successes < 0 || successes > population {
+		return nil, errors.New("successes must be in the range [0, population]")
+	} else if draws < 0 || draws > population {
+		return nil, errors.New("draws must be in the range [0, population]")
+	}
+	failures := population - successes
+	return &Hypergeometric{
+		population: population,
+		successes:  successes,
+		draws:      draws,
+		discrete: discrete{
+			lo: max(0, draws-failures),
+			hi: min(draws, successes),
+			count: func(k int) *big.Int {
+				c := count.Binomial(successes, k)
+				return c.Mul(c, count.Binomial(failures, draws-k))
+			},
+			log_count: func(k int) float64 {
+				return log_binomial(successes, k) + log_binomial(failures, draws-k)
+			},
+			total:     count.Binomial(population, draws),
+			log_total: log_binomial(population, draws),
+		},
+	}, nil
+}
+
+// Mean returns the expected number of successes, draws * successes / population
+func (h *Hypergeometric) Mean() float64 {
+	if h.population == 0 {
+		return 0
+	}
+	return float64(h.draws) * float64(h.successes) / float64(h.population)
+}
+
+// MultivariateHypergeometric is how many items of each kind there are in draws taken
+// without replacement from a population with a given number of each kind. Create it with
+// `NewMultivariateHypergeometric`.
+type MultivariateHypergeometric struct {
+	counts     []int
+	population int
+	draws      int
+	total      *big.Int
+	log_total  float64
+}
+
+// NewMultivariateHypergeometric creates the distribution of how many of each kind of
+// item there are in draws taken from a population with counts[i] items of kind i
+func NewMultivariateHypergeometric(counts []int, draws int) (*MultivariateHypergeometric, error) {
+	population := 0
+	for _, c := range counts {
+		if c < 0 {
+			return nil, errors.New("every count must be greater than or equal to 0")
+		}
+		population += c
+	}
+	if draws < 0 || draws > population {
+		return nil, errors.New("draws must be in the range [0, sum(counts)]")
+	}
+	return &MultivariateHypergeometric{
+		counts:     append([]int{}, counts...),
+		population: population,
+		draws:      draws,
+		total:      count.Binomial(population, draws),
+		log_total:  log_binomial(population, draws),
+	}, nil
+}
+
+// possible reports whether ks could be the numbers of each kind drawn
+func (m *MultivariateHypergeometric) possible(ks []int) bool {
+	if len(ks) != len(m.counts) {
+		return false
+	}
+	sum := 0
+	for i, k := range ks {
+		if k < 0 || k > m.counts[i] {
+			return false
+		}
+		sum += k
+	}
+	return sum == m.draws
+}
+
+// PMF returns the probability of drawing ks[i] items of kind i, for every i. It is 0 if
+// ks has the wrong length, or does not add up to the number of draws.
+func (m *MultivariateHypergeometric) PMF(ks []int) float64 {
+	if !m.possible(ks) {
+		return 0
+	}
+	log_count := 0.0
+	for i, k := range ks {
+		log_count += log_binomial(m.counts[i], k)
+	}
+	return math.Exp(log_count - m.log_total)
+}
+
+// PMFExact returns the probability of drawing ks[i] items of kind i, for every i,
+// exactly
+func (m *MultivariateHypergeometric) PMFExact(ks []int) *big.Rat {
+	if !m.possible(ks) {
+		return new(big.Rat)
+	}
+	c := big.NewInt(1)
+	for i, k := range ks {
+		c.Mul(c, count.Binomial(m.counts[i], k))
+	}
+	return new(big.Rat).SetFrac(c, m.total)
+}
+
+// CDF returns the probability of drawing at most ks[i] items of kind i, for every i. It
+// takes about len(ks) * draws^2 steps.
+func (m *MultivariateHypergeometric) CDF(ks []int) float64 {
+	if len(ks) != len(m.counts) {
+		return 0
+	}
+	// prob[s] is the probability that s draws from the kinds so far stay within the
+	// limits. Going from the first i kinds (seen items) to i+1, j of the s draws are of the
+	// new kind with hypergeometric probability, so every value stays a probability.
+	prob := []float64{1}
+	seen := 0
+	for i, k := range ks {
+		c := m.counts[i]
+		next := make([]float64, min(m.draws, seen+c)+1)
+		for s := range next {
+			for j := max(0, s-seen); j <= min(s, c, k); j++ {
+				if prob[s-j] == 0 {
+					continue
+				}
+				log_p := log_binomial(c, j) + log_binomial(seen, s-j) - log_binomial(seen+c, s)
+				next[s] += prob[s-j] * math.Exp(log_p)
+			}
+		}
+		prob, seen = next, seen+c
+	}
+	return min(prob[m.draws], 1)
+}
+
+// CDFExact returns the probability of drawing at most ks[i] items of kind i, for every
+// i, exactly. The ways to draw are counted with the generating function
+// product((1 + x)^counts[i] up to x^ks[i]), so this takes about len(ks) * draws^2 steps
+// rather than a step for every outcome. It is 0 if any ks[i] is negative.
+func (m *MultivariateHypergeometric) CDFExact(ks []int) *big.Rat {
+	if len(ks) != len(m.counts) {
+		return big.NewRat(0, 1)
+	}
+	for _, k := range ks {
+		if k < 0 {
+			return big.NewRat(0, 1)
+		}
+	}
+	gf := genfunc.New(1)
+	for i, k := range ks {
+		gf = gf.MulTrunc(genfunc.SubsetsAtMost(m.counts[i], k), m.draws)
+	}
+	return new(big.Rat).SetFrac(gf.Coeff(m.draws), m.total)
+}
+
+// Marginal returns the distribution of the number of items of kind i drawn, which is
+// hypergeometric. Use it for the quantiles of each kind.
+func (m *MultivariateHypergeometric) Marginal(i int) (*Hypergeometric, error) {
+	if i < 0 || i >= len(m.counts) {
+		return nil, errors.New("i must be in the range [0, len(counts))")
+	}
+	return NewHypergeometric(m.population, m.counts[i], m.draws)
+}
+
+// NegativeHypergeometric is the number of successes drawn, without replacement, before
+// a given number of failures have been drawn. Create it with `NewNegativeHypergeometric`.
+type NegativeHypergeometric struct {
+	population, successes, failures int
+	discrete
+}
+
+// NewNegativeHypergeometric creates the distribution of the number of successes drawn
+// from population items, of which successes are successes, before the failures-th
+// failure. The probability of k is
+// (k+failures-1 choose k) * (population-failures-k choose successes-k) / (population choose successes).
+func NewNegativeHypergeometric(population, successes, failures int) (*NegativeHypergeometric, error) {
+	if population < 0 {
+		return nil, errors.New("population must be greater than or equal to 0")
+	} else if successes < 0 || successes > population {
+		return nil, errors.New("successes must be in the range [0, population]")
+	} else if failures <= 0 || failures > population-successes {
+		return nil, errors.New("failures must be in the range [1, population-successes]")
+	}
+	return &NegativeHypergeometric{
+		population: population,
+		successes:  successes,
+		failures:   failures,
+		discrete: discrete{
+			lo: 0,
+			hi: successes,
+			// Of the orders of the successes and failures, count those where the
+			// failures-th failure comes after exactly k successes
+			count: func(k int) *big.Int {
+				c := count.Binomial(k+failures-1, k)
+				return c.Mul(c, count.Binomial(population-failures-k, successes-k))
+			},
+			log_count: func(k int) float64 {
+				return log_binomial(k+failures-1, k) + log_binomial(population-failures-k, successes-k)
+			},
+			total:     count.Binomial(population, successes),
+			log_total: log_binomial(population, successes),
+		},
+	}, nil
+}
+
+// Mean returns the expected number of successes, failures * successes / (failures in
+// the population + 1)
+func (nh *NegativeHypergeometric) Mean() float64 {
+	return float64(nh.failures) * float64(nh.successes) / float64(nh.population-nh.successes+1)
+}
@@ -0,0 +1,285 @@
+package dist
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// close_to reports whether got is within a relative tolerance of the exact value want
+func close_to(got float64, want *big.Rat, tol float64) bool {
+	w, _ := want.Float64()
+	return math.Abs(got-w) <= tol*math.Max(math.Abs(w), 1e-300)
+}
+
+func TestHypergeometricPMF(t *testing.T) {
+	// 50 items, 5 of them successes, 10 drawn
+	h, err := NewHypergeometric(50, 5, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (5 choose 2) * (45 choose 8) / (50 choose 10)
+	want := big.NewRat(10*215553195, 10272278170)
+	if got := h.PMFExact(2); got.Cmp(want) != 0 {
+		t.Errorf("PMFExact(2) = %v, want %v", got, want)
+	}
+	if got := h.PMF(2); !close_to(got, want, 1e-12) {
+		t.Errorf("PMF(2) = %v, want %v", got, want.FloatString(15))
+	}
+	if got := h.PMF(6); got != 0 {
+		t.Errorf("PMF(6) = %v, want 0", got)
+	}
+	if got := h.PMFExact(-1); got.Sign() != 0 {
+		t.Errorf("PMFExact(-1) = %v, want 0", got)
+	}
+	if got := h.Mean(); got != 1 {
+		t.Errorf("Mean() = %v, want 1", got)
+	}
+}
+
+func TestHypergeometricSupport(t *testing.T) {
+	testCases := []struct {
+		desc                         string
+		population, successes, draws int
+		lo, hi                       int
+	}{
+		{desc: "plenty of both", population: 20, successes: 7, draws: 5, lo: 0, hi: 5},
+		{desc: "few successes", population: 20, successes: 3, draws: 5, lo: 0, hi: 3},
+		{desc: "few failures", population: 20, successes: 18, draws: 5, lo: 3, hi: 5},
+		{desc: "draw everything", population: 6, successes: 2, draws: 6, lo: 2, hi: 2},
+		{desc: "draw nothing", population: 6, successes: 2, draws: 0, lo: 0, hi: 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			h, err := NewHypergeometric(tC.population, tC.successes, tC.draws)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lo, hi := h.Support(); lo != tC.lo || hi != tC.hi {
+				t.Errorf("Support() = %v, %v, want %v, %v", lo, hi, tC.lo, tC.hi)
+			}
+			// The probabilities add up to exactly 1
+			if got := h.CDFExact(tC.hi); got.Cmp(big.NewRat(1, 1)) != 0 {
+				t.Errorf("CDFExact(%v) = %v, want 1", tC.hi, got)
+			}
+		})
+	}
+}
+
+func TestHypergeometricFloatMatchesExact(t *testing.T) {
+	for _, params := range [][3]int{{10, 4, 3}, {100, 30, 20}, {1000, 10, 400}, {500, 250, 250}} {
+		h, err := NewHypergeometric(params[0], params[1], params[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		lo, hi := h.Support()
+		for k := lo - 1; k <= hi+1; k++ {
+			if got, want := h.PMF(k), h.PMFExact(k); !close_to(got, want, 1e-9) {
+				t.Errorf("%v PMF(%v) = %v, want %v", params, k, got, want.FloatString(20))
+			}
+			if got, want := h.CDF(k), h.CDFExact(k); !close_to(got, want, 1e-9) {
+				t.Errorf("%v CDF(%v) = %v, want %v", params, k, got, want.FloatString(20))
+			}
+			if got, want := h.Survival(k), h.SurvivalExact(k); !close_to(got, want, 1e-9) {
+				t.Errorf("%v Survival(%v) = %v, want %v", params, k, got, want.FloatString(20))
+			}
+			sum := new(big.Rat).Add(h.CDFExact(k), h.SurvivalExact(k))
+			if sum.Cmp(big.NewRat(1, 1)) != 0 {
+				t.Errorf("%v CDFExact(%v) + SurvivalExact(%v) = %v, want 1", params, k, k, sum)
+			}
+		}
+	}
+}
+
+func TestHypergeometricQuantile(t *testing.T) {
+	// 8 items, 4 successes, 4 drawn: the counts are 1, 16, 36, 16, 1 out of 70
+	h, _ := NewHypergeometric(8, 4, 4)
+	testCases := []struct {
+		p    *big.Rat
+		want int
+	}{
+		{p: big.NewRat(0, 1), want: 0},
+		{p: big.NewRat(1, 70), want: 0},
+		{p: big.NewRat(2, 70), want: 1},
+		{p: big.NewRat(1, 2), want: 2},
+		{p: big.NewRat(53, 70), want: 2},
+		{p: big.NewRat(54, 70), want: 3},
+		{p: big.NewRat(1, 1), want: 4},
+	}
+	for _, tC := range testCases {
+		if got, _ := h.QuantileExact(tC.p); got != tC.want {
+			t.Errorf("QuantileExact(%v) = %v, want %v", tC.p, got, tC.want)
+		}
+		// Stay away from the exact steps, where float rounding could go either way
+		p, _ := tC.p.Float64()
+		if got, _ := h.Quantile(p * (1 - 1e-9)); got != tC.want {
+			t.Errorf("Quantile(%v) = %v, want %v", p, got, tC.want)
+		}
+	}
+	if _, err := h.Quantile(1.5); err == nil {
+		t.Errorf("Quantile(1.5) gave no error")
+	}
+	if _, err := h.Quantile(math.NaN()); err == nil {
+		t.Errorf("Quantile(NaN) gave no error")
+	}
+	if _, err := h.QuantileExact(big.NewRat(-1, 2)); err == nil {
+		t.Errorf("QuantileExact(-1/2) gave no error")
+	}
+}
+
+func TestNewHypergeometricErrors(t *testing.T) {
+	for _, params := range [][3]int{{-1, 0, 0}, {5, 6, 1}, {5, -1, 1}, {5, 2, 6}, {5, 2, -1}} {
+		if _, err := NewHypergeometric(params[0], params[1], params[2]); err == nil {
+			t.Errorf("NewHypergeometric%v gave no error", params)
+		}
+	}
+}
+
+// multivariate_outcomes calls f with every way to draw from counts, as a slice of how
+// many of each kind were drawn
+func multivariate_outcomes(counts []int, draws int, f func(ks []int)) {
+	ks := make([]int, len(counts))
+	var fill func(i, left int)
+	fill = func(i, left int) {
+		if i == len(counts) {
+			if left == 0 {
+				f(ks)
+			}
+			return
+		}
+		for k := 0; k <= min(counts[i], left); k++ {
+			ks[i] = k
+			fill(i+1, left-k)
+		}
+	}
+	fill(0, draws)
+}
+
+func TestMultivariateHypergeometric(t *testing.T) {
+	counts := []int{4, 6, 3, 5}
+	m, err := NewMultivariateHypergeometric(counts, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := new(big.Rat)
+	multivariate_outcomes(counts, 7, func(ks []int) {
+		p := m.PMFExact(ks)
+		total.Add(total, p)
+		if got := m.PMF(ks); !close_to(got, p, 1e-9) {
+			t.Errorf("PMF(%v) = %v, want %v", ks, got, p.FloatString(20))
+		}
+	})
+	if total.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("the PMF adds up to %v, want 1", total)
+	}
+
+	// The CDF by brute force
+	for _, limits := range [][]int{{2, 2, 2, 2}, {4, 6, 3, 5}, {0, 3, 3, 1}, {1, 1, 1, 1}} {
+		want := new(big.Rat)
+		multivariate_outcomes(counts, 7, func(ks []int) {
+			for i, k := range ks {
+				if k > limits[i] {
+					return
+				}
+			}
+			want.Add(want, m.PMFExact(ks))
+		})
+		if got := m.CDFExact(limits); got.Cmp(want) != 0 {
+			t.Errorf("CDFExact(%v) = %v, want %v", limits, got, want)
+		}
+		if got := m.CDF(limits); !close_to(got, want, 1e-9) && !(got == 0 && want.Sign() == 0) {
+			t.Errorf("CDF(%v) = %v, want %v", limits, got, want.FloatString(20))
+		}
+	}
+
+	// A negative limit can never be met
+	for _, limits := range [][]int{{-2, 4, 3, 5}, {4, 6, -1, 5}, {0, 0, 0, -7}} {
+		if got := m.CDFExact(limits); got.Sign() != 0 {
+			t.Errorf("CDFExact(%v) = %v, want 0", limits, got)
+		}
+		if got := m.CDF(limits); got != 0 {
+			t.Errorf("CDF(%v) = %v, want 0", limits, got)
+		}
+	}
+
+	for _, ks := range [][]int{{1, 2, 3}, {1, 2, 3, 2}, {5, 2, 0, 0}, {-1, 3, 3, 2}} {
+		if got := m.PMFExact(ks); got.Sign() != 0 {
+			t.Errorf("PMFExact(%v) = %v, want 0", ks, got)
+		}
+	}
+
+	marginal, err := m.Marginal(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := marginal.Mean(), 7.0*6/18; math.Abs(got-want) > 1e-12 {
+		t.Errorf("Marginal(1).Mean() = %v, want %v", got, want)
+	}
+	if _, err := m.Marginal(4); err == nil {
+		t.Errorf("Marginal(4) gave no error")
+	}
+	if _, err := NewMultivariateHypergeometric([]int{1, 2}, 4); err == nil {
+		t.Errorf("drawing more than the population gave no error")
+	}
+}
+
+func TestNegativeHypergeometricBruteForce(t *testing.T) {
+	// 9 items, 4 of them successes, drawn until the 3rd failure. Go through every order
+	// of the successes and failures, as a bit mask of where the successes are.
+	const population, successes, failures = 9, 4, 3
+	nh, err := NewNegativeHypergeometric(population, successes, failures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make([]int64, successes+1)
+	var total int64
+	for mask := 0; mask < 1<<population; mask++ {
+		if popcount(mask) != successes {
+			continue
+		}
+		total++
+		k, f := 0, 0
+		for i := 0; f < failures; i++ {
+			if mask&(1<<i) != 0 {
+				k++
+			} else {
+				f++
+			}
+		}
+		counts[k]++
+	}
+	mean := 0.0
+	for k, c := range counts {
+		want := big.NewRat(c, total)
+		if got := nh.PMFExact(k); got.Cmp(want) != 0 {
+			t.Errorf("PMFExact(%v) = %v, want %v", k, got, want)
+		}
+		if got := nh.PMF(k); !close_to(got, want, 1e-12) {
+			t.Errorf("PMF(%v) = %v, want %v", k, got, want.FloatString(15))
+		}
+		w, _ := want.Float64()
+		mean += float64(k) * w
+	}
+	if got := nh.Mean(); math.Abs(got-mean) > 1e-12 {
+		t.Errorf("Mean() = %v, want %v", got, mean)
+	}
+	if _, err := NewNegativeHypergeometric(9, 4, 6); err == nil {
+		t.Errorf("waiting for more failures than there are gave no error")
+	}
+}
+
+func popcount(x int) int {
+	n := 0
+	for ; x > 0; x &= x - 1 {
+		n++
+	}
+	return n
+}
+
+func BenchmarkHypergeometricCDF(b *testing.B) {
+	h, _ := NewHypergeometric(100_000, 40_000, 1_000)
+	for i := 0; i < b.N; i++ {
+		h.CDF(400)
+	}
+}
@@ -0,0 +1,238 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math/big"
+)
+
+// DistinctRepresentatives generates every system of distinct representatives (SDR) of a
+// list of sets: every way to pick one item from each set so that no item is picked
+// twice. Unlike `Permutations`, which lets any item go in any slot, each slot i here
+// only takes items from sets[i].
+//
+// If no SDR exists, `NewDistinctRepresentatives` says so up front, by looking for a
+// matching between sets and items (Hall's condition). While generating, a partial pick is
+// only extended if the remaining sets can still be matched to the remaining items, so the
+// search never walks into a dead end.
+// DistinctRepresentatives meets the `CombinationLike` interface
+type DistinctRepresentatives[T comparable] struct {
+	universe []T
+	cands    [][]int
+	cand_pos [][]int
+	m        int
+	isfirst  bool
+	done     bool
+	choice   []int
+	used     []bool
+	inds     []int
+	buffer   []T
+}
+
+// NewDistinctRepresentatives creates a new DistinctRepresentatives object. An item that
+// appears more than once in the same set only counts once. There must be at least one
+// set, and the sets must have a system of distinct representatives.
+func NewDistinctRepresentatives[T comparable](sets [][]T) (*DistinctRepresentatives[T], error) {
+	m := len(sets)
+	if m <= 0 {
+		return nil, errors.New("len(sets) must be greater than 0")
+	}
+
+	universe, cands, cand_pos := index_sets(sets)
+	if !has_matching(cands, 0, make([]bool, len(universe))) {
+		return nil, errors.New("the sets have no system of distinct representatives")
+	}
+
+	return &DistinctRepresentatives[T]{
+		universe: universe,
+		cands:    cands,
+		cand_pos: cand_pos,
+		m:        m,
+		isfirst:  true,
+		choice:   make([]int, m),
+		used:     make([]bool, len(universe)),
+		inds:     make([]int, m),
+		buffer:   make([]T, m),
+	}, nil
+}
+
+// index_sets gives every distinct item an id. cands[i] holds the ids of the items in
+// sets[i], without repeats, and cand_pos[i] where in sets[i] each of them was first seen.
+func index_sets[T comparable](sets [][]T) ([]T, [][]int, [][]int) {
+	universe := make([]T, 0)
+	ids := make(map[T]int)
+	cands := make([][]int, len(sets))
+	cand_pos := make([][]int, len(sets))
+	for i, set := range sets {
+		seen := make(map[int]bool, len(set))
+		for pos, item := range set {
+			id, ok := ids[item]
+			if !ok {
+				id = len(universe)
+				ids[item] = id
+				universe = append(universe, item)
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			cands[i] = append(cands[i], id)
+			cand_pos[i] = append(cand_pos[i], pos)
+		}
+	}
+	return universe, cands, cand_pos
+}
+
+// has_matching reports whether every set from `from` onwards can be given its own item,
+// avoiding the items already marked in used. It uses augmenting paths (Kuhn's algorithm).
+func has_matching(cands [][]int, from int, used []bool) bool {
+	owner := make([]int, len(used))
+	for j := range owner {
+		owner[j] = -1
+	}
+	var augment func(i int, visited []bool) bool
+	augment = func(i int, visited []bool) bool {
+		for _, j := range cands[i] {
+			if used[j] || visited[j] {
+				continue
+			}
+			visited[j] = true
+			if owner[j] == -1 || augment(owner[j], visited) {
+				owner[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := from; i < len(cands); i++ {
+		if !augment(i, make([]bool, len(used))) {
+			return false
+		}
+	}
+	return true
+}
+
+// Next will move on to the next system of distinct representatives, returning false
+// when there are none left.
+func (d *DistinctRepresentatives[T]) Next() bool {
+	if d.done {
+		return false
+	}
+
+	// t is the set we are currently picking from
+	t := d.m - 1
+	if d.isfirst {
+		t = 0
+		d.choice[0] = -1
+		d.isfirst = false
+	} else {
+		d.used[d.cands[t][d.choice[t]]] = false
+	}
+
+	for t >= 0 {
+		// Try the next candidate from set t that is still free
+		c := d.choice[t] + 1
+		for c < len(d.cands[t]) && d.used[d.cands[t][c]] {
+			c++
+		}
+		if c == len(d.cands[t]) {
+			// Nothing left in this set, so back up
+			t--
+			if t >= 0 {
+				d.used[d.cands[t][d.choice[t]]] = false
+			}
+			continue
+		}
+		d.choice[t] = c
+		d.used[d.cands[t][c]] = true
+
+		if t == d.m-1 {
+			d.fill_inds()
+			return true
+		}
+		if !has_matching(d.cands, t+1, d.used) {
+			d.used[d.cands[t][c]] = false
+			continue
+		}
+		t++
+		d.choice[t] = -1
+	}
+	d.done = true
+	return false
+}
+
+func (d *DistinctRepresentatives[T]) fill_inds() {
+	for i, c := range d.choice {
+		d.inds[i] = d.cand_pos[i][c]
+	}
+}
+
+func (d *DistinctRepresentatives[T]) LenInds() int {
+	return d.m
+}
+
+// Indices gives, for each set i, the position in sets[i] of the item picked from it
+func (d *DistinctRepresentatives[T]) Indices() []int {
+	return d.inds
+}
+
+// Items is how you get the picked items. You iterate with `d.Next()`, and then get the
+// items with `d.Items()`. The data in the slice returned will be overwritten every
+// iteration. If you need to keep the data from each iteration, be sure to make a copy.
+func (d *DistinctRepresentatives[T]) Items() []T {
+	for i, c := range d.choice {
+		d.buffer[i] = d.universe[d.cands[i][c]]
+	}
+	return d.buffer
+}
+
+// max_sdr_count_sets is the most sets CountDistinctRepresentatives will handle, as it
+// keeps a count for every subset of the sets.
+const max_sdr_count_sets = 25
+
+// CountDistinctRepresentatives returns how many systems of distinct representatives
+// sets has, without generating them. This is the permanent of the (sets x items)
+// incidence matrix, and is found by going through the items one at a time, keeping
+// track of how many ways each subset of the sets can already have been given
+// representatives. It takes time proportional to (number of items) * 2^len(sets), so
+// it only accepts up to 25 sets. Like `NewDistinctRepresentatives`, it needs at least
+// one set, but it gives 0 for sets with no system of distinct representatives.
+func CountDistinctRepresentatives[T comparable](sets [][]T) (*big.Int, error) {
+	m := len(sets)
+	if m <= 0 {
+		return nil, errors.New("len(sets) must be greater than 0")
+	} else if m > max_sdr_count_sets {
+		return nil, errors.New("too many sets to count systems of distinct representatives")
+	}
+	universe, cands, _ := index_sets(sets)
+
+	// Which sets each item is in
+	holders := make([][]int, len(universe))
+	for i, ids := range cands {
+		for _, j := range ids {
+			holders[j] = append(holders[j], i)
+		}
+	}
+
+	// ways[mask] is how many ways the sets in mask can have been given distinct
+	// representatives from the items seen so far
+	ways := make([]*big.Int, 1<<uint(m))
+	for mask := range ways {
+		ways[mask] = big.NewInt(0)
+	}
+	ways[0].SetInt64(1)
+	for _, hs := range holders {
+		// Go down through the masks so that each item is used at most once
+		for mask := len(ways) - 1; mask >= 0; mask-- {
+			if ways[mask].Sign() == 0 {
+				continue
+			}
+			for _, i := range hs {
+				if mask&(1<<uint(i)) == 0 {
+					dest := mask | (1 << uint(i))
+					ways[dest].Add(ways[dest], ways[mask])
+				}
+			}
+		}
+	}
+	return ways[len(ways)-1], nil
+}
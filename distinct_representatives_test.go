@@ -0,0 +1,170 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// sdrs_by_brute_force tries every way of picking one item from each set
+func sdrs_by_brute_force(sets [][]int) map[string]bool {
+	result := make(map[string]bool)
+	picked := make([]int, len(sets))
+	var pick func(i int)
+	pick = func(i int) {
+		if i == len(sets) {
+			seen := make(map[int]bool)
+			for _, item := range picked {
+				if seen[item] {
+					return
+				}
+				seen[item] = true
+			}
+			result[fmt.Sprint(picked)] = true
+			return
+		}
+		for _, item := range sets[i] {
+			picked[i] = item
+			pick(i + 1)
+		}
+	}
+	pick(0)
+	return result
+}
+
+func TestNewDistinctRepresentativesErrors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		sets [][]string
+	}{
+		{desc: "no sets", sets: [][]string{}},
+		{desc: "empty set", sets: [][]string{{"a"}, {}}},
+		{desc: "hall condition fails", sets: [][]string{{"a", "b"}, {"a", "b"}, {"b", "a"}, {"c"}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := NewDistinctRepresentatives(tC.sets); err == nil {
+				t.Errorf("NewDistinctRepresentatives() did not return an error")
+			}
+		})
+	}
+}
+
+func TestDistinctRepresentativesNext(t *testing.T) {
+	sets := [][]string{
+		{"ann", "bob"},
+		{"bob", "cat"},
+		{"ann", "cat", "ann"},
+	}
+	d, err := NewDistinctRepresentatives(sets)
+	if err != nil {
+		t.Fatalf("NewDistinctRepresentatives() = %v, want nil", err)
+	}
+	want_items := [][]string{
+		{"ann", "bob", "cat"},
+		{"bob", "cat", "ann"},
+	}
+	want_inds := [][]int{
+		{0, 0, 1},
+		{1, 1, 0},
+	}
+	got_items := make([][]string, 0)
+	got_inds := make([][]int, 0)
+	for d.Next() {
+		items := make([]string, len(d.Items()))
+		copy(items, d.Items())
+		got_items = append(got_items, items)
+		inds := make([]int, len(d.Indices()))
+		copy(inds, d.Indices())
+		got_inds = append(got_inds, inds)
+	}
+	if !reflect.DeepEqual(got_items, want_items) {
+		t.Errorf("DistinctRepresentatives items = %v, want %v", got_items, want_items)
+	}
+	if !reflect.DeepEqual(got_inds, want_inds) {
+		t.Errorf("DistinctRepresentatives indices = %v, want %v", got_inds, want_inds)
+	}
+	if d.Next() {
+		t.Errorf("Next() returned true after the end")
+	}
+}
+
+func Test100RandomDistinctRepresentatives(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		m := rand.Intn(6) + 1
+		n_items := m + rand.Intn(3)
+		sets := make([][]int, m)
+		for s := range sets {
+			size := rand.Intn(n_items) + 1
+			for j := 0; j < size; j++ {
+				sets[s] = append(sets[s], rand.Intn(n_items))
+			}
+		}
+
+		run_name := fmt.Sprintf("%v", sets)
+		t.Run(run_name, func(t *testing.T) {
+			want := sdrs_by_brute_force(sets)
+
+			count, err := CountDistinctRepresentatives(sets)
+			if err != nil {
+				t.Fatalf("CountDistinctRepresentatives() = %v, want nil", err)
+			}
+			d, err := NewDistinctRepresentatives(sets)
+			if len(want) == 0 {
+				if err == nil {
+					t.Errorf("NewDistinctRepresentatives() did not return an error")
+				}
+				if count.Sign() != 0 {
+					t.Errorf("CountDistinctRepresentatives() = %v, want 0", count)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewDistinctRepresentatives() = %v, want nil", err)
+			}
+
+			got := make(map[string]bool)
+			for d.Next() {
+				for i, idx := range d.Indices() {
+					if sets[i][idx] != d.Items()[i] {
+						t.Errorf("Indices() and Items() disagree: %v vs %v", d.Indices(), d.Items())
+					}
+				}
+				key := fmt.Sprint(d.Items())
+				if got[key] {
+					t.Errorf("Saw %v twice", key)
+				}
+				got[key] = true
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("DistinctRepresentatives = %v, want %v", got, want)
+			}
+			if count.Cmp(big.NewInt(int64(len(want)))) != 0 {
+				t.Errorf("CountDistinctRepresentatives() = %v, want %v", count, len(want))
+			}
+		})
+	}
+}
+
+func TestCountDistinctRepresentativesErrors(t *testing.T) {
+	too_many := make([][]int, max_sdr_count_sets+1)
+	for i := range too_many {
+		too_many[i] = []int{i}
+	}
+	testCases := []struct {
+		desc string
+		sets [][]int
+	}{
+		{desc: "no sets", sets: [][]int{}},
+		{desc: "too many sets", sets: too_many},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := CountDistinctRepresentatives(tC.sets); err == nil {
+				t.Errorf("CountDistinctRepresentatives() did not return an error")
+			}
+		})
+	}
+}
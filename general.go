@@ -23,3 +23,20 @@ func fill_buffer[T any](buffer []T, data []T, indices []int) error {
 	}
 	return nil
 }
+
+// Integer is any type with an underlying integer type. It mirrors
+// golang.org/x/exp/constraints.Integer so that this package stays dependency free.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is any type with an underlying floating point type.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is anything we can add up
+type Number interface {
+	Integer | Float
+}
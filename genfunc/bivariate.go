@@ -0,0 +1,251 @@
+package genfunc
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Poly2 is a polynomial in x and y with integer coefficients, where x marks the size of
+// an object and y its weight. The zero value is the zero polynomial. Pass math.MaxInt
+// as a largest size or weight to mean there is no limit.
+type Poly2 struct {
+	// rows[i] is the coefficient of x^i, as a polynomial in y. The last one is never 0.
+	rows []*Poly
+}
+
+// NewPoly2 creates the polynomial rows[0] + rows[1] x + rows[2] x^2 + ..., where each of
+// the rows is a polynomial in y
+func NewPoly2(rows ...*Poly) *Poly2 {
+	return from_rows(append([]*Poly{}, rows...))
+}
+
+// Monomial2 creates the polynomial coeff * x^i * y^j. It is 0 if i or j is negative.
+func Monomial2(coeff int64, i, j int) *Poly2 {
+	if i < 0 {
+		return &Poly2{}
+	}
+	rows := make([]*Poly, i+1)
+	for k := range rows {
+		rows[k] = &Poly{}
+	}
+	rows[i] = Monomial(coeff, j)
+	return from_rows(rows)
+}
+
+// from_rows makes a Poly2 out of rows nothing else refers to, taking off the zero rows at
+// the end. The rows themselves are never changed, so they can be shared.
+func from_rows(rows []*Poly) *Poly2 {
+	for len(rows) > 0 && rows[len(rows)-1].Degree() < 0 {
+		rows = rows[:len(rows)-1]
+	}
+	return &Poly2{rows: rows}
+}
+
+// SizeDegree returns the largest power of x with a coefficient that is not 0, or -1 for
+// the zero polynomial
+func (p *Poly2) SizeDegree() int {
+	return len(p.rows) - 1
+}
+
+// Coeff returns the coefficient of x^i y^j: how many objects have size i and weight j
+func (p *Poly2) Coeff(i, j int) *big.Int {
+	return p.Size(i).Coeff(j)
+}
+
+// Size returns the coefficient of x^i, as a polynomial in y: the weights of the objects
+// of size i
+func (p *Poly2) Size(i int) *Poly {
+	if i < 0 || i >= len(p.rows) {
+		return &Poly{}
+	}
+	return p.rows[i]
+}
+
+// Sizes returns p with y set to 1: how many objects there are of each size, whatever
+// their weight
+func (p *Poly2) Sizes() *Poly {
+	c := make([]*big.Int, len(p.rows))
+	for i, row := range p.rows {
+		c[i] = row.Eval(big.NewInt(1))
+	}
+	return from_owned(c)
+}
+
+// Weights returns p with x set to 1: how many objects there are of each weight, whatever
+// their size
+func (p *Poly2) Weights() *Poly {
+	result := &Poly{}
+	for _, row := range p.rows {
+		result = result.Add(row)
+	}
+	return result
+}
+
+// Equal reports whether p and q are the same polynomial
+func (p *Poly2) Equal(q *Poly2) bool {
+	if len(p.rows) != len(q.rows) {
+		return false
+	}
+	for i, row := range p.rows {
+		if !row.Equal(q.rows[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Add returns p + q
+func (p *Poly2) Add(q *Poly2) *Poly2 {
+	rows := make([]*Poly, max(len(p.rows), len(q.rows)))
+	for i := range rows {
+		rows[i] = p.Size(i).Add(q.Size(i))
+	}
+	return from_rows(rows)
+}
+
+// Mul returns p * q
+func (p *Poly2) Mul(q *Poly2) *Poly2 {
+	return p.MulTrunc(q, math.MaxInt, math.MaxInt)
+}
+
+// MulTrunc returns p * q without any of the terms of size above max_size or weight
+// above max_weight. It only works out the terms it keeps.
+func (p *Poly2) MulTrunc(q *Poly2, max_size, max_weight int) *Poly2 {
+	if len(p.rows) == 0 || len(q.rows) == 0 || max_size < 0 {
+		return &Poly2{}
+	}
+	rows := make([]*Poly, min(max_size, p.SizeDegree()+q.SizeDegree())+1)
+	for i := range rows {
+		rows[i] = &Poly{}
+	}
+	for i, a := range p.rows {
+		if i >= len(rows) {
+			break
+		}
+		if a.Degree() < 0 {
+			continue
+		}
+		for j, b := range q.rows {
+			if i+j >= len(rows) {
+				break
+			}
+			rows[i+j] = rows[i+j].Add(a.MulTrunc(b, max_weight))
+		}
+	}
+	return from_rows(rows)
+}
+
+// Pow returns p^e. It panics if e is negative.
+func (p *Poly2) Pow(e int) *Poly2 {
+	return p.PowTrunc(e, math.MaxInt, math.MaxInt)
+}
+
+// PowTrunc returns p^e without any of the terms of size above max_size or weight above
+// max_weight, working out the power by repeated squaring. It panics if e is negative.
+func (p *Poly2) PowTrunc(e, max_size, max_weight int) *Poly2 {
+	if e < 0 {
+		panic("genfunc: negative exponent")
+	}
+	result := Monomial2(1, 0, 0).Truncate(max_size, max_weight)
+	square := p.Truncate(max_size, max_weight)
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result = result.MulTrunc(square, max_size, max_weight)
+		}
+		if e > 1 {
+			square = square.MulTrunc(square, max_size, max_weight)
+		}
+	}
+	return result
+}
+
+// Truncate returns p without any of the terms of size above max_size or weight above
+// max_weight
+func (p *Poly2) Truncate(max_size, max_weight int) *Poly2 {
+	if max_size < 0 {
+		return &Poly2{}
+	}
+	rows := make([]*Poly, min(max_size, len(p.rows)-1)+1)
+	for i := range rows {
+		rows[i] = p.rows[i].Truncate(max_weight)
+	}
+	return from_rows(rows)
+}
+
+// String writes the polynomial out by size and then weight, e.g. "1 + x y + 2x^2 y^3"
+func (p *Poly2) String() string {
+	parts := make([]string, 0)
+	for i, row := range p.rows {
+		for j, c := range row.coeffs {
+			if c.Sign() == 0 {
+				continue
+			}
+			factors := make([]string, 0)
+			if i > 0 {
+				factors = append(factors, power("x", i))
+			}
+			if j > 0 {
+				factors = append(factors, power("y", j))
+			}
+			term := strings.Join(factors, " ")
+			switch {
+			case term == "":
+				term = c.String()
+			case c.Cmp(big.NewInt(-1)) == 0:
+				term = "-" + term
+			case c.Cmp(big.NewInt(1)) != 0:
+				term = c.String() + term
+			}
+			parts = append(parts, term)
+		}
+	}
+	if len(parts) == 0 {
+		return "0"
+	}
+	return strings.Join(parts, " + ")
+}
+
+func power(v string, i int) string {
+	if i == 1 {
+		return v
+	}
+	return fmt.Sprintf("%s^%d", v, i)
+}
+
+// SubsetsByWeight returns the product of (1 + x y^w) over the weights: the subsets of
+// items with those weights, by how many items they have and the sum of their weights. It
+// is 0 if any weight is negative.
+func SubsetsByWeight(weights ...int) *Poly2 {
+	result := Monomial2(1, 0, 0)
+	for _, w := range weights {
+		if w < 0 {
+			return &Poly2{}
+		}
+		result = result.Mul(Monomial2(1, 0, 0).Add(Monomial2(1, 1, w)))
+	}
+	return result
+}
+
+// MultisetsByWeight returns the product of 1 / (1 - x y^w) over the weights, cut off
+// after size max_size: the multisets of items with those weights, by how many items they
+// have and the sum of their weights. It is 0 if any weight, or max_size, is negative.
+func MultisetsByWeight(max_size int, weights ...int) *Poly2 {
+	if max_size < 0 {
+		return &Poly2{}
+	}
+	result := Monomial2(1, 0, 0).Truncate(max_size, math.MaxInt)
+	for _, w := range weights {
+		if w < 0 {
+			return &Poly2{}
+		}
+		// 1 + x y^w + x^2 y^2w + ..., up to max_size
+		rows := make([]*Poly, max_size+1)
+		for t := range rows {
+			rows[t] = Monomial(1, t*w)
+		}
+		result = result.MulTrunc(from_rows(rows), max_size, math.MaxInt)
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package genfunc
+
+import (
+	"math/big"
+
+	"github.com/natemcintosh/gocombinatorics/count"
+)
+
+// The builders below give 0 for arguments that are out of range, such as a negative
+// number of items, as there are no objects of that kind to count.
+
+// Subsets returns (1 + x)^n, the generating function for the subsets of n distinct
+// items by size. Its coefficient of x^k is the length of `Combinations` choosing k of
+// the n items.
+func Subsets(n int) *Poly {
+	if n < 0 {
+		return &Poly{}
+	}
+	c := make([]*big.Int, n+1)
+	for k := range c {
+		c[k] = count.Binomial(n, k)
+	}
+	return from_owned(c)
+}
+
+// SubsetsAtMost returns the generating function for the subsets of n distinct items
+// that have at most m of them: the terms of (1 + x)^n up to x^m. Multiply one of these
+// for each category to count choices with at most m from each.
+func SubsetsAtMost(n, m int) *Poly {
+	if n < 0 || m < 0 {
+		return &Poly{}
+	}
+	c := make([]*big.Int, min(n, m)+1)
+	for k := range c {
+		c[k] = count.Binomial(n, k)
+	}
+	return from_owned(c)
+}
+
+// Multisets returns the generating function for the multisets of n kinds of item by
+// size, 1 / (1 - x)^n, cut off after x^max_size. Its coefficient of x^k is the length of
+// `CombinationsWithReplacement` choosing k from n items.
+func Multisets(n, max_size int) *Poly {
+	if n < 0 || max_size < 0 {
+		return &Poly{}
+	} else if n == 0 {
+		// There is one way to choose nothing from nothing, and no way to choose anything
+		return New(1)
+	}
+	c := make([]*big.Int, max_size+1)
+	for k := range c {
+		c[k] = count.Binomial(n+k-1, k)
+	}
+	return from_owned(c)
+}
+
+// Bounded returns (1 + x + ... + x^m)^n, the generating function for the multisets of
+// n kinds of item by size, where each kind is used at most m times
+func Bounded(n, m int) *Poly {
+	if n < 0 || m < 0 {
+		return &Poly{}
+	}
+	return upto(m).Pow(n)
+}
+
+// BoundedEach is like `Bounded`, but kind i may be used at most limits[i] times: it
+// returns the product of (1 + x + ... + x^limits[i])
+func BoundedEach(limits ...int) *Poly {
+	result := New(1)
+	for _, m := range limits {
+		if m < 0 {
+			return &Poly{}
+		}
+		result = result.Mul(upto(m))
+	}
+	return result
+}
+
+// upto returns 1 + x + ... + x^m
+func upto(m int) *Poly {
+	c := make([]*big.Int, m+1)
+	for i := range c {
+		c[i] = big.NewInt(1)
+	}
+	return from_owned(c)
+}
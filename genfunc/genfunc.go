@@ -0,0 +1,238 @@
+// Package genfunc counts with generating functions: polynomials whose coefficient of
+// x^n is how many objects of size n there are. Multiplying two of them counts pairs of
+// objects whose sizes add up, so constraints such as "choose k items with at most 2 from
+// each category" become a product of one small polynomial per category.
+//
+// Coefficients are exact *big.Int values. A `Poly` is never changed once made: every
+// operation returns a new one, and the coefficients it hands out are copies. Series
+// that never end, such as the number of multisets of each size, are cut off at a
+// largest size, and `MulTrunc` and `PowTrunc` keep products cut off the same way
+// without working out the terms that would be thrown away.
+//
+// `Poly2` does the same with two variables, x for the size and y for a weight, such as
+// the sum of the items chosen.
+package genfunc
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Poly is a polynomial in x with integer coefficients. The zero value is the zero
+// polynomial.
+type Poly struct {
+	// coeffs[i] is the coefficient of x^i. The last one is never 0.
+	coeffs []*big.Int
+}
+
+// New creates the polynomial coeffs[0] + coeffs[1] x + coeffs[2] x^2 + ...
+func New(coeffs ...int64) *Poly {
+	c := make([]*big.Int, len(coeffs))
+	for i, v := range coeffs {
+		c[i] = big.NewInt(v)
+	}
+	return from_owned(c)
+}
+
+// FromBig is like `New`, for coefficients that do not fit in an int64. The coefficients
+// are copied.
+func FromBig(coeffs []*big.Int) *Poly {
+	c := make([]*big.Int, len(coeffs))
+	for i, v := range coeffs {
+		c[i] = new(big.Int).Set(v)
+	}
+	return from_owned(c)
+}
+
+// Monomial creates the polynomial coeff * x^i. It is 0 if i is negative.
+func Monomial(coeff int64, i int) *Poly {
+	if i < 0 {
+		return &Poly{}
+	}
+	c := make([]*big.Int, i+1)
+	for j := range c {
+		c[j] = new(big.Int)
+	}
+	c[i].SetInt64(coeff)
+	return from_owned(c)
+}
+
+// from_owned makes a Poly out of coefficients nothing else refers to, taking off the zeros
+// at the end
+func from_owned(c []*big.Int) *Poly {
+	for len(c) > 0 && c[len(c)-1].Sign() == 0 {
+		c = c[:len(c)-1]
+	}
+	return &Poly{coeffs: c}
+}
+
+// Degree returns the largest power of x with a coefficient that is not 0, or -1 for the
+// zero polynomial
+func (p *Poly) Degree() int {
+	return len(p.coeffs) - 1
+}
+
+// Coeff returns the coefficient of x^i, which is 0 for any i beyond the degree or below 0
+func (p *Poly) Coeff(i int) *big.Int {
+	if i < 0 || i >= len(p.coeffs) {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(p.coeffs[i])
+}
+
+// Coeffs returns a copy of every coefficient, from x^0 up to x^Degree()
+func (p *Poly) Coeffs() []*big.Int {
+	return FromBig(p.coeffs).coeffs
+}
+
+// Equal reports whether p and q are the same polynomial
+func (p *Poly) Equal(q *Poly) bool {
+	if len(p.coeffs) != len(q.coeffs) {
+		return false
+	}
+	for i, c := range p.coeffs {
+		if c.Cmp(q.coeffs[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Add returns p + q
+func (p *Poly) Add(q *Poly) *Poly {
+	c := make([]*big.Int, max(len(p.coeffs), len(q.coeffs)))
+	for i := range c {
+		c[i] = new(big.Int)
+		if i < len(p.coeffs) {
+			c[i].Add(c[i], p.coeffs[i])
+		}
+		if i < len(q.coeffs) {
+			c[i].Add(c[i], q.coeffs[i])
+		}
+	}
+	return from_owned(c)
+}
+
+// Scale returns a * p
+func (p *Poly) Scale(a *big.Int) *Poly {
+	c := make([]*big.Int, len(p.coeffs))
+	for i, v := range p.coeffs {
+		c[i] = new(big.Int).Mul(a, v)
+	}
+	return from_owned(c)
+}
+
+// Mul returns p * q
+func (p *Poly) Mul(q *Poly) *Poly {
+	return p.MulTrunc(q, p.Degree()+q.Degree())
+}
+
+// MulTrunc returns p * q without any of the terms of degree above n. It only works out
+// the terms it keeps.
+func (p *Poly) MulTrunc(q *Poly, n int) *Poly {
+	if len(p.coeffs) == 0 || len(q.coeffs) == 0 || n < 0 {
+		return &Poly{}
+	}
+	c := make([]*big.Int, min(n, p.Degree()+q.Degree())+1)
+	for i := range c {
+		c[i] = new(big.Int)
+	}
+	term := new(big.Int)
+	for i, a := range p.coeffs {
+		if i >= len(c) {
+			break
+		}
+		if a.Sign() == 0 {
+			continue
+		}
+		for j, b := range q.coeffs {
+			if i+j >= len(c) {
+				break
+			}
+			c[i+j].Add(c[i+j], term.Mul(a, b))
+		}
+	}
+	return from_owned(c)
+}
+
+// Pow returns p^e. It panics if e is negative.
+func (p *Poly) Pow(e int) *Poly {
+	if e < 0 {
+		panic("genfunc: negative exponent")
+	}
+	return p.PowTrunc(e, e*max(p.Degree(), 0))
+}
+
+// PowTrunc returns p^e without any of the terms of degree above n, working out the
+// power by repeated squaring with every product cut off at n. It panics if e is
+// negative.
+func (p *Poly) PowTrunc(e, n int) *Poly {
+	if e < 0 {
+		panic("genfunc: negative exponent")
+	}
+	result := New(1).Truncate(n)
+	square := p.Truncate(n)
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result = result.MulTrunc(square, n)
+		}
+		if e > 1 {
+			square = square.MulTrunc(square, n)
+		}
+	}
+	return result
+}
+
+// Truncate returns p without any of the terms of degree above n
+func (p *Poly) Truncate(n int) *Poly {
+	if n < 0 {
+		return &Poly{}
+	}
+	return FromBig(p.coeffs[:min(n, len(p.coeffs)-1)+1])
+}
+
+// Eval returns p(x)
+func (p *Poly) Eval(x *big.Int) *big.Int {
+	// Horner's method
+	result := new(big.Int)
+	for i := len(p.coeffs) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, p.coeffs[i])
+	}
+	return result
+}
+
+// String writes the polynomial out from the lowest power up, e.g. "1 + 3x + 3x^2 + x^3"
+func (p *Poly) String() string {
+	if len(p.coeffs) == 0 {
+		return "0"
+	}
+	var sb strings.Builder
+	for i, c := range p.coeffs {
+		if c.Sign() == 0 {
+			continue
+		}
+		// The sign goes in the separator, except at the very start
+		abs := new(big.Int).Abs(c)
+		if sb.Len() == 0 {
+			if c.Sign() < 0 {
+				sb.WriteString("-")
+			}
+		} else if c.Sign() < 0 {
+			sb.WriteString(" - ")
+		} else {
+			sb.WriteString(" + ")
+		}
+		if abs.Cmp(big.NewInt(1)) != 0 || i == 0 {
+			sb.WriteString(abs.String())
+		}
+		switch {
+		case i == 1:
+			sb.WriteString("x")
+		case i > 1:
+			fmt.Fprintf(&sb, "x^%d", i)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,247 @@
+package genfunc
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestPolyString(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    *Poly
+		want string
+	}{
+		{desc: "zero", p: &Poly{}, want: "0"},
+		{desc: "zeros at the end are dropped", p: New(0, 0, 0), want: "0"},
+		{desc: "constant", p: New(-3), want: "-3"},
+		{desc: "binomial", p: New(1, 3, 3, 1), want: "1 + 3x + 3x^2 + x^3"},
+		{desc: "signs and gaps", p: New(0, -1, 0, 2, -5), want: "-x + 2x^3 - 5x^4"},
+		{desc: "monomial", p: Monomial(7, 2), want: "7x^2"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.String(); got != tC.want {
+				t.Errorf("String() = %v, want %v", got, tC.want)
+			}
+		})
+	}
+}
+
+func TestPolyArithmetic(t *testing.T) {
+	p := New(1, 1)
+	q := New(1, -1)
+	testCases := []struct {
+		desc string
+		got  *Poly
+		want *Poly
+	}{
+		{desc: "Add", got: p.Add(q), want: New(2)},
+		{desc: "Add cancels the top", got: New(1, 2, 3).Add(New(0, 0, -3)), want: New(1, 2)},
+		{desc: "Mul", got: p.Mul(q), want: New(1, 0, -1)},
+		{desc: "Mul by zero", got: p.Mul(&Poly{}), want: &Poly{}},
+		{desc: "MulTrunc", got: p.Pow(3).MulTrunc(p.Pow(3), 2), want: New(1, 6, 15)},
+		{desc: "Pow", got: p.Pow(4), want: New(1, 4, 6, 4, 1)},
+		{desc: "Pow 0", got: q.Pow(0), want: New(1)},
+		{desc: "Pow 0 of zero", got: (&Poly{}).Pow(0), want: New(1)},
+		{desc: "PowTrunc", got: p.PowTrunc(10, 3), want: New(1, 10, 45, 120)},
+		{desc: "PowTrunc negative n", got: p.PowTrunc(10, -1), want: &Poly{}},
+		{desc: "Truncate", got: New(1, 2, 3).Truncate(1), want: New(1, 2)},
+		{desc: "Truncate beyond the degree", got: New(1, 2, 3).Truncate(10), want: New(1, 2, 3)},
+		{desc: "Scale", got: New(1, 2).Scale(big.NewInt(-3)), want: New(-3, -6)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if !tC.got.Equal(tC.want) {
+				t.Errorf("got %v, want %v", tC.got, tC.want)
+			}
+		})
+	}
+}
+
+func TestPolyCoefficientsAreCopies(t *testing.T) {
+	p := New(1, 2, 3)
+	p.Coeff(1).SetInt64(100)
+	p.Coeffs()[2].SetInt64(100)
+	q := p.Truncate(5)
+	q.Coeff(0).SetInt64(100)
+	if want := New(1, 2, 3); !p.Equal(want) {
+		t.Errorf("p = %v after changing copies, want %v", p, want)
+	}
+	if got := p.Coeff(-1); got.Sign() != 0 {
+		t.Errorf("Coeff(-1) = %v, want 0", got)
+	}
+	if got := p.Coeff(3); got.Sign() != 0 {
+		t.Errorf("Coeff(3) = %v, want 0", got)
+	}
+}
+
+func TestEval(t *testing.T) {
+	// (1 + x)^20 at x = 1 is 2^20
+	if got := Subsets(20).Eval(big.NewInt(1)); got.Int64() != 1<<20 {
+		t.Errorf("Subsets(20).Eval(1) = %v, want %v", got, 1<<20)
+	}
+	if got := New(1, -2, 1).Eval(big.NewInt(5)); got.Int64() != 16 {
+		t.Errorf("(1 - 2x + x^2).Eval(5) = %v, want 16", got)
+	}
+}
+
+func TestBuilders(t *testing.T) {
+	testCases := []struct {
+		desc string
+		got  *Poly
+		want *Poly
+	}{
+		{desc: "Subsets", got: Subsets(4), want: New(1, 4, 6, 4, 1)},
+		{desc: "Subsets of nothing", got: Subsets(0), want: New(1)},
+		{desc: "SubsetsAtMost", got: SubsetsAtMost(5, 2), want: New(1, 5, 10)},
+		{desc: "SubsetsAtMost more than n", got: SubsetsAtMost(2, 5), want: New(1, 2, 1)},
+		{desc: "Multisets", got: Multisets(3, 4), want: New(1, 3, 6, 10, 15)},
+		{desc: "Multisets of nothing", got: Multisets(0, 4), want: New(1)},
+		{desc: "Bounded", got: Bounded(3, 1), want: Subsets(3)},
+		{desc: "Bounded by 2", got: Bounded(2, 2), want: New(1, 2, 3, 2, 1)},
+		{desc: "BoundedEach", got: BoundedEach(1, 2), want: New(1, 2, 2, 1)},
+		{desc: "BoundedEach of nothing", got: BoundedEach(), want: New(1)},
+		{desc: "negative n", got: Subsets(-1), want: &Poly{}},
+		{desc: "SubsetsAtMost negative m", got: SubsetsAtMost(3, -2), want: &Poly{}},
+		{desc: "SubsetsAtMost m = -1", got: SubsetsAtMost(3, -1), want: &Poly{}},
+		{desc: "negative limit", got: BoundedEach(2, -1), want: &Poly{}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if !tC.got.Equal(tC.want) {
+				t.Errorf("got %v, want %v", tC.got, tC.want)
+			}
+		})
+	}
+}
+
+func TestBoundedAgreesWithMultisets(t *testing.T) {
+	// With a bound at least as big as the size, the bound makes no difference
+	for n := 1; n <= 6; n++ {
+		if got, want := Bounded(n, 8).Truncate(8), Multisets(n, 8); !got.Equal(want) {
+			t.Errorf("Bounded(%v, 8) = %v, want %v up to x^8", n, got, want)
+		}
+	}
+}
+
+func TestPoly2(t *testing.T) {
+	// Items weighing 1, 2 and 2
+	p := SubsetsByWeight(1, 2, 2)
+	if got, want := p.String(), "1 + x y + 2x y^2 + 2x^2 y^3 + x^2 y^4 + x^3 y^5"; got != want {
+		t.Errorf("SubsetsByWeight(1, 2, 2) = %v, want %v", got, want)
+	}
+	if got, want := p.Sizes(), Subsets(3); !got.Equal(want) {
+		t.Errorf("Sizes() = %v, want %v", got, want)
+	}
+	if got, want := p.Weights(), New(1, 1, 2, 2, 1, 1); !got.Equal(want) {
+		t.Errorf("Weights() = %v, want %v", got, want)
+	}
+	if got := p.Coeff(1, 2); got.Int64() != 2 {
+		t.Errorf("Coeff(1, 2) = %v, want 2", got)
+	}
+	if got, want := p.Truncate(2, 3).String(), "1 + x y + 2x y^2 + 2x^2 y^3"; got != want {
+		t.Errorf("Truncate(2, 3) = %v, want %v", got, want)
+	}
+
+	// A product of the items one at a time is the same as all at once
+	q := SubsetsByWeight(1).Mul(SubsetsByWeight(2).Pow(2))
+	if !q.Equal(p) {
+		t.Errorf("SubsetsByWeight(1) * SubsetsByWeight(2)^2 = %v, want %v", q, p)
+	}
+	if got := p.PowTrunc(3, 2, 4); !got.Equal(p.Pow(3).Truncate(2, 4)) {
+		t.Errorf("PowTrunc(3, 2, 4) = %v, want %v", got, p.Pow(3).Truncate(2, 4))
+	}
+	if got := (&Poly2{}).Add(Monomial2(-1, 0, 0)).String(); got != "-1" {
+		t.Errorf("String() = %v, want -1", got)
+	}
+}
+
+func TestSubsetsByWeightBruteForce(t *testing.T) {
+	weights := []int{3, 1, 4, 1, 5, 9, 2, 6}
+	p := SubsetsByWeight(weights...)
+	want := make(map[[2]int]int64)
+	for mask := 0; mask < 1<<len(weights); mask++ {
+		size, weight := 0, 0
+		for i, w := range weights {
+			if mask&(1<<i) != 0 {
+				size++
+				weight += w
+			}
+		}
+		want[[2]int{size, weight}]++
+	}
+	for i := 0; i <= len(weights); i++ {
+		for j := 0; j <= 40; j++ {
+			if got := p.Coeff(i, j); got.Int64() != want[[2]int{i, j}] {
+				t.Errorf("Coeff(%v, %v) = %v, want %v", i, j, got, want[[2]int{i, j}])
+			}
+		}
+	}
+}
+
+func TestMultisetsByWeight(t *testing.T) {
+	// With every weight 1, the weight is the size
+	p := MultisetsByWeight(5, 1, 1, 1)
+	for i := 0; i <= 5; i++ {
+		want := Multisets(3, 5).Coeff(i)
+		if got := p.Coeff(i, i); got.Cmp(want) != 0 {
+			t.Errorf("Coeff(%v, %v) = %v, want %v", i, i, got, want)
+		}
+	}
+	if got := p.SizeDegree(); got != 5 {
+		t.Errorf("SizeDegree() = %v, want 5", got)
+	}
+
+	// Multisets of coins worth 1 and 2 adding up to j: there are j/2 + 1 of them, and
+	// as many as j items, so every one is within the size limit
+	coins := MultisetsByWeight(10, 1, 2).Weights()
+	for j := 0; j <= 10; j++ {
+		if got := coins.Coeff(j); got.Int64() != int64(j/2+1) {
+			t.Errorf("ways to make %v = %v, want %v", j, got, j/2+1)
+		}
+	}
+	if got := MultisetsByWeight(3, 1, -1); got.SizeDegree() != -1 {
+		t.Errorf("MultisetsByWeight with a negative weight = %v, want 0", got)
+	}
+	if got := MultisetsByWeight(math.MaxInt - 1); got.SizeDegree() != 0 {
+		t.Errorf("MultisetsByWeight with no weights = %v, want 1", got)
+	}
+}
+
+func BenchmarkPowTrunc(b *testing.B) {
+	p := Bounded(1, 2)
+	for i := 0; i < b.N; i++ {
+		p.PowTrunc(200, 100)
+	}
+}
+
+func TestMultisetsByWeightNegativeSize(t *testing.T) {
+	for _, max_size := range []int{-1, -2, -10} {
+		if got := MultisetsByWeight(max_size, 1, 2); !got.Equal(&Poly2{}) {
+			t.Errorf("MultisetsByWeight(%v, 1, 2) = %v, want 0", max_size, got)
+		}
+	}
+}
+
+func TestPowPanicsOnNegativeExponent(t *testing.T) {
+	testCases := []struct {
+		desc string
+		pow  func()
+	}{
+		{desc: "Pow", pow: func() { New(1, 1).Pow(-1) }},
+		{desc: "PowTrunc", pow: func() { New(1, 1).PowTrunc(-1, 5) }},
+		{desc: "Poly2 Pow", pow: func() { Monomial2(1, 1, 1).Pow(-1) }},
+		{desc: "Poly2 PowTrunc", pow: func() { Monomial2(1, 1, 1).PowTrunc(-2, 3, 3) }},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v with a negative exponent did not panic", tC.desc)
+				}
+			}()
+			tC.pow()
+		})
+	}
+}
@@ -0,0 +1,352 @@
+// Package group works with permutation groups: the groups you get from composing some
+// generator permutations in every possible way, such as the moves of a puzzle or the
+// symmetries of a board.
+//
+// A permutation of 0, ..., n-1 is a []int holding where each point goes: p sends i to
+// p[i]. This is the same layout as gocombinatorics.Perm, so those can be passed in
+// directly.
+//
+// Groups are stored as a stabilizer chain built with the Schreier-Sims algorithm, which
+// gives the exact order of the group, membership tests, and a way to step through or
+// sample elements, without ever listing the whole group.
+package group
+
+import (
+	"errors"
+	"math/big"
+	"math/rand/v2"
+)
+
+// Group is a permutation group given by generators. Create it with `NewGroup`.
+type Group struct {
+	n      int
+	gens   [][]int
+	levels []*level
+	kind   group_kind
+}
+
+// group_kind marks groups made by the built in constructors, whose cycle index has a
+// closed form.
+type group_kind int
+
+const (
+	kind_general group_kind = iota
+	kind_cyclic
+	kind_dihedral
+	kind_symmetric
+)
+
+// level is one step of the stabilizer chain. Every element at this level fixes the base
+// points of all the earlier levels. gens generate the elements at this level, orbit is
+// where they can send base, and trans[p] is an element sending base to p (nil if p is
+// not in the orbit). inv[p] is the inverse of trans[p].
+type level struct {
+	base  int
+	gens  [][]int
+	orbit []int
+	trans [][]int
+	inv   [][]int
+}
+
+var errNotAPermutation = errors.New("generators must be permutations of 0, ..., n-1")
+
+// NewGroup creates the group generated by the given permutations. They must all have
+// the same length n, and there must be at least one. To get the trivial group on n
+// points, pass the identity.
+func NewGroup(generators ...[]int) (*Group, error) {
+	return new_group_with_base(nil, generators)
+}
+
+// new_group_with_base makes the group generated by generators, starting the stabilizer
+// chain with the given base points.
+func new_group_with_base(base []int, generators [][]int) (*Group, error) {
+	if len(generators) == 0 {
+		return nil, errors.New("there must be at least one generator")
+	}
+	n := len(generators[0])
+	gens := make([][]int, len(generators))
+	for i, gen := range generators {
+		if len(gen) != n || !is_permutation(gen) {
+			return nil, errNotAPermutation
+		}
+		gens[i] = make([]int, n)
+		copy(gens[i], gen)
+	}
+
+	g := &Group{n: n, gens: gens}
+	for _, b := range base {
+		g.levels = append(g.levels, new_level(n, b))
+	}
+	for _, gen := range gens {
+		if !g.Contains(gen) {
+			g.add_gen(0, gen)
+		}
+	}
+	return g, nil
+}
+
+func new_level(n, base int) *level {
+	trans := make([][]int, n)
+	inv := make([][]int, n)
+	trans[base] = identity(n)
+	inv[base] = trans[base]
+	return &level{base: base, orbit: []int{base}, trans: trans, inv: inv}
+}
+
+// add_gen adds s, which fixes the base points of levels before i, to the generators of
+// level i. It extends the orbit at level i, and checks every new Schreier generator
+// (an element of the next level down) against the rest of the chain, adding it there if
+// it is missing. This is the incremental Schreier-Sims algorithm.
+func (g *Group) add_gen(i int, s []int) {
+	if i == len(g.levels) {
+		g.levels = append(g.levels, new_level(g.n, first_moved(s)))
+	}
+	lv := g.levels[i]
+	lv.gens = append(lv.gens, s)
+
+	// Points already in the orbit only need checking against the new generator. Points
+	// that join the orbit need checking against all of them.
+	already := len(lv.orbit)
+	for idx := 0; idx < len(lv.orbit); idx++ {
+		beta := lv.orbit[idx]
+		check := lv.gens
+		if idx < already {
+			check = [][]int{s}
+		}
+		for _, t := range check {
+			gamma := t[beta]
+			if lv.trans[gamma] == nil {
+				lv.trans[gamma] = compose(t, lv.trans[beta])
+				lv.inv[gamma] = inverse(lv.trans[gamma])
+				lv.orbit = append(lv.orbit, gamma)
+				continue
+			}
+			// u_gamma^-1 t u_beta fixes the base point, so belongs one level down
+			h := compose(lv.inv[gamma], compose(t, lv.trans[beta]))
+			if residue, _ := g.strip(h, i+1); !is_identity(residue) {
+				g.add_gen(i+1, residue)
+			}
+		}
+	}
+}
+
+// strip sifts p down the chain from level `from`, dividing out a transversal element at
+// each level. It returns what is left, and the level where it got stuck (len(levels) if
+// it made it all the way). p is in the group exactly when what is left is the identity.
+func (g *Group) strip(p []int, from int) ([]int, int) {
+	for l := from; l < len(g.levels); l++ {
+		lv := g.levels[l]
+		u_inv := lv.inv[p[lv.base]]
+		if u_inv == nil {
+			return p, l
+		}
+		p = compose(u_inv, p)
+	}
+	return p, len(g.levels)
+}
+
+// Degree is n, the number of points the group acts on
+func (g *Group) Degree() int {
+	return g.n
+}
+
+// Generators returns a copy of the generators the group was made from, so changing it
+// does not change the group
+func (g *Group) Generators() [][]int {
+	gens := make([][]int, len(g.gens))
+	for i, gen := range g.gens {
+		gens[i] = make([]int, len(gen))
+		copy(gens[i], gen)
+	}
+	return gens
+}
+
+// Order returns the number of elements in the group: the product of the orbit sizes
+// down the stabilizer chain.
+func (g *Group) Order() *big.Int {
+	order := big.NewInt(1)
+	for _, lv := range g.levels {
+		order.Mul(order, big.NewInt(int64(len(lv.orbit))))
+	}
+	return order
+}
+
+// Contains reports whether p is an element of the group
+func (g *Group) Contains(p []int) bool {
+	if len(p) != g.n || !is_permutation(p) {
+		return false
+	}
+	residue, _ := g.strip(p, 0)
+	return is_identity(residue)
+}
+
+// Orbit returns every point the group can send point to, starting with point itself.
+// It returns nil if point is not in [0, n).
+func (g *Group) Orbit(point int) []int {
+	if point < 0 || point >= g.n {
+		return nil
+	}
+	seen := make([]bool, g.n)
+	seen[point] = true
+	orbit := []int{point}
+	for idx := 0; idx < len(orbit); idx++ {
+		for _, gen := range g.gens {
+			if next := gen[orbit[idx]]; !seen[next] {
+				seen[next] = true
+				orbit = append(orbit, next)
+			}
+		}
+	}
+	return orbit
+}
+
+// Stabilizer returns the subgroup of elements that leave every one of points where it
+// is. It rebuilds the stabilizer chain with points at the front, and keeps the part of
+// the chain below them.
+func (g *Group) Stabilizer(points ...int) (*Group, error) {
+	seen := make([]bool, g.n)
+	for _, p := range points {
+		if p < 0 || p >= g.n || seen[p] {
+			return nil, errors.New("points must be distinct and in the range [0, n)")
+		}
+		seen[p] = true
+	}
+	full, _ := new_group_with_base(points, g.gens)
+	below := full.levels[len(points):]
+
+	// The generators of the stabilizer are the strong generators at the first level
+	// below the given points
+	gens := [][]int{identity(g.n)}
+	if len(below) > 0 && len(below[0].gens) > 0 {
+		gens = below[0].gens
+	}
+	return &Group{n: g.n, gens: gens, levels: below}, nil
+}
+
+// Random returns an element of the group chosen uniformly at random, using randomness
+// from src. Picking a uniformly random transversal element at each level of the chain
+// and multiplying them together gives every element with the same probability.
+func (g *Group) Random(src rand.Source) []int {
+	rng := rand.New(src)
+	result := identity(g.n)
+	for _, lv := range g.levels {
+		u := lv.trans[lv.orbit[rng.IntN(len(lv.orbit))]]
+		result = compose(result, u)
+	}
+	return result
+}
+
+// ElementIterator steps through every element of a group exactly once. Create it with
+// `Group.Elements()`, iterate with `Next()` and get each element with `Element()`.
+type ElementIterator struct {
+	g       *Group
+	choice  []int
+	isfirst bool
+	buffer  []int
+	scratch []int
+}
+
+// Elements returns an iterator over every element of the group. Each element is
+// written uniquely as a product of one transversal element from each level of the
+// stabilizer chain, so the iterator counts through those choices like an odometer.
+func (g *Group) Elements() *ElementIterator {
+	return &ElementIterator{
+		g:       g,
+		choice:  make([]int, len(g.levels)),
+		isfirst: true,
+		buffer:  identity(g.n),
+		scratch: identity(g.n),
+	}
+}
+
+// Next moves on to the next element, returning false when they have all been seen
+func (e *ElementIterator) Next() bool {
+	if e.isfirst {
+		e.isfirst = false
+		e.fill_buffer()
+		return true
+	}
+	for l := len(e.choice) - 1; l >= 0; l-- {
+		e.choice[l]++
+		if e.choice[l] < len(e.g.levels[l].orbit) {
+			e.fill_buffer()
+			return true
+		}
+		e.choice[l] = 0
+	}
+	return false
+}
+
+func (e *ElementIterator) fill_buffer() {
+	for i := range e.buffer {
+		e.buffer[i] = i
+	}
+	for l, lv := range e.g.levels {
+		u := lv.trans[lv.orbit[e.choice[l]]]
+		for i, v := range u {
+			e.scratch[i] = e.buffer[v]
+		}
+		e.buffer, e.scratch = e.scratch, e.buffer
+	}
+}
+
+// Element returns the current element. The slice returned will be overwritten every
+// iteration. If you need to keep it, be sure to make a copy.
+func (e *ElementIterator) Element() []int {
+	return e.buffer
+}
+
+// compose returns the permutation that does q first, then p: result[i] = p[q[i]]
+func compose(p, q []int) []int {
+	result := make([]int, len(q))
+	for i, v := range q {
+		result[i] = p[v]
+	}
+	return result
+}
+
+func inverse(p []int) []int {
+	result := make([]int, len(p))
+	for i, v := range p {
+		result[v] = i
+	}
+	return result
+}
+
+func identity(n int) []int {
+	result := make([]int, n)
+	for i := range result {
+		result[i] = i
+	}
+	return result
+}
+
+func is_identity(p []int) bool {
+	for i, v := range p {
+		if i != v {
+			return false
+		}
+	}
+	return true
+}
+
+// first_moved returns the smallest point p does not fix, or -1 for the identity
+func first_moved(p []int) int {
+	for i, v := range p {
+		if i != v {
+			return i
+		}
+	}
+	return -1
+}
+
+func is_permutation(p []int) bool {
+	seen := make([]bool, len(p))
+	for _, v := range p {
+		if v < 0 || v >= len(p) || seen[v] {
+			return false
+		}
+		seen[v] = true
+	}
+	return true
+}
@@ -0,0 +1,243 @@
+package group
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"testing"
+)
+
+// cycles_to_perm builds a permutation of n points from cycles written 1-based, the way
+// they usually appear in the literature
+func cycles_to_perm(n int, cycles ...[]int) []int {
+	p := identity(n)
+	for _, cycle := range cycles {
+		for i, v := range cycle {
+			p[v-1] = cycle[(i+1)%len(cycle)] - 1
+		}
+	}
+	return p
+}
+
+func reflection(n int) []int {
+	p := make([]int, n)
+	for i := range p {
+		p[i] = (n - i) % n
+	}
+	return p
+}
+
+func transposition(n, a, b int) []int {
+	p := identity(n)
+	p[a], p[b] = b, a
+	return p
+}
+
+func TestNewGroupErrors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		gens [][]int
+	}{
+		{desc: "no generators", gens: [][]int{}},
+		{desc: "not a permutation", gens: [][]int{{0, 0, 1}}},
+		{desc: "different lengths", gens: [][]int{{0, 1}, {0, 1, 2}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := NewGroup(tC.gens...); err == nil {
+				t.Errorf("NewGroup() did not return an error")
+			}
+		})
+	}
+}
+
+func TestGroupOrder(t *testing.T) {
+	testCases := []struct {
+		desc string
+		gens [][]int
+		want string
+	}{
+		{desc: "trivial", gens: [][]int{identity(5)}, want: "1"},
+		{desc: "cyclic 7", gens: [][]int{rotation(7)}, want: "7"},
+		{desc: "dihedral 8", gens: [][]int{rotation(8), reflection(8)}, want: "16"},
+		{desc: "symmetric 6", gens: [][]int{rotation(6), transposition(6, 0, 1)}, want: "720"},
+		{desc: "symmetric 20", gens: [][]int{rotation(20), transposition(20, 0, 1)}, want: "2432902008176640000"},
+		{
+			desc: "alternating 7",
+			gens: [][]int{cycles_to_perm(7, []int{1, 2, 3}), cycles_to_perm(7, []int{3, 4, 5, 6, 7})},
+			want: "2520",
+		},
+		{
+			desc: "Mathieu 11",
+			gens: [][]int{
+				cycles_to_perm(11, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}),
+				cycles_to_perm(11, []int{3, 7, 11, 8}, []int{4, 10, 5, 6}),
+			},
+			want: "7920",
+		},
+		{
+			desc: "Mathieu 12",
+			gens: [][]int{
+				cycles_to_perm(12, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}),
+				cycles_to_perm(12, []int{3, 7, 11, 8}, []int{4, 10, 5, 6}),
+				cycles_to_perm(12, []int{1, 12}, []int{2, 11}, []int{3, 6}, []int{4, 8}, []int{5, 9}, []int{7, 10}),
+			},
+			want: "95040",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			g, err := NewGroup(tC.gens...)
+			if err != nil {
+				t.Fatalf("NewGroup() = %v, want nil", err)
+			}
+			want, _ := new(big.Int).SetString(tC.want, 10)
+			if got := g.Order(); got.Cmp(want) != 0 {
+				t.Errorf("Order() = %v, want %v", got, want)
+			}
+			for _, gen := range tC.gens {
+				if !g.Contains(gen) {
+					t.Errorf("Contains(%v) = false for a generator", gen)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupContains(t *testing.T) {
+	// The alternating group has the even permutations only
+	g, _ := NewGroup(cycles_to_perm(5, []int{1, 2, 3}), cycles_to_perm(5, []int{1, 2, 3, 4, 5}))
+	if !g.Contains(cycles_to_perm(5, []int{1, 2}, []int{3, 4})) {
+		t.Errorf("Contains() = false for an even permutation")
+	}
+	if g.Contains(cycles_to_perm(5, []int{1, 2})) {
+		t.Errorf("Contains() = true for an odd permutation")
+	}
+	if g.Contains([]int{0, 1, 2}) {
+		t.Errorf("Contains() = true for a permutation of the wrong length")
+	}
+	if g.Contains([]int{0, 0, 1, 2, 3}) {
+		t.Errorf("Contains() = true for something that is not a permutation")
+	}
+}
+
+func TestGroupElements(t *testing.T) {
+	testCases := []struct {
+		desc string
+		gens [][]int
+	}{
+		{desc: "trivial", gens: [][]int{identity(3)}},
+		{desc: "dihedral 6", gens: [][]int{rotation(6), reflection(6)}},
+		{desc: "symmetric 5", gens: [][]int{rotation(5), transposition(5, 0, 1)}},
+		{desc: "two disjoint cycles", gens: [][]int{cycles_to_perm(7, []int{1, 2, 3}, []int{4, 5}), cycles_to_perm(7, []int{6, 7})}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			g, _ := NewGroup(tC.gens...)
+			seen := make(map[string]bool)
+			e := g.Elements()
+			for e.Next() {
+				key := fmt.Sprint(e.Element())
+				if seen[key] {
+					t.Errorf("Saw %v twice", key)
+				}
+				seen[key] = true
+				if !g.Contains(e.Element()) {
+					t.Errorf("Element %v is not in the group", key)
+				}
+			}
+			if g.Order().Cmp(big.NewInt(int64(len(seen)))) != 0 {
+				t.Errorf("Saw %v elements, want %v", len(seen), g.Order())
+			}
+
+			// Closed under the generators
+			e = g.Elements()
+			for e.Next() {
+				for _, gen := range tC.gens {
+					if key := fmt.Sprint(compose(gen, e.Element())); !seen[key] {
+						t.Errorf("%v is missing from the elements", key)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGroupOrbitStabilizer(t *testing.T) {
+	g, _ := NewGroup(
+		cycles_to_perm(11, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}),
+		cycles_to_perm(11, []int{3, 7, 11, 8}, []int{4, 10, 5, 6}),
+	)
+	if orbit := g.Orbit(0); len(orbit) != 11 || orbit[0] != 0 {
+		t.Errorf("Orbit(0) = %v, want all 11 points starting at 0", orbit)
+	}
+	if orbit := g.Orbit(11); orbit != nil {
+		t.Errorf("Orbit(11) = %v, want nil", orbit)
+	}
+
+	// Orbit-stabilizer: |G| = |orbit| * |stabilizer|
+	stab, err := g.Stabilizer(4)
+	if err != nil {
+		t.Fatalf("Stabilizer() = %v, want nil", err)
+	}
+	if got := stab.Order(); got.Cmp(big.NewInt(720)) != 0 {
+		t.Errorf("Stabilizer(4).Order() = %v, want 720", got)
+	}
+	e := stab.Elements()
+	for e.Next() {
+		if e.Element()[4] != 4 {
+			t.Errorf("Stabilizer(4) has an element %v that moves 4", e.Element())
+		}
+	}
+
+	// M11 is sharply 4-transitive, so fixing 4 points leaves only the identity
+	stab, _ = g.Stabilizer(0, 1, 2, 3)
+	if got := stab.Order(); got.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Stabilizer(0, 1, 2, 3).Order() = %v, want 1", got)
+	}
+	if _, err := g.Stabilizer(0, 0); err == nil {
+		t.Errorf("Stabilizer(0, 0) did not return an error")
+	}
+}
+
+func TestGroupRandom(t *testing.T) {
+	g, _ := NewGroup(rotation(4), transposition(4, 0, 1))
+	src := rand.NewPCG(3, 4)
+	counts := make(map[string]int)
+	draws := 24000
+	for i := 0; i < draws; i++ {
+		p := g.Random(src)
+		if !g.Contains(p) {
+			t.Fatalf("Random() = %v, which is not in the group", p)
+		}
+		counts[fmt.Sprint(p)]++
+	}
+	if len(counts) != 24 {
+		t.Errorf("Random() gave %v different elements, want 24", len(counts))
+	}
+	for key, count := range counts {
+		if count < 850 || count > 1150 {
+			t.Errorf("Random() gave %v %v times, expected about 1000", key, count)
+		}
+	}
+}
+
+func TestGroupGeneratorsIsACopy(t *testing.T) {
+	g, _ := Cyclic(5)
+	gens := g.Generators()
+	gens[0][0], gens[0][1] = gens[0][1], gens[0][0]
+	gens[0] = identity(5)
+	if got := g.Generators()[0]; got[0] != 1 || got[1] != 2 {
+		t.Errorf("Generators()[0] = %v after changing the copy, want the rotation", got)
+	}
+	if g.Order().Int64() != 5 || !g.Contains(rotation(5)) {
+		t.Errorf("changing the result of Generators() changed the group")
+	}
+}
+
+func BenchmarkNewGroup(b *testing.B) {
+	gens := [][]int{rotation(50), transposition(50, 0, 1)}
+	for i := 0; i < b.N; i++ {
+		NewGroup(gens...)
+	}
+}
@@ -0,0 +1,352 @@
+package group
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// Cyclic returns the cyclic group of rotations of n points arranged in a circle, the
+// symmetries of a necklace.
+func Cyclic(n int) (*Group, error) {
+	if n <= 0 {
+		return nil, errors.New("n must be greater than 0")
+	}
+	g, err := NewGroup(rotation(n))
+	if err != nil {
+		return nil, err
+	}
+	g.kind = kind_cyclic
+	return g, nil
+}
+
+// Dihedral returns the dihedral group of rotations and reflections of n points arranged
+// in a circle, the symmetries of a bracelet. For n >= 3 it has 2n elements.
+func Dihedral(n int) (*Group, error) {
+	if n <= 0 {
+		return nil, errors.New("n must be greater than 0")
+	}
+	reflection := make([]int, n)
+	for i := range reflection {
+		reflection[i] = (n - i) % n
+	}
+	g, err := NewGroup(rotation(n), reflection)
+	if err != nil {
+		return nil, err
+	}
+	// With 1 or 2 points, a reflection is no different to a rotation
+	if n >= 3 {
+		g.kind = kind_dihedral
+	}
+	return g, nil
+}
+
+// Symmetric returns the group of all n! permutations of n points. Its stabilizer chain
+// is known in advance (fix 0, then 1, then 2, ...), so it is built directly rather than
+// with Schreier-Sims.
+func Symmetric(n int) (*Group, error) {
+	if n <= 0 {
+		return nil, errors.New("n must be greater than 0")
+	}
+	swap := identity(n)
+	if n > 1 {
+		swap[0], swap[1] = 1, 0
+	}
+	g := &Group{n: n, gens: [][]int{rotation(n), swap}, kind: kind_symmetric}
+
+	for b := 0; b+1 < n; b++ {
+		lv := new_level(n, b)
+		for p := b + 1; p < n; p++ {
+			// The transposition (b p) sends b to p, and is its own inverse
+			t := identity(n)
+			t[b], t[p] = p, b
+			lv.gens = append(lv.gens, t)
+			lv.trans[p] = t
+			lv.inv[p] = t
+			lv.orbit = append(lv.orbit, p)
+		}
+		g.levels = append(g.levels, lv)
+	}
+	return g, nil
+}
+
+func rotation(n int) []int {
+	p := make([]int, n)
+	for i := range p {
+		p[i] = (i + 1) % n
+	}
+	return p
+}
+
+// Term is one term of a cycle index: Coeff times the product over j of x_(j+1) raised to
+// the power Cycles[j]. Cycles[j] is how many cycles of length j+1 a permutation has.
+type Term struct {
+	Coeff  *big.Rat
+	Cycles []int
+}
+
+// Polynomial is the cycle index of a permutation group on n points: the average over
+// the group of x_1^(cycles of length 1) * x_2^(cycles of length 2) * ...
+type Polynomial struct {
+	n     int
+	terms []Term
+	index map[string]int
+}
+
+// CycleIndex returns the cycle index of g. The cyclic, dihedral and symmetric groups
+// from `Cyclic`, `Dihedral` and `Symmetric` use their closed forms. Any other group is
+// done by going through all of its elements, so it needs to be small enough for that.
+func CycleIndex(g *Group) *Polynomial {
+	p := &Polynomial{n: g.n, index: make(map[string]int)}
+	switch g.kind {
+	case kind_cyclic:
+		p.add_cyclic(big.NewRat(1, int64(g.n)))
+	case kind_dihedral:
+		p.add_cyclic(big.NewRat(1, int64(2*g.n)))
+		if g.n%2 == 1 {
+			// Every reflection fixes one point and swaps the rest in pairs
+			p.add(big.NewRat(1, 2), map[int]int{1: 1, 2: (g.n - 1) / 2})
+		} else {
+			// Half the reflections go through two points, half through none
+			p.add(big.NewRat(1, 4), map[int]int{2: g.n / 2})
+			p.add(big.NewRat(1, 4), map[int]int{1: 2, 2: (g.n - 2) / 2})
+		}
+	case kind_symmetric:
+		p.add_symmetric()
+	default:
+		p.add_elements(g)
+	}
+	p.sort()
+	return p
+}
+
+// add adds coeff times the monomial with cycles[j] cycles of length j
+func (p *Polynomial) add(coeff *big.Rat, cycles map[int]int) {
+	c := make([]int, p.n)
+	for length, count := range cycles {
+		c[length-1] += count
+	}
+	key := fmt.Sprint(c)
+	if i, ok := p.index[key]; ok {
+		p.terms[i].Coeff.Add(p.terms[i].Coeff, coeff)
+		return
+	}
+	p.index[key] = len(p.terms)
+	p.terms = append(p.terms, Term{Coeff: new(big.Rat).Set(coeff), Cycles: c})
+}
+
+// add_cyclic adds scale times the sum over divisors d of n of phi(d) x_d^(n/d), which
+// is n times the cycle index of the cyclic group
+func (p *Polynomial) add_cyclic(scale *big.Rat) {
+	for d := 1; d <= p.n; d++ {
+		if p.n%d != 0 {
+			continue
+		}
+		coeff := new(big.Rat).Mul(scale, big.NewRat(int64(euler_phi(d)), 1))
+		p.add(coeff, map[int]int{d: p.n / d})
+	}
+}
+
+// add_symmetric adds one term per partition of n: the permutations with a_j cycles of
+// length j make up 1 / (prod j^a_j a_j!) of the symmetric group.
+func (p *Polynomial) add_symmetric() {
+	counts := make([]int, p.n+1)
+	var partitions func(remaining, largest int)
+	partitions = func(remaining, largest int) {
+		if remaining == 0 {
+			denominator := big.NewInt(1)
+			cycles := make(map[int]int)
+			for j := 1; j <= p.n; j++ {
+				if counts[j] == 0 {
+					continue
+				}
+				cycles[j] = counts[j]
+				power := new(big.Int).Exp(big.NewInt(int64(j)), big.NewInt(int64(counts[j])), nil)
+				denominator.Mul(denominator, power)
+				denominator.Mul(denominator, new(big.Int).MulRange(1, int64(counts[j])))
+			}
+			p.add(new(big.Rat).SetFrac(big.NewInt(1), denominator), cycles)
+			return
+		}
+		for j := min(remaining, largest); j >= 1; j-- {
+			counts[j]++
+			partitions(remaining-j, j)
+			counts[j]--
+		}
+	}
+	partitions(p.n, p.n)
+}
+
+// add_elements works out the cycle type of every element of g
+func (p *Polynomial) add_elements(g *Group) {
+	share := new(big.Rat).SetFrac(big.NewInt(1), g.Order())
+	seen := make([]bool, p.n)
+	e := g.Elements()
+	for e.Next() {
+		element := e.Element()
+		cycles := make(map[int]int)
+		for i := range seen {
+			seen[i] = false
+		}
+		for start := range element {
+			if seen[start] {
+				continue
+			}
+			length := 0
+			for i := start; !seen[i]; i = element[i] {
+				seen[i] = true
+				length++
+			}
+			cycles[length]++
+		}
+		p.add(share, cycles)
+	}
+}
+
+// sort puts the terms with the most short cycles first, so the identity comes first.
+// Once sorted, no more terms can be added.
+func (p *Polynomial) sort() {
+	slices.SortFunc(p.terms, func(a, b Term) int {
+		return -slices.Compare(a.Cycles, b.Cycles)
+	})
+	p.index = nil
+}
+
+func euler_phi(n int) int {
+	result := n
+	for f := 2; f*f <= n; f++ {
+		if n%f == 0 {
+			for n%f == 0 {
+				n /= f
+			}
+			result -= result / f
+		}
+	}
+	if n > 1 {
+		result -= result / n
+	}
+	return result
+}
+
+// Terms returns a copy of the terms of the cycle index
+func (p *Polynomial) Terms() []Term {
+	result := make([]Term, len(p.terms))
+	for i, t := range p.terms {
+		result[i] = Term{Coeff: new(big.Rat).Set(t.Coeff), Cycles: slices.Clone(t.Cycles)}
+	}
+	return result
+}
+
+// String writes the cycle index out, e.g. "1/4 x1^4 + 1/4 x2^2 + 1/2 x4"
+func (p *Polynomial) String() string {
+	parts := make([]string, len(p.terms))
+	for i, t := range p.terms {
+		factors := make([]string, 0)
+		if t.Coeff.Cmp(big.NewRat(1, 1)) != 0 {
+			factors = append(factors, t.Coeff.RatString())
+		}
+		for j, count := range t.Cycles {
+			if count == 1 {
+				factors = append(factors, fmt.Sprintf("x%d", j+1))
+			} else if count > 1 {
+				factors = append(factors, fmt.Sprintf("x%d^%d", j+1, count))
+			}
+		}
+		parts[i] = strings.Join(factors, " ")
+	}
+	return strings.Join(parts, " + ")
+}
+
+// CountColorings returns how many ways there are to color the points with the given
+// number of colors, counting two colorings as the same when an element of the group
+// turns one into the other. By Polya's theorem, this is the cycle index with every x_j
+// set to colors.
+func (p *Polynomial) CountColorings(colors int) *big.Int {
+	total := new(big.Rat)
+	c := big.NewInt(int64(colors))
+	for _, t := range p.terms {
+		n_cycles := 0
+		for _, count := range t.Cycles {
+			n_cycles += count
+		}
+		ways := new(big.Int).Exp(c, big.NewInt(int64(n_cycles)), nil)
+		total.Add(total, new(big.Rat).Mul(t.Coeff, new(big.Rat).SetInt(ways)))
+	}
+	return total.Num()
+}
+
+// CountColoringsWithCounts is like CountColorings, but only counts the colorings that
+// use color i exactly counts[i] times. The counts must add up to the number of points.
+// This sets each x_j to the sum over colors of y_i^j, and picks out the coefficient of
+// the product of y_i^counts[i].
+func (p *Polynomial) CountColoringsWithCounts(counts []int) (*big.Int, error) {
+	sum := 0
+	for _, c := range counts {
+		if c < 0 {
+			return nil, errors.New("counts must not be negative")
+		}
+		sum += c
+	}
+	if sum != p.n {
+		return nil, errors.New("counts must add up to the number of points")
+	}
+
+	total := new(big.Rat)
+	for _, t := range p.terms {
+		ways := cycle_colorings(t.Cycles, counts)
+		total.Add(total, new(big.Rat).Mul(t.Coeff, new(big.Rat).SetInt(ways)))
+	}
+	return total.Num(), nil
+}
+
+// cycle_colorings counts the ways to give each cycle a single color so that color i
+// covers exactly counts[i] points. It goes through the cycles one at a time, keeping
+// track of how many points of each color are still to be used.
+func cycle_colorings(cycles []int, counts []int) *big.Int {
+	ways := map[string]*big.Int{fmt.Sprint(counts): big.NewInt(1)}
+	states := map[string][]int{fmt.Sprint(counts): slices.Clone(counts)}
+	for j, n_cycles := range cycles {
+		length := j + 1
+		for c := 0; c < n_cycles; c++ {
+			next_ways := make(map[string]*big.Int)
+			next_states := make(map[string][]int)
+			for key, w := range ways {
+				state := states[key]
+				for i := range state {
+					if state[i] < length {
+						continue
+					}
+					state[i] -= length
+					next_key := fmt.Sprint(state)
+					if existing, ok := next_ways[next_key]; ok {
+						existing.Add(existing, w)
+					} else {
+						next_ways[next_key] = new(big.Int).Set(w)
+						next_states[next_key] = slices.Clone(state)
+					}
+					state[i] += length
+				}
+			}
+			ways, states = next_ways, next_states
+		}
+	}
+	for _, w := range ways {
+		// Everything has been used up, so only the all zero state can be left
+		return w
+	}
+	return big.NewInt(0)
+}
+
+// CountColorings returns how many ways there are to color the points g acts on with the
+// given number of colors, up to the symmetries in g.
+func CountColorings(g *Group, colors int) *big.Int {
+	return CycleIndex(g).CountColorings(colors)
+}
+
+// CountColoringsWithCounts returns how many ways there are to color the points g acts on
+// so that color i is used exactly counts[i] times, up to the symmetries in g.
+func CountColoringsWithCounts(g *Group, counts []int) (*big.Int, error) {
+	return CycleIndex(g).CountColoringsWithCounts(counts)
+}
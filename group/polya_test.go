@@ -0,0 +1,198 @@
+package group
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// colorings_by_brute_force counts the colorings of the points of g with the given color
+// counts (nil for any counts), by finding the smallest coloring in each orbit
+func colorings_by_brute_force(g *Group, colors int, counts []int) int {
+	n := g.Degree()
+	elements := make([][]int, 0)
+	e := g.Elements()
+	for e.Next() {
+		elements = append(elements, append([]int{}, e.Element()...))
+	}
+
+	total := 0
+	coloring := make([]int, n)
+	image := make([]int, n)
+	var color func(i int)
+	color = func(i int) {
+		if i < n {
+			for c := 0; c < colors; c++ {
+				coloring[i] = c
+				color(i + 1)
+			}
+			return
+		}
+		if counts != nil {
+			used := make([]int, colors)
+			for _, c := range coloring {
+				used[c]++
+			}
+			if fmt.Sprint(used) != fmt.Sprint(counts) {
+				return
+			}
+		}
+		// Only count the coloring if no symmetry makes it smaller
+		for _, element := range elements {
+			for i, v := range element {
+				image[v] = coloring[i]
+			}
+			if fmt.Sprint(image) < fmt.Sprint(coloring) {
+				return
+			}
+		}
+		total++
+	}
+	color(0)
+	return total
+}
+
+func TestBuiltinGroupOrders(t *testing.T) {
+	testCases := []struct {
+		desc string
+		make func(int) (*Group, error)
+		n    int
+		want int64
+	}{
+		{desc: "cyclic 1", make: Cyclic, n: 1, want: 1},
+		{desc: "cyclic 9", make: Cyclic, n: 9, want: 9},
+		{desc: "dihedral 2", make: Dihedral, n: 2, want: 2},
+		{desc: "dihedral 9", make: Dihedral, n: 9, want: 18},
+		{desc: "symmetric 1", make: Symmetric, n: 1, want: 1},
+		{desc: "symmetric 7", make: Symmetric, n: 7, want: 5040},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			g, err := tC.make(tC.n)
+			if err != nil {
+				t.Fatalf("make(%v) = %v, want nil", tC.n, err)
+			}
+			if got := g.Order(); got.Cmp(big.NewInt(tC.want)) != 0 {
+				t.Errorf("Order() = %v, want %v", got, tC.want)
+			}
+			for _, gen := range g.Generators() {
+				if !g.Contains(gen) {
+					t.Errorf("Contains(%v) = false for a generator", gen)
+				}
+			}
+			if _, err := tC.make(0); err == nil {
+				t.Errorf("make(0) did not return an error")
+			}
+		})
+	}
+}
+
+func TestCycleIndexString(t *testing.T) {
+	testCases := []struct {
+		desc string
+		make func(int) (*Group, error)
+		n    int
+		want string
+	}{
+		{desc: "cyclic 4", make: Cyclic, n: 4, want: "1/4 x1^4 + 1/4 x2^2 + 1/2 x4"},
+		{desc: "dihedral 4", make: Dihedral, n: 4, want: "1/8 x1^4 + 1/4 x1^2 x2 + 3/8 x2^2 + 1/4 x4"},
+		{desc: "symmetric 3", make: Symmetric, n: 3, want: "1/6 x1^3 + 1/2 x1 x2 + 1/3 x3"},
+		{desc: "symmetric 1", make: Symmetric, n: 1, want: "x1"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			g, _ := tC.make(tC.n)
+			if got := CycleIndex(g).String(); got != tC.want {
+				t.Errorf("CycleIndex() = %v, want %v", got, tC.want)
+			}
+		})
+	}
+}
+
+func TestCycleIndexClosedFormsMatchElements(t *testing.T) {
+	for n := 1; n <= 8; n++ {
+		for name, make := range map[string]func(int) (*Group, error){"cyclic": Cyclic, "dihedral": Dihedral, "symmetric": Symmetric} {
+			t.Run(fmt.Sprintf("%v %v", name, n), func(t *testing.T) {
+				g, _ := make(n)
+				closed := CycleIndex(g).String()
+
+				// The same group, but without the closed form
+				general, _ := NewGroup(g.Generators()...)
+				if got := CycleIndex(general).String(); got != closed {
+					t.Errorf("CycleIndex() from elements = %v, closed form = %v", got, closed)
+				}
+			})
+		}
+	}
+}
+
+func TestCountColorings(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		make   func(int) (*Group, error)
+		n      int
+		colors int
+		want   string
+	}{
+		{desc: "binary necklaces of 6", make: Cyclic, n: 6, colors: 2, want: "14"},
+		{desc: "binary bracelets of 6", make: Dihedral, n: 6, colors: 2, want: "13"},
+		{desc: "3 color necklaces of 10", make: Cyclic, n: 10, colors: 3, want: "5934"},
+		{desc: "multisets of 5 from 4 colors", make: Symmetric, n: 5, colors: 4, want: "56"},
+		{desc: "big necklaces", make: Cyclic, n: 60, colors: 2, want: "19215358428046176"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			g, _ := tC.make(tC.n)
+			want, _ := new(big.Int).SetString(tC.want, 10)
+			if got := CountColorings(g, tC.colors); got.Cmp(want) != 0 {
+				t.Errorf("CountColorings() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCountColoringsAgainstBruteForce(t *testing.T) {
+	board := []int{6, 3, 0, 7, 4, 1, 8, 5, 2}
+	mirror := []int{2, 1, 0, 5, 4, 3, 8, 7, 6}
+	square, _ := NewGroup(board, mirror)
+	cyclic, _ := Cyclic(7)
+	dihedral, _ := Dihedral(6)
+	testCases := []struct {
+		desc   string
+		g      *Group
+		counts []int
+	}{
+		{desc: "3x3 board", g: square, counts: []int{4, 3, 2}},
+		{desc: "necklace of 7", g: cyclic, counts: []int{3, 2, 2}},
+		{desc: "bracelet of 6", g: dihedral, counts: []int{2, 2, 2}},
+		{desc: "bracelet of 6, one color missing", g: dihedral, counts: []int{3, 0, 3}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			colors := len(tC.counts)
+			want := colorings_by_brute_force(tC.g, colors, nil)
+			if got := CountColorings(tC.g, colors); got.Cmp(big.NewInt(int64(want))) != 0 {
+				t.Errorf("CountColorings() = %v, want %v", got, want)
+			}
+
+			want = colorings_by_brute_force(tC.g, colors, tC.counts)
+			got, err := CountColoringsWithCounts(tC.g, tC.counts)
+			if err != nil {
+				t.Fatalf("CountColoringsWithCounts() = %v, want nil", err)
+			}
+			if got.Cmp(big.NewInt(int64(want))) != 0 {
+				t.Errorf("CountColoringsWithCounts() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCountColoringsWithCountsErrors(t *testing.T) {
+	g, _ := Cyclic(4)
+	if _, err := CountColoringsWithCounts(g, []int{1, 2}); err == nil {
+		t.Errorf("CountColoringsWithCounts() with counts not adding up did not return an error")
+	}
+	if _, err := CountColoringsWithCounts(g, []int{5, -1}); err == nil {
+		t.Errorf("CountColoringsWithCounts() with negative counts did not return an error")
+	}
+}
@@ -0,0 +1,195 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math/big"
+	"math/rand/v2"
+)
+
+// GroupedPermutations generates every arrangement of an input slice in which items are
+// only moved around within their own group (stratum). The positions belonging to each
+// group stay fixed, so every arrangement is as long as the input. This is what you want
+// for stratified permutation tests.
+//
+// Each group is permuted with its own `Permutations`, and the groups are stepped like
+// an odometer: the group whose first position is last in the input changes fastest.
+// GroupedPermutations meets the `CombinationLike` interface
+type GroupedPermutations[T any] struct {
+	data      []T
+	n         int
+	positions [][]int
+	perms     []*Permutations[int]
+	isfirst   bool
+	done      bool
+	inds      []int
+	length
+	buffer []T
+}
+
+// NewGroupedPermutations creates a new GroupedPermutations object. groupOf[i] is the
+// group that input_data[i] belongs to. Group labels can be any ints.
+func NewGroupedPermutations[T any](input_data []T, groupOf []int) (*GroupedPermutations[T], error) {
+	data := make([]T, len(input_data))
+	copy(data, input_data)
+	n := len(input_data)
+
+	if n <= 0 {
+		return nil, errors.New("len(input_data) must be greater than 0")
+	} else if len(groupOf) != n {
+		return nil, errors.New("len(groupOf) must equal len(input_data)")
+	}
+
+	// Gather up the positions of each group, in order of first appearance
+	positions := make([][]int, 0)
+	group_idx := make(map[int]int)
+	for i, g := range groupOf {
+		idx, ok := group_idx[g]
+		if !ok {
+			idx = len(positions)
+			group_idx[g] = idx
+			positions = append(positions, make([]int, 0))
+		}
+		positions[idx] = append(positions[idx], i)
+	}
+
+	// Each group gets its own Permutations over its local positions
+	perms := make([]*Permutations[int], len(positions))
+	total := big.NewInt(1)
+	for g, pos := range positions {
+		perms[g] = new_group_permutations(len(pos))
+		total.Mul(total, perms[g].BigLen())
+	}
+
+	inds := stepped_range(0, n, 1)
+	buffer := make([]T, n)
+	fill_buffer(buffer, data, inds)
+
+	return &GroupedPermutations[T]{
+		data:      data,
+		n:         n,
+		positions: positions,
+		perms:     perms,
+		isfirst:   true,
+		inds:      inds,
+		length:    length_from_big(total),
+		buffer:    buffer,
+	}, nil
+}
+
+// new_group_permutations makes the Permutations that drives a group of size m
+func new_group_permutations(m int) *Permutations[int] {
+	p, _ := NewPermutations(stepped_range(0, m, 1), m)
+	return p
+}
+
+// Next will move on to the next arrangement, returning false once they have all been
+// seen.
+func (g *GroupedPermutations[T]) Next() bool {
+	if g.done {
+		return false
+	}
+	if g.isfirst {
+		for _, p := range g.perms {
+			p.Next()
+		}
+		g.isfirst = false
+		g.fill_inds()
+		return true
+	}
+
+	// Step the last group, carrying into earlier groups when a group runs out. A group
+	// that runs out goes back to its first permutation.
+	for i := len(g.perms) - 1; i >= 0; i-- {
+		if g.perms[i].Next() {
+			g.fill_inds()
+			return true
+		}
+		g.perms[i].reset()
+	}
+	g.done = true
+	return false
+}
+
+// fill_inds works out the full length indices from each group's permutation
+func (g *GroupedPermutations[T]) fill_inds() {
+	for i, pos := range g.positions {
+		for j, local := range g.perms[i].Indices() {
+			g.inds[pos[j]] = pos[local]
+		}
+	}
+}
+
+func (g *GroupedPermutations[T]) LenInds() int {
+	return g.n
+}
+
+// Indices tells you which input item sits at each position in this arrangement
+func (g *GroupedPermutations[T]) Indices() []int {
+	return g.inds
+}
+
+// Items is how you get the items in this arrangement. You iterate with `g.Next()`, and
+// then get the arrangement with `g.Items()`. The data in the slice returned will be
+// overwritten every iteration. If you need to keep the data from each iteration, be
+// sure to make a copy.
+func (g *GroupedPermutations[T]) Items() []T {
+	fill_buffer(g.buffer, g.data, g.inds)
+	return g.buffer
+}
+
+// Rank returns the position of the current arrangement in the order that `Next()`
+// generates them, starting at 0.
+func (g *GroupedPermutations[T]) Rank() *big.Int {
+	rank := big.NewInt(0)
+	if g.isfirst {
+		return rank
+	}
+	for _, p := range g.perms {
+		rank.Mul(rank, p.BigLen())
+		rank.Add(rank, rank_permutation(p.Indices(), p.n))
+	}
+	return rank
+}
+
+// Unrank moves the GroupedPermutations to the arrangement at position `rank`, so that
+// `Indices()` and `Items()` reflect it. Calling `Next()` afterwards carries on from
+// there.
+func (g *GroupedPermutations[T]) Unrank(rank *big.Int) error {
+	if rank.Sign() < 0 || g.cmp(rank) <= 0 {
+		return errRankOutOfRange
+	}
+
+	// Peel off one mixed radix digit per group, starting with the fastest changing
+	r := new(big.Int).Set(rank)
+	digit := new(big.Int)
+	for i := len(g.perms) - 1; i >= 0; i-- {
+		p := g.perms[i]
+		r.DivMod(r, p.BigLen(), digit)
+		prefix := make([]int, p.n)
+		unrank_permutation(digit, p.n, prefix)
+		p.set_prefix(prefix)
+	}
+	g.isfirst = false
+	g.done = false
+	g.fill_inds()
+	return nil
+}
+
+// Random moves the GroupedPermutations to an arrangement chosen uniformly at random,
+// using randomness from src, and returns its items. Each group is shuffled with a
+// Fisher-Yates shuffle. Calling `Next()` afterwards carries on from there.
+func (g *GroupedPermutations[T]) Random(src rand.Source) []T {
+	rng := rand.New(src)
+	for _, p := range g.perms {
+		prefix := stepped_range(0, p.n, 1)
+		for i := p.n - 1; i > 0; i-- {
+			j := rng.IntN(i + 1)
+			prefix[i], prefix[j] = prefix[j], prefix[i]
+		}
+		p.set_prefix(prefix)
+	}
+	g.isfirst = false
+	g.done = false
+	g.fill_inds()
+	return g.Items()
+}
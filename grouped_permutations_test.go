@@ -0,0 +1,181 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+// grouped_by_filtering finds all the arrangements that keep every item in its group, by
+// checking every permutation
+func grouped_by_filtering(groupOf []int) map[string]bool {
+	n := len(groupOf)
+	p, _ := NewPermutations(stepped_range(0, n, 1), n)
+	result := make(map[string]bool)
+	for p.Next() {
+		ok := true
+		for pos, idx := range p.Indices() {
+			if groupOf[pos] != groupOf[idx] {
+				ok = false
+			}
+		}
+		if ok {
+			result[fmt.Sprint(p.Indices())] = true
+		}
+	}
+	return result
+}
+
+func TestNewGroupedPermutationsErrors(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		data    []int
+		groupOf []int
+	}{
+		{desc: "n <= 0", data: []int{}, groupOf: []int{}},
+		{desc: "groupOf too short", data: []int{1, 2, 3}, groupOf: []int{0, 0}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := NewGroupedPermutations(tC.data, tC.groupOf); err == nil {
+				t.Errorf("NewGroupedPermutations() did not return an error")
+			}
+		})
+	}
+}
+
+func TestGroupedPermutationsNext(t *testing.T) {
+	data := []string{"a", "b", "c", "d"}
+	g, err := NewGroupedPermutations(data, []int{7, 3, 7, 3})
+	if err != nil {
+		t.Fatalf("NewGroupedPermutations() = %v, want nil", err)
+	}
+	want := [][]string{
+		{"a", "b", "c", "d"},
+		{"a", "d", "c", "b"},
+		{"c", "b", "a", "d"},
+		{"c", "d", "a", "b"},
+	}
+	got := make([][]string, 0)
+	for g.Next() {
+		items := make([]string, len(g.Items()))
+		copy(items, g.Items())
+		got = append(got, items)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupedPermutations = %v, want %v", got, want)
+	}
+	if g.Next() {
+		t.Errorf("Next() returned true after the end")
+	}
+}
+
+func TestGroupedPermutationsAgainstFiltering(t *testing.T) {
+	testCases := [][]int{
+		{0},
+		{0, 0, 0},
+		{0, 1, 2, 3},
+		{0, 1, 0, 1, 0},
+		{2, 2, 1, 1, 1, 0},
+		{5, 4, 5, 4, 4, 5, 9},
+	}
+	for _, groupOf := range testCases {
+		t.Run(fmt.Sprint(groupOf), func(t *testing.T) {
+			want := grouped_by_filtering(groupOf)
+			g, err := NewGroupedPermutations(stepped_range(0, len(groupOf), 1), groupOf)
+			if err != nil {
+				t.Fatalf("NewGroupedPermutations() = %v, want nil", err)
+			}
+			if g.BigLen().Cmp(big.NewInt(int64(len(want)))) != 0 {
+				t.Errorf("BigLen() = %v, want %v", g.BigLen(), len(want))
+			}
+
+			u, _ := NewGroupedPermutations(stepped_range(0, len(groupOf), 1), groupOf)
+			got := make(map[string]bool)
+			rank := int64(0)
+			for g.Next() {
+				got[fmt.Sprint(g.Indices())] = true
+				if r := g.Rank(); r.Cmp(big.NewInt(rank)) != 0 {
+					t.Errorf("Rank() of %v = %v, want %v", g.Indices(), r, rank)
+				}
+				if err := u.Unrank(big.NewInt(rank)); err != nil {
+					t.Fatalf("Unrank(%v) = %v, want nil", rank, err)
+				}
+				if !reflect.DeepEqual(u.Indices(), g.Indices()) {
+					t.Errorf("Unrank(%v) = %v, want %v", rank, u.Indices(), g.Indices())
+				}
+				rank++
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GroupedPermutations(%v) = %v, want %v", groupOf, got, want)
+			}
+		})
+	}
+}
+
+func TestGroupedPermutationsUnrankThenNext(t *testing.T) {
+	groupOf := []int{0, 1, 0, 1, 0, 2, 2}
+	g, _ := NewGroupedPermutations(stepped_range(0, len(groupOf), 1), groupOf)
+	if err := g.Unrank(big.NewInt(5)); err != nil {
+		t.Fatalf("Unrank() = %v, want nil", err)
+	}
+	for i := int64(6); g.Next(); i++ {
+		if got := g.Rank(); got.Cmp(big.NewInt(i)) != 0 {
+			t.Fatalf("Rank() after Unrank and Next = %v, want %v", got, i)
+		}
+	}
+	if err := g.Unrank(g.BigLen()); err == nil {
+		t.Errorf("Unrank(BigLen()) did not return an error")
+	}
+}
+
+func TestGroupedPermutationsRandom(t *testing.T) {
+	groupOf := []int{0, 1, 0, 1, 0}
+	g, _ := NewGroupedPermutations(stepped_range(0, len(groupOf), 1), groupOf)
+	valid := grouped_by_filtering(groupOf)
+	src := rand.NewPCG(1, 2)
+
+	// Every arrangement should come up roughly Length times out of 12 * 1000 draws
+	counts := make(map[string]int)
+	draws := 12000
+	for i := 0; i < draws; i++ {
+		key := fmt.Sprint(g.Random(src))
+		if !valid[key] {
+			t.Fatalf("Random() gave %v, which moves an item out of its group", key)
+		}
+		counts[key]++
+	}
+	for key, count := range counts {
+		if count < 850 || count > 1150 {
+			t.Errorf("Random() gave %v %v times, expected about 1000", key, count)
+		}
+	}
+	if len(counts) != len(valid) {
+		t.Errorf("Random() gave %v different arrangements, want %v", len(counts), len(valid))
+	}
+}
+
+func TestGroupedPermutationsNextDoesNotAllocate(t *testing.T) {
+	// Five groups of two, so every other step carries into an earlier group. There are
+	// 32 arrangements, enough for the 31 calls AllocsPerRun makes.
+	g, _ := NewGroupedPermutations(stepped_range(0, 10, 1), []int{0, 0, 1, 1, 2, 2, 3, 3, 4, 4})
+	allocs := testing.AllocsPerRun(30, func() {
+		if !g.Next() {
+			t.Fatalf("Next() ran out of arrangements")
+		}
+	})
+	if allocs > 0 {
+		t.Errorf("Next() made %v allocations, want 0", allocs)
+	}
+}
+
+func BenchmarkGroupedPermutationsNext(b *testing.B) {
+	g, _ := NewGroupedPermutations(stepped_range(0, 12, 1), []int{0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5})
+	for i := 0; i < b.N; i++ {
+		if !g.Next() {
+			g.Unrank(big.NewInt(0))
+		}
+	}
+}
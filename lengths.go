@@ -0,0 +1,108 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+// length is how many items a generator will give. It is kept as a uint64 when it fits,
+// and only as a *big.Int when it does not. Embedding it gives a type the `Len()` and
+// `BigLen()` methods.
+type length struct {
+	small uint64
+	// big is nil when the length fits in small
+	big *big.Int
+}
+
+var errRankOutOfRange = errors.New("rank must be in the range [0, BigLen())")
+
+// length_from_big wraps up a count that has already been worked out as a *big.Int
+func length_from_big(b *big.Int) length {
+	if b.IsUint64() {
+		return length{small: b.Uint64()}
+	}
+	return length{big: new(big.Int).Set(b)}
+}
+
+// Len returns how many items there are in total, and true, if that fits in a uint64.
+// Otherwise it returns 0 and false, and you need `BigLen()`.
+func (l length) Len() (uint64, bool) {
+	if l.big != nil {
+		return 0, false
+	}
+	return l.small, true
+}
+
+// BigLen returns how many items there are in total. It is a new *big.Int every time, so
+// it is yours to change.
+func (l length) BigLen() *big.Int {
+	if l.big != nil {
+		return new(big.Int).Set(l.big)
+	}
+	return new(big.Int).SetUint64(l.small)
+}
+
+// cmp compares the length with x, returning -1, 0 or +1 like `big.Int.Cmp`
+func (l length) cmp(x *big.Int) int {
+	if l.big != nil {
+		return l.big.Cmp(x)
+	}
+	if !x.IsUint64() {
+		return -x.Sign()
+	}
+	switch v := x.Uint64(); {
+	case l.small < v:
+		return -1
+	case l.small > v:
+		return 1
+	}
+	return 0
+}
+
+// binomial_u64 returns nchoosek(n, k), with nchoosek(n, 0) = 1, and true, if it fits in
+// a uint64. Otherwise it returns false. It builds the result up multiplicatively:
+// after step i it holds nchoosek(n-k+i, i), which only ever grows, so the first step
+// that would overflow means the answer overflows too.
+func binomial_u64(n, k uint64) (uint64, bool) {
+	if k > n {
+		return 0, true
+	}
+	k = min(k, n-k)
+	result := uint64(1)
+	for i := uint64(1); i <= k; i++ {
+		hi, lo := bits.Mul64(result, n-k+i)
+		// The quotient fits in a uint64 exactly when hi < i
+		if hi >= i {
+			return 0, false
+		}
+		result, _ = bits.Div64(hi, lo, i)
+	}
+	return result, true
+}
+
+// binomial_length returns nchoosek(n, k), with nchoosek(n, 0) = 1, only using a
+// *big.Int if it does not fit in a uint64
+func binomial_length(n, k uint64) length {
+	if v, ok := binomial_u64(n, k); ok {
+		return length{small: v}
+	}
+	return length{big: new(big.Int).Binomial(int64(n), int64(k))}
+}
+
+// falling_factorial_length returns n * (n-1) * ... * (n-k+1), only using a *big.Int if
+// it does not fit in a uint64
+func falling_factorial_length(n, k uint64) length {
+	if k > n {
+		return length{}
+	}
+	result := uint64(1)
+	for i := n - k + 1; i <= n; i++ {
+		hi, lo := bits.Mul64(result, i)
+		if hi != 0 {
+			return length{big: new(big.Int).MulRange(int64(n-k+1), int64(n))}
+		}
+		result = lo
+	}
+	return length{small: result}
+}
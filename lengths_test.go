@@ -0,0 +1,158 @@
+package gocombinatorics
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/natemcintosh/gocombinatorics/genfunc"
+)
+
+func TestBinomialU64(t *testing.T) {
+	for n := uint64(0); n <= 70; n++ {
+		for k := uint64(0); k <= n+1; k++ {
+			want := new(big.Int).Binomial(int64(n), int64(k))
+			if k > n {
+				want.SetInt64(0)
+			}
+			got, ok := binomial_u64(n, k)
+			if ok != want.IsUint64() {
+				t.Fatalf("binomial_u64(%v, %v) ok = %v, want %v", n, k, ok, want.IsUint64())
+			}
+			if ok && got != want.Uint64() {
+				t.Errorf("binomial_u64(%v, %v) = %v, want %v", n, k, got, want)
+			}
+			if l := binomial_length(n, k); l.BigLen().Cmp(want) != 0 {
+				t.Errorf("binomial_length(%v, %v) = %v, want %v", n, k, l.BigLen(), want)
+			}
+		}
+	}
+}
+
+func TestFallingFactorialLength(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		n, k   uint64
+		want   string
+		fits64 bool
+	}{
+		{desc: "nothing", n: 5, k: 0, want: "1", fits64: true},
+		{desc: "too many", n: 3, k: 4, want: "0", fits64: true},
+		{desc: "20!", n: 20, k: 20, want: "2432902008176640000", fits64: true},
+		{desc: "21!", n: 21, k: 21, want: "51090942171709440000", fits64: false},
+		{desc: "a million, 3 at a time", n: 1_000_000, k: 3, want: "999997000002000000", fits64: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			l := falling_factorial_length(tC.n, tC.k)
+			if got := l.BigLen().String(); got != tC.want {
+				t.Errorf("BigLen() = %v, want %v", got, tC.want)
+			}
+			if _, ok := l.Len(); ok != tC.fits64 {
+				t.Errorf("Len() ok = %v, want %v", ok, tC.fits64)
+			}
+		})
+	}
+}
+
+func TestLenAndBigLen(t *testing.T) {
+	million := make([]int, 1_000_000)
+	c, _ := NewCombinations(million, 3)
+	if got, ok := c.Len(); !ok || got != 166_666_166_667_000_000 {
+		t.Errorf("Combinations Len() = %v, %v, want 166666166667000000, true", got, ok)
+	}
+
+	p, _ := NewPermutations(stepped_range(0, 30, 1), 30)
+	if _, ok := p.Len(); ok {
+		t.Errorf("Permutations Len() fits in a uint64, want it not to")
+	}
+	want := new(big.Int).MulRange(1, 30)
+	if got := p.BigLen(); got.Cmp(want) != 0 {
+		t.Errorf("Permutations BigLen() = %v, want %v", got, want)
+	}
+
+	// Changing what BigLen returns must not change the length
+	p.BigLen().SetInt64(0)
+	if got := p.BigLen(); got.Cmp(want) != 0 {
+		t.Errorf("Permutations BigLen() after changing a copy = %v, want %v", got, want)
+	}
+
+	cwr, _ := NewCombinationsWithReplacement(million, 2)
+	if got, ok := cwr.Len(); !ok || got != 500_000_500_000 {
+		t.Errorf("CombinationsWithReplacement Len() = %v, %v, want 500000500000, true", got, ok)
+	}
+}
+
+func TestLengthCmp(t *testing.T) {
+	small := length{small: 10}
+	large := length_from_big(new(big.Int).Lsh(big.NewInt(1), 70))
+	testCases := []struct {
+		desc string
+		l    length
+		x    *big.Int
+		want int
+	}{
+		{desc: "small, less", l: small, x: big.NewInt(11), want: -1},
+		{desc: "small, equal", l: small, x: big.NewInt(10), want: 0},
+		{desc: "small, more", l: small, x: big.NewInt(9), want: 1},
+		{desc: "small, negative", l: small, x: big.NewInt(-1), want: 1},
+		{desc: "small, huge", l: small, x: new(big.Int).Lsh(big.NewInt(1), 80), want: -1},
+		{desc: "large, more", l: large, x: big.NewInt(10), want: 1},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.l.cmp(tC.x); got != tC.want {
+				t.Errorf("cmp(%v) = %v, want %v", tC.x, got, tC.want)
+			}
+		})
+	}
+}
+
+func TestLengthsMatchGeneratingFunctions(t *testing.T) {
+	for n := 1; n <= 12; n++ {
+		subsets := genfunc.Subsets(n)
+		multisets := genfunc.Multisets(n, n+2)
+		for k := 1; k <= n+2; k++ {
+			if k <= n {
+				c, _ := NewCombinations(stepped_range(0, n, 1), k)
+				if got, want := c.BigLen(), subsets.Coeff(k); got.Cmp(want) != 0 {
+					t.Errorf("Combinations of %v choose %v BigLen() = %v, want %v", n, k, got, want)
+				}
+			}
+			cwr, _ := NewCombinationsWithReplacement(stepped_range(0, n, 1), k)
+			if got, want := cwr.BigLen(), multisets.Coeff(k); got.Cmp(want) != 0 {
+				t.Errorf("CombinationsWithReplacement of %v choose %v BigLen() = %v, want %v", n, k, got, want)
+			}
+		}
+	}
+}
+
+func TestAtMostTwoFromEachCategory(t *testing.T) {
+	// Items 0-2 are in one category, 3-4 in another, and 5-8 in a third
+	category := []int{0, 0, 0, 1, 1, 2, 2, 2, 2}
+	gf := genfunc.SubsetsAtMost(3, 2).Mul(genfunc.SubsetsAtMost(2, 2)).Mul(genfunc.SubsetsAtMost(4, 2))
+	for k := 1; k <= len(category); k++ {
+		c, _ := NewCombinations(category, k)
+		want := big.NewInt(0)
+		for c.Next() {
+			counts := make([]int, 3)
+			ok := true
+			for _, cat := range c.Items() {
+				counts[cat]++
+				ok = ok && counts[cat] <= 2
+			}
+			if ok {
+				want.Add(want, big.NewInt(1))
+			}
+		}
+		if got := gf.Coeff(k); got.Cmp(want) != 0 {
+			t.Errorf("choosing %v with at most 2 from each category: got %v, want %v", k, got, want)
+		}
+	}
+}
+
+func BenchmarkNewCombinationsMillionChoose3(b *testing.B) {
+	million := make([]int, 1_000_000)
+	for i := 0; i < b.N; i++ {
+		NewCombinations(million, 3)
+	}
+}
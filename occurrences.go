@@ -0,0 +1,111 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/natemcintosh/gocombinatorics/count"
+)
+
+// Kind picks which generator `OccurrenceCount` and `PositionCount` describe
+type Kind int
+
+const (
+	// KindCombinations is `Combinations`: k of n items, in increasing order of index
+	KindCombinations Kind = iota
+	// KindPermutations is `Permutations`: k of n items, in every order
+	KindPermutations
+	// KindCombinationsWithReplacement is `CombinationsWithReplacement`: k of n items,
+	// where an item can be picked more than once, in non-decreasing order of index
+	KindCombinationsWithReplacement
+)
+
+// String returns the name of the generator
+func (kind Kind) String() string {
+	switch kind {
+	case KindCombinations:
+		return "Combinations"
+	case KindPermutations:
+		return "Permutations"
+	case KindCombinationsWithReplacement:
+		return "CombinationsWithReplacement"
+	}
+	return "Kind(?)"
+}
+
+// check_kind_n_k checks n and k the same way the constructor for kind does
+func check_kind_n_k(kind Kind, n, k int) error {
+	switch kind {
+	case KindCombinations, KindPermutations, KindCombinationsWithReplacement:
+	default:
+		return errors.New("kind must be KindCombinations, KindPermutations or KindCombinationsWithReplacement")
+	}
+	if n <= 0 {
+		return errors.New("n must be greater than 0")
+	} else if k <= 0 {
+		return errors.New("k must be greater than 0")
+	} else if k > n && kind != KindCombinationsWithReplacement {
+		return errors.New("k must be less than or equal to n")
+	}
+	return nil
+}
+
+// OccurrenceCount returns how many times any one of the n items appears in everything
+// the generator of the given kind gives, when choosing k. Every item appears the same
+// number of times. For `CombinationsWithReplacement`, an item picked more than once in a
+// combination counts once for each time it is picked.
+//
+//   - Combinations: nchoosek(n-1, k-1), the combinations of the other items that fill the
+//     remaining k-1 places
+//   - Permutations: k * n!/(n-k)! / n, as each of the k places holds each item equally often
+//   - CombinationsWithReplacement: nchoosek(n+k-1, k-1), which is
+//     k * nchoosek(n+k-1, k) / n for the same reason
+func OccurrenceCount(kind Kind, n, k int) (*big.Int, error) {
+	if err := check_kind_n_k(kind, n, k); err != nil {
+		return nil, err
+	}
+	switch kind {
+	case KindCombinations:
+		return count.Binomial(n-1, k-1), nil
+	case KindPermutations:
+		c := count.FallingFactorial(n-1, k-1)
+		return c.Mul(c, big.NewInt(int64(k))), nil
+	default:
+		return count.Binomial(n+k-1, k-1), nil
+	}
+}
+
+// PositionCount returns how many of the items the generator of the given kind gives,
+// when choosing k of n, have item (an index in [0, n)) at position (an index in
+// [0, k)). Adding these up over the positions gives `OccurrenceCount`.
+//
+//   - Combinations: the indices are increasing, so the position items before it come
+//     from the item items below it, and the rest from the n-1-item above it:
+//     nchoosek(item, position) * nchoosek(n-1-item, k-1-position)
+//   - Permutations: every item is equally likely in every position, so it is
+//     (n-1)!/(n-k)!, the ways to fill the other k-1 places
+//   - CombinationsWithReplacement: the indices are non-decreasing, so the position items
+//     before it are a multiset of the item+1 indices up to item, and the ones after are a
+//     multiset of the n-item indices from item on
+func PositionCount(kind Kind, n, k, item, position int) (*big.Int, error) {
+	if err := check_kind_n_k(kind, n, k); err != nil {
+		return nil, err
+	}
+	if item < 0 || item >= n {
+		return nil, errors.New("item must be in the range [0, n)")
+	} else if position < 0 || position >= k {
+		return nil, errors.New("position must be in the range [0, k)")
+	}
+	after := k - 1 - position
+	switch kind {
+	case KindCombinations:
+		c := count.Binomial(item, position)
+		return c.Mul(c, count.Binomial(n-1-item, after)), nil
+	case KindPermutations:
+		return count.FallingFactorial(n-1, k-1), nil
+	default:
+		// A multiset of size m from d values is nchoosek(d+m-1, m)
+		c := count.Binomial(item+position, position)
+		return c.Mul(c, count.Binomial(n-item+after-1, after)), nil
+	}
+}
@@ -0,0 +1,101 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"testing"
+)
+
+// new_generator creates the generator of the given kind over 0, ..., n-1
+func new_generator(kind Kind, n, k int) (combinationLike, error) {
+	data := stepped_range(0, n, 1)
+	switch kind {
+	case KindCombinations:
+		return NewCombinations(data, k)
+	case KindPermutations:
+		return NewPermutations(data, k)
+	default:
+		return NewCombinationsWithReplacement(data, k)
+	}
+}
+
+func TestOccurrenceAndPositionCounts(t *testing.T) {
+	kinds := []Kind{KindCombinations, KindPermutations, KindCombinationsWithReplacement}
+	for _, kind := range kinds {
+		for n := 1; n <= 6; n++ {
+			for k := 1; k <= n+1; k++ {
+				if k > n && kind != KindCombinationsWithReplacement {
+					continue
+				}
+				t.Run(fmt.Sprintf("%v n=%v, k=%v", kind, n, k), func(t *testing.T) {
+					g, err := new_generator(kind, n, k)
+					if err != nil {
+						t.Fatal(err)
+					}
+					// at[item][position] counts what the generator gives
+					at := make([][]int64, n)
+					for i := range at {
+						at[i] = make([]int64, k)
+					}
+					for g.Next() {
+						for position, item := range g.Indices() {
+							at[item][position]++
+						}
+					}
+
+					occurrences, err := OccurrenceCount(kind, n, k)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for item := 0; item < n; item++ {
+						var total int64
+						for position := 0; position < k; position++ {
+							total += at[item][position]
+							got, err := PositionCount(kind, n, k, item, position)
+							if err != nil {
+								t.Fatal(err)
+							}
+							if got.Int64() != at[item][position] {
+								t.Errorf("PositionCount(item=%v, position=%v) = %v, want %v", item, position, got, at[item][position])
+							}
+						}
+						if occurrences.Int64() != total {
+							t.Errorf("OccurrenceCount() = %v, but item %v appears %v times", occurrences, item, total)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestOccurrenceCountErrors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		kind Kind
+		n, k int
+	}{
+		{desc: "unknown kind", kind: Kind(7), n: 3, k: 2},
+		{desc: "no items", kind: KindCombinations, n: 0, k: 1},
+		{desc: "k is 0", kind: KindPermutations, n: 3, k: 0},
+		{desc: "k > n", kind: KindCombinations, n: 3, k: 4},
+		{desc: "k > n for permutations", kind: KindPermutations, n: 3, k: 4},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := OccurrenceCount(tC.kind, tC.n, tC.k); err == nil {
+				t.Errorf("OccurrenceCount() gave no error")
+			}
+			if _, err := PositionCount(tC.kind, tC.n, tC.k, 0, 0); err == nil {
+				t.Errorf("PositionCount() gave no error")
+			}
+		})
+	}
+	for _, ip := range [][2]int{{-1, 0}, {5, 0}, {0, -1}, {0, 2}} {
+		if _, err := PositionCount(KindCombinations, 5, 2, ip[0], ip[1]); err == nil {
+			t.Errorf("PositionCount(item=%v, position=%v) gave no error", ip[0], ip[1])
+		}
+	}
+	if got, err := OccurrenceCount(KindCombinationsWithReplacement, 2, 5); err != nil || got.Int64() != 15 {
+		t.Errorf("OccurrenceCount(KindCombinationsWithReplacement, 2, 5) = %v, %v, want 15, nil", got, err)
+	}
+}
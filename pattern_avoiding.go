@@ -0,0 +1,219 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"sort"
+)
+
+// ContainsPattern reports whether perm contains pattern, i.e. whether there are
+// positions i_1 < i_2 < ... < i_m such that perm[i_1], ..., perm[i_m] are in the same
+// relative order as the m entries of pattern. Only relative order matters, so the
+// pattern 231 can be given as []int{2, 3, 1} or []int{1, 2, 0}. The entries of both
+// slices are assumed to be distinct.
+//
+// The search picks positions for the pattern left to right, and each candidate is only
+// compared against the two already matched entries that must sit just below and just
+// above it in value, so dead ends are cut off early.
+func ContainsPattern(perm, pattern []int) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	m := new_pattern_matcher(pattern)
+	return m.search(perm, 0, 0, make([]int, len(pattern)), false)
+}
+
+// pattern_matcher holds a standardized pattern, and for each of its positions, which
+// earlier position holds the next smaller and the next larger value (or -1).
+type pattern_matcher struct {
+	pat   []int
+	lower []int
+	upper []int
+}
+
+func new_pattern_matcher(pattern []int) *pattern_matcher {
+	pat := standardize(pattern)
+	k := len(pat)
+	lower := make([]int, k)
+	upper := make([]int, k)
+	for j := 0; j < k; j++ {
+		lower[j], upper[j] = -1, -1
+		for p := 0; p < j; p++ {
+			if pat[p] < pat[j] && (lower[j] == -1 || pat[p] > pat[lower[j]]) {
+				lower[j] = p
+			}
+			if pat[p] > pat[j] && (upper[j] == -1 || pat[p] < pat[upper[j]]) {
+				upper[j] = p
+			}
+		}
+	}
+	return &pattern_matcher{pat: pat, lower: lower, upper: upper}
+}
+
+// search tries to match pattern position j onwards, using perm positions from start
+// onwards. vals holds the perm values matched so far. If ending is true, the last
+// pattern position must be matched to the last perm position.
+func (m *pattern_matcher) search(perm []int, j int, start int, vals []int, ending bool) bool {
+	k := len(m.pat)
+	if j == k {
+		return true
+	}
+	// Leave enough room for the rest of the pattern
+	stop := len(perm) - (k - j)
+	if ending && j == k-1 && start < len(perm)-1 {
+		start = len(perm) - 1
+	}
+	for i := start; i <= stop; i++ {
+		v := perm[i]
+		if m.lower[j] != -1 && v < vals[m.lower[j]] {
+			continue
+		}
+		if m.upper[j] != -1 && v > vals[m.upper[j]] {
+			continue
+		}
+		vals[j] = v
+		if m.search(perm, j+1, i+1, vals, ending) {
+			return true
+		}
+	}
+	return false
+}
+
+// standardize replaces each entry of s by its rank among the entries of s, so that
+// []int{5, 9, 2} becomes []int{1, 2, 0}
+func standardize(s []int) []int {
+	order := stepped_range(0, len(s), 1)
+	sort.Slice(order, func(i, j int) bool {
+		return s[order[i]] < s[order[j]]
+	})
+	result := make([]int, len(s))
+	for r, idx := range order {
+		result[idx] = r
+	}
+	return result
+}
+
+// PatternAvoidingPermutations generates, in lexicographic order, every permutation of
+// 0, ..., n-1 that avoids (does not contain) all of the given patterns. Permutations
+// are built up one entry at a time, and a prefix is abandoned as soon as it contains a
+// pattern, so the permutations that would be thrown away are never generated.
+// PatternAvoidingPermutations meets the `CombinationLike` interface
+type PatternAvoidingPermutations struct {
+	n        int
+	matchers []*pattern_matcher
+	isfirst  bool
+	done     bool
+	inds     []int
+	used     []bool
+	vals     []int
+}
+
+// NewPatternAvoidingPermutations creates a new PatternAvoidingPermutations object for
+// permutations of length n that avoid every one of patterns.
+func NewPatternAvoidingPermutations(n int, patterns ...[]int) (*PatternAvoidingPermutations, error) {
+	if n <= 0 {
+		return nil, errors.New("n must be greater than 0")
+	}
+	matchers := make([]*pattern_matcher, len(patterns))
+	longest := 0
+	for i, pattern := range patterns {
+		if len(pattern) == 0 {
+			return nil, errors.New("patterns must not be empty")
+		}
+		seen := make(map[int]bool, len(pattern))
+		for _, v := range pattern {
+			if seen[v] {
+				return nil, errors.New("patterns must not contain repeated values")
+			}
+			seen[v] = true
+		}
+		matchers[i] = new_pattern_matcher(pattern)
+		if len(pattern) > longest {
+			longest = len(pattern)
+		}
+	}
+
+	return &PatternAvoidingPermutations{
+		n:        n,
+		matchers: matchers,
+		isfirst:  true,
+		inds:     make([]int, n),
+		used:     make([]bool, n),
+		vals:     make([]int, longest),
+	}, nil
+}
+
+// Next will move on to the next permutation avoiding the patterns, returning false when
+// there are none left.
+func (p *PatternAvoidingPermutations) Next() bool {
+	if p.done {
+		return false
+	}
+
+	// t is the position we are currently trying to fill
+	t := p.n - 1
+	if p.isfirst {
+		t = 0
+		p.inds[0] = -1
+		p.isfirst = false
+	} else {
+		p.used[p.inds[t]] = false
+	}
+
+	for t >= 0 {
+		// Try the next unused value at position t
+		v := p.inds[t] + 1
+		for v < p.n && p.used[v] {
+			v++
+		}
+		if v == p.n {
+			// Nothing left for this position, so back up
+			t--
+			if t >= 0 {
+				p.used[p.inds[t]] = false
+			}
+			continue
+		}
+		p.inds[t] = v
+
+		// Any new occurrence of a pattern has to use the entry we just placed
+		if p.completes_pattern(t + 1) {
+			continue
+		}
+
+		p.used[v] = true
+		if t == p.n-1 {
+			return true
+		}
+		t++
+		p.inds[t] = -1
+	}
+	p.done = true
+	return false
+}
+
+// completes_pattern checks whether the prefix of length `length` has an occurrence of
+// any of the patterns that ends at its last entry.
+func (p *PatternAvoidingPermutations) completes_pattern(length int) bool {
+	prefix := p.inds[:length]
+	for _, m := range p.matchers {
+		if len(m.pat) <= length && m.search(prefix, 0, 0, p.vals, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PatternAvoidingPermutations) LenInds() int {
+	return p.n
+}
+
+// Indices gives the current permutation
+func (p *PatternAvoidingPermutations) Indices() []int {
+	return p.inds
+}
+
+// Items gives the current permutation. As there is no input data, this is the same
+// slice as `Indices()`, and it will be overwritten every iteration.
+func (p *PatternAvoidingPermutations) Items() []int {
+	return p.inds
+}
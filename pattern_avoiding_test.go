@@ -0,0 +1,178 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// contains_pattern_brute_force checks every set of positions in perm
+func contains_pattern_brute_force(perm, pattern []int) bool {
+	if len(pattern) > len(perm) {
+		return false
+	}
+	want := standardize(pattern)
+	c, _ := NewCombinations(perm, len(pattern))
+	for c.Next() {
+		if reflect.DeepEqual(standardize(c.Items()), want) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStandardize(t *testing.T) {
+	got := standardize([]int{5, 9, 2, 7})
+	want := []int{1, 3, 0, 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("standardize() = %v, want %v", got, want)
+	}
+}
+
+func TestContainsPattern(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		perm    []int
+		pattern []int
+		want    bool
+	}{
+		{desc: "231 in 3142", perm: []int{3, 1, 4, 2}, pattern: []int{2, 3, 1}, want: true},
+		{desc: "231 not in 1234", perm: []int{1, 2, 3, 4}, pattern: []int{2, 3, 1}, want: false},
+		{desc: "zero based pattern", perm: []int{3, 1, 4, 2}, pattern: []int{1, 2, 0}, want: true},
+		{desc: "1324 in 41352", perm: []int{4, 1, 3, 5, 2}, pattern: []int{1, 3, 2, 4}, want: false},
+		{desc: "1324 in 14253", perm: []int{1, 4, 2, 5, 3}, pattern: []int{1, 3, 2, 4}, want: true},
+		{desc: "pattern longer than perm", perm: []int{0, 1}, pattern: []int{0, 1, 2}, want: false},
+		{desc: "empty pattern", perm: []int{0, 1}, pattern: []int{}, want: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := ContainsPattern(tC.perm, tC.pattern); got != tC.want {
+				t.Errorf("ContainsPattern(%v, %v) = %v, want %v", tC.perm, tC.pattern, got, tC.want)
+			}
+		})
+	}
+}
+
+func TestContainsPatternAgainstBruteForce(t *testing.T) {
+	patterns := [][]int{{0, 1}, {2, 0, 1}, {1, 2, 0}, {0, 2, 1, 3}, {1, 3, 0, 2}, {3, 2, 1, 0}}
+	n := 6
+	p, _ := NewPermutations(stepped_range(0, n, 1), n)
+	for p.Next() {
+		for _, pattern := range patterns {
+			want := contains_pattern_brute_force(p.Indices(), pattern)
+			if got := ContainsPattern(p.Indices(), pattern); got != want {
+				t.Errorf("ContainsPattern(%v, %v) = %v, want %v", p.Indices(), pattern, got, want)
+			}
+		}
+	}
+}
+
+func TestNewPatternAvoidingPermutationsErrors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		n        int
+		patterns [][]int
+	}{
+		{desc: "n <= 0", n: 0, patterns: [][]int{{1, 2}}},
+		{desc: "empty pattern", n: 3, patterns: [][]int{{}}},
+		{desc: "repeated values", n: 3, patterns: [][]int{{1, 1, 2}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := NewPatternAvoidingPermutations(tC.n, tC.patterns...); err == nil {
+				t.Errorf("NewPatternAvoidingPermutations() did not return an error")
+			}
+		})
+	}
+}
+
+func TestPatternAvoidingPermutationsCounts(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		patterns [][]int
+		// want[n-1] is the number of permutations of length n avoiding the patterns
+		want []int
+	}{
+		// Every pattern of length 3 is avoided by a Catalan number of permutations
+		{desc: "123", patterns: [][]int{{1, 2, 3}}, want: []int{1, 2, 5, 14, 42, 132, 429, 1430}},
+		{desc: "132", patterns: [][]int{{1, 3, 2}}, want: []int{1, 2, 5, 14, 42, 132, 429, 1430}},
+		{desc: "231", patterns: [][]int{{2, 3, 1}}, want: []int{1, 2, 5, 14, 42, 132, 429, 1430}},
+		{desc: "321", patterns: [][]int{{3, 2, 1}}, want: []int{1, 2, 5, 14, 42, 132, 429, 1430}},
+		// The three Wilf classes of length 4 patterns
+		{desc: "1342", patterns: [][]int{{1, 3, 4, 2}}, want: []int{1, 2, 6, 23, 103, 512, 2740}},
+		{desc: "2413", patterns: [][]int{{2, 4, 1, 3}}, want: []int{1, 2, 6, 23, 103, 512, 2740}},
+		{desc: "1234", patterns: [][]int{{1, 2, 3, 4}}, want: []int{1, 2, 6, 23, 103, 513, 2761}},
+		{desc: "1324", patterns: [][]int{{1, 3, 2, 4}}, want: []int{1, 2, 6, 23, 103, 513, 2762}},
+		// Avoiding two patterns of length 3
+		{desc: "123 and 132", patterns: [][]int{{1, 2, 3}, {1, 3, 2}}, want: []int{1, 2, 4, 8, 16, 32, 64}},
+		{desc: "123 and 321", patterns: [][]int{{1, 2, 3}, {3, 2, 1}}, want: []int{1, 2, 4, 4, 0, 0}},
+		{desc: "no patterns", patterns: [][]int{}, want: []int{1, 2, 6, 24, 120}},
+	}
+	for _, tC := range testCases {
+		for i, want := range tC.want {
+			n := i + 1
+			run_name := fmt.Sprintf("%v, n=%v", tC.desc, n)
+			t.Run(run_name, func(t *testing.T) {
+				p, err := NewPatternAvoidingPermutations(n, tC.patterns...)
+				if err != nil {
+					t.Fatalf("NewPatternAvoidingPermutations() = %v, want nil", err)
+				}
+				got := 0
+				for p.Next() {
+					got++
+				}
+				if got != want {
+					t.Errorf("Found %v permutations, want %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestPatternAvoidingPermutationsAgainstFiltering(t *testing.T) {
+	patterns := [][]int{{2, 3, 1}, {1, 3, 2, 4}}
+	n := 6
+	want := make([][]int, 0)
+	p, _ := NewPermutations(stepped_range(0, n, 1), n)
+	for p.Next() {
+		ok := true
+		for _, pattern := range patterns {
+			if ContainsPattern(p.Indices(), pattern) {
+				ok = false
+			}
+		}
+		if ok {
+			next_set_of_indices := make([]int, n)
+			copy(next_set_of_indices, p.Indices())
+			want = append(want, next_set_of_indices)
+		}
+	}
+
+	a, err := NewPatternAvoidingPermutations(n, patterns...)
+	if err != nil {
+		t.Fatalf("NewPatternAvoidingPermutations() = %v, want nil", err)
+	}
+	got := make([][]int, 0)
+	for a.Next() {
+		next_set_of_indices := make([]int, n)
+		copy(next_set_of_indices, a.Items())
+		got = append(got, next_set_of_indices)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PatternAvoidingPermutations = %v, want %v", got, want)
+	}
+	if a.Next() {
+		t.Errorf("Next() returned true after the end")
+	}
+}
+
+func BenchmarkPatternAvoidingPermutations(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		p, err := NewPatternAvoidingPermutations(10, []int{2, 3, 1})
+		if err != nil {
+			b.Errorf("NewPatternAvoidingPermutations() = %v, want nil", err)
+		}
+		for p.Next() {
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package gocombinatorics
+
+import (
+	"cmp"
+	"errors"
+	"math/big"
+	"slices"
+)
+
+// Perm is a permutation of 0, ..., n-1, stored as where each position maps to: position
+// i goes to p[i]. Laying data out as data[p[0]], data[p[1]], ... is exactly what
+// `Items()` does with `Indices()`, so the indices from a full length `Permutations` can
+// be used as a Perm directly.
+//
+// Methods assume the Perm is valid. Use `NewPerm` to check a slice you did not build
+// yourself.
+type Perm []int
+
+var errNotAPermutation = errors.New("slice is not a permutation of 0, ..., n-1")
+var errPermLengthMismatch = errors.New("permutations must have the same length")
+
+// NewPerm checks that s contains each of 0, ..., len(s)-1 exactly once, and returns it
+// as a Perm. It does not copy s.
+func NewPerm(s []int) (Perm, error) {
+	seen := make([]bool, len(s))
+	for _, v := range s {
+		if v < 0 || v >= len(s) || seen[v] {
+			return nil, errNotAPermutation
+		}
+		seen[v] = true
+	}
+	return Perm(s), nil
+}
+
+// Identity returns the permutation of length n that leaves everything where it is
+func Identity(n int) Perm {
+	return Perm(stepped_range(0, n, 1))
+}
+
+// FromCycles builds the permutation of length n made of the given cycles. Each cycle
+// [a, b, c] sends a to b, b to c and c to a. Anything not in a cycle stays put.
+func FromCycles(n int, cycles ...[]int) (Perm, error) {
+	p := Identity(n)
+	seen := make([]bool, n)
+	for _, cycle := range cycles {
+		for i, v := range cycle {
+			if v < 0 || v >= n || seen[v] {
+				return nil, errors.New("cycles must hold distinct values in the range [0, n)")
+			}
+			seen[v] = true
+			p[v] = cycle[(i+1)%len(cycle)]
+		}
+	}
+	return p, nil
+}
+
+// Argsort returns the permutation that sorts data: data[p[0]] <= data[p[1]] <= ...
+// Equal items keep their original order.
+func Argsort[T cmp.Ordered](data []T) Perm {
+	p := Identity(len(data))
+	slices.SortStableFunc(p, func(i, j int) int {
+		return cmp.Compare(data[i], data[j])
+	})
+	return p
+}
+
+// Compose returns the permutation that does q first, then p, i.e. result[i] = p[q[i]].
+// Used on data, Apply(p.Compose(q), data) is the same as Apply(p, data) followed by
+// Apply(q, data).
+func (p Perm) Compose(q Perm) (Perm, error) {
+	if len(p) != len(q) {
+		return nil, errPermLengthMismatch
+	}
+	result := make(Perm, len(p))
+	for i, v := range q {
+		result[i] = p[v]
+	}
+	return result, nil
+}
+
+// Inverse returns the permutation that undoes p
+func (p Perm) Inverse() Perm {
+	result := make(Perm, len(p))
+	for i, v := range p {
+		result[v] = i
+	}
+	return result
+}
+
+// Cycles returns the cycles of p, each starting at its smallest element, in order of
+// that smallest element. Fixed points show up as cycles of length 1.
+func (p Perm) Cycles() [][]int {
+	seen := make([]bool, len(p))
+	cycles := make([][]int, 0)
+	for start := range p {
+		if seen[start] {
+			continue
+		}
+		cycle := make([]int, 0)
+		for i := start; !seen[i]; i = p[i] {
+			seen[i] = true
+			cycle = append(cycle, i)
+		}
+		cycles = append(cycles, cycle)
+	}
+	return cycles
+}
+
+// cycle_lengths is like Cycles, but only keeps how long each cycle is
+func (p Perm) cycle_lengths() []int {
+	seen := make([]bool, len(p))
+	lengths := make([]int, 0)
+	for start := range p {
+		if seen[start] {
+			continue
+		}
+		length := 0
+		for i := start; !seen[i]; i = p[i] {
+			seen[i] = true
+			length++
+		}
+		lengths = append(lengths, length)
+	}
+	return lengths
+}
+
+// Sign returns 1 if p is an even permutation, and -1 if it is odd
+func (p Perm) Sign() int {
+	// Every cycle of length L is made of L-1 transpositions
+	transpositions := len(p) - len(p.cycle_lengths())
+	if transpositions%2 == 0 {
+		return 1
+	}
+	return -1
+}
+
+// Order returns the smallest k > 0 such that p applied k times is the identity: the
+// least common multiple of its cycle lengths. This can be very large, so it is a
+// *big.Int.
+func (p Perm) Order() *big.Int {
+	order := big.NewInt(1)
+	gcd := new(big.Int)
+	length := new(big.Int)
+	for _, l := range p.cycle_lengths() {
+		length.SetInt64(int64(l))
+		gcd.GCD(nil, nil, order, length)
+		order.Mul(order, length.Div(length, gcd))
+	}
+	return order
+}
+
+// Power returns p composed with itself k times. Negative k gives powers of the inverse.
+func (p Perm) Power(k int) Perm {
+	result := make(Perm, len(p))
+	for _, cycle := range p.Cycles() {
+		l := len(cycle)
+		shift := ((k % l) + l) % l
+		for i, v := range cycle {
+			result[v] = cycle[(i+shift)%l]
+		}
+	}
+	return result
+}
+
+// Apply rearranges data in place so that it ends up as
+// data[p[0]], data[p[1]], ..., data[p[n-1]]
+// It follows the cycles of p, so it needs no extra memory. While it runs, it marks the
+// entries of p it has visited by flipping their bits, and puts them back before
+// returning, so p must not be read by anything else at the same time.
+func Apply[T any](p Perm, data []T) error {
+	if len(p) != len(data) {
+		return errPermLengthMismatch
+	}
+	for start := range p {
+		if p[start] < 0 {
+			continue
+		}
+		// Pull each item into place along the cycle
+		first := data[start]
+		i := start
+		for p[i] != start {
+			next := p[i]
+			data[i] = data[next]
+			p[i] = ^p[i]
+			i = next
+		}
+		data[i] = first
+		p[i] = ^p[i]
+	}
+	for i := range p {
+		p[i] = ^p[i]
+	}
+	return nil
+}
+
+// Perm returns the full arrangement of all n indices that the Permutations is currently
+// at. The first k entries are `Indices()`, and the rest are the unused indices. The
+// returned Perm is a copy, so changing it does not affect the Permutations.
+func (p *Permutations[T]) Perm() Perm {
+	result := make(Perm, p.n)
+	copy(result, p.inds)
+	return result
+}
@@ -0,0 +1,188 @@
+package gocombinatorics
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestNewPerm(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		s       []int
+		want_ok bool
+	}{
+		{desc: "empty", s: []int{}, want_ok: true},
+		{desc: "identity", s: []int{0, 1, 2}, want_ok: true},
+		{desc: "shuffled", s: []int{2, 0, 3, 1}, want_ok: true},
+		{desc: "repeat", s: []int{0, 0, 1}, want_ok: false},
+		{desc: "too big", s: []int{0, 3, 1}, want_ok: false},
+		{desc: "negative", s: []int{-1, 0, 1}, want_ok: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := NewPerm(tC.s)
+			if (err == nil) != tC.want_ok {
+				t.Errorf("NewPerm(%v) = %v, want ok = %v", tC.s, err, tC.want_ok)
+			}
+		})
+	}
+}
+
+func TestFromCycles(t *testing.T) {
+	p, err := FromCycles(6, []int{0, 2, 4}, []int{1, 5})
+	if err != nil {
+		t.Fatalf("FromCycles() = %v, want nil", err)
+	}
+	want := Perm{2, 5, 4, 3, 0, 1}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("FromCycles() = %v, want %v", p, want)
+	}
+	want_cycles := [][]int{{0, 2, 4}, {1, 5}, {3}}
+	if got := p.Cycles(); !reflect.DeepEqual(got, want_cycles) {
+		t.Errorf("Cycles() = %v, want %v", got, want_cycles)
+	}
+
+	if _, err := FromCycles(3, []int{0, 1}, []int{1, 2}); err == nil {
+		t.Errorf("FromCycles() with overlapping cycles did not return an error")
+	}
+	if _, err := FromCycles(3, []int{0, 3}); err == nil {
+		t.Errorf("FromCycles() with a value out of range did not return an error")
+	}
+}
+
+func TestPermComposeInverse(t *testing.T) {
+	p := Perm{2, 0, 3, 1}
+	q := Perm{1, 3, 0, 2}
+	got, err := p.Compose(q)
+	if err != nil {
+		t.Fatalf("Compose() = %v, want nil", err)
+	}
+	want := Perm{0, 1, 2, 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compose() = %v, want %v", got, want)
+	}
+	if inv := p.Inverse(); !reflect.DeepEqual(inv, q) {
+		t.Errorf("Inverse() = %v, want %v", inv, q)
+	}
+	if _, err := p.Compose(Perm{0}); err == nil {
+		t.Errorf("Compose() with different lengths did not return an error")
+	}
+}
+
+func TestPermSignOrderPower(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		p          Perm
+		want_sign  int
+		want_order int64
+	}{
+		{desc: "identity", p: Identity(4), want_sign: 1, want_order: 1},
+		{desc: "transposition", p: Perm{1, 0, 2}, want_sign: -1, want_order: 2},
+		{desc: "3-cycle", p: Perm{1, 2, 0}, want_sign: 1, want_order: 3},
+		{desc: "2 and 3 cycles", p: Perm{1, 0, 3, 4, 2}, want_sign: -1, want_order: 6},
+		{desc: "empty", p: Perm{}, want_sign: 1, want_order: 1},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := tC.p.Sign(); got != tC.want_sign {
+				t.Errorf("Sign() = %v, want %v", got, tC.want_sign)
+			}
+			if got := tC.p.Order(); got.Cmp(big.NewInt(tC.want_order)) != 0 {
+				t.Errorf("Order() = %v, want %v", got, tC.want_order)
+			}
+
+			// Check Power against composing over and over
+			power := Identity(len(tC.p))
+			for k := 0; k <= 2*int(tC.want_order)+1; k++ {
+				if got := tC.p.Power(k); !reflect.DeepEqual(got, power) {
+					t.Errorf("Power(%v) = %v, want %v", k, got, power)
+				}
+				if got := tC.p.Inverse().Power(k); !reflect.DeepEqual(got, tC.p.Power(-k)) {
+					t.Errorf("Power(%v) = %v, want %v", -k, tC.p.Power(-k), got)
+				}
+				power, _ = power.Compose(tC.p)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	// Apply should agree with what Permutations.Items() gives for the same indices
+	data := []string{"a", "b", "c", "d", "e"}
+	p, _ := NewPermutations(data, len(data))
+	for p.Next() {
+		perm := p.Perm()
+		before := make(Perm, len(perm))
+		copy(before, perm)
+
+		got := make([]string, len(data))
+		copy(got, data)
+		if err := Apply(perm, got); err != nil {
+			t.Fatalf("Apply() = %v, want nil", err)
+		}
+		if !reflect.DeepEqual(got, p.Items()) {
+			t.Errorf("Apply(%v) = %v, want %v", perm, got, p.Items())
+		}
+		if !reflect.DeepEqual(perm, before) {
+			t.Errorf("Apply() changed the permutation from %v to %v", before, perm)
+		}
+
+		// Applying a composition is applying one then the other
+		q := Perm{4, 2, 0, 1, 3}
+		pq, _ := perm.Compose(q)
+		one_then_other := make([]string, len(data))
+		copy(one_then_other, data)
+		Apply(perm, one_then_other)
+		Apply(q, one_then_other)
+		both := make([]string, len(data))
+		copy(both, data)
+		Apply(pq, both)
+		if !reflect.DeepEqual(both, one_then_other) {
+			t.Errorf("Apply(p.Compose(q)) = %v, want %v", both, one_then_other)
+		}
+	}
+	if err := Apply(Perm{0, 1}, []int{1}); err == nil {
+		t.Errorf("Apply() with different lengths did not return an error")
+	}
+}
+
+func TestArgsort(t *testing.T) {
+	data := []float64{3.5, -1, 2, -1, 10}
+	got := Argsort(data)
+	want := Perm{1, 3, 2, 0, 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Argsort(%v) = %v, want %v", data, got, want)
+	}
+	Apply(got, data)
+	want_sorted := []float64{-1, -1, 2, 3.5, 10}
+	if !reflect.DeepEqual(data, want_sorted) {
+		t.Errorf("Apply(Argsort()) = %v, want %v", data, want_sorted)
+	}
+}
+
+func TestPermutationsPerm(t *testing.T) {
+	p, _ := NewPermutations([]int{5, 6, 7, 8}, 2)
+	for p.Next() {
+		perm := p.Perm()
+		if _, err := NewPerm(perm); err != nil {
+			t.Errorf("Perm() = %v is not a permutation", perm)
+		}
+		if !reflect.DeepEqual([]int(perm[:2]), p.Indices()) {
+			t.Errorf("Perm() = %v does not start with Indices() = %v", perm, p.Indices())
+		}
+		perm[0] = -1
+		if p.Indices()[0] == -1 {
+			t.Errorf("Changing Perm() changed the Permutations")
+		}
+	}
+}
+
+func BenchmarkApply(b *testing.B) {
+	data := stepped_range(0, 1000, 1)
+	p, _ := FromCycles(1000, stepped_range(0, 1000, 3), stepped_range(1, 1000, 3))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Apply(p, data)
+	}
+}
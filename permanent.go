@@ -0,0 +1,212 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// max_permanent_size is the largest matrix the permanent functions accept, so that
+// every subset of the columns fits in a uint64. Anything close to it would take far too
+// long anyway.
+const max_permanent_size = 62
+
+// Permanent returns the permanent of the square matrix m: the sum over every permutation
+// p of m[0][p[0]] * m[1][p[1]] * ... * m[n-1][p[n-1]]. This is like the determinant,
+// but without the signs. For a 0-1 matrix, it counts the perfect matchings of the
+// bipartite graph with m as its adjacency matrix.
+//
+// Going through all n! permutations takes n! * n steps. This uses Ryser's formula
+// instead, which sums over the 2^n subsets S of the columns:
+//
+//	perm(m) = (-1)^n * sum over S of (-1)^|S| * product over rows i of (sum over j in S of m[i][j])
+//
+// The subsets are visited in Gray code order, so that each one differs from the last by
+// a single column and the row sums can be updated in n steps. This takes 2^n * n steps.
+// The permanent of the 0x0 matrix is 1.
+func Permanent(m [][]*big.Int) (*big.Int, error) {
+	return PermanentParallel(m, 1)
+}
+
+// PermanentFloat is like Permanent, for a matrix of float64s
+func PermanentFloat(m [][]float64) (float64, error) {
+	return PermanentFloatParallel(m, 1)
+}
+
+// PermanentParallel is like Permanent, but splits the subsets of the columns between
+// the given number of goroutines. If workers <= 0, it uses one per CPU.
+func PermanentParallel(m [][]*big.Int, workers int) (*big.Int, error) {
+	if err := check_square(m); err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		return big.NewInt(1), nil
+	}
+	parts := split_gray_code(len(m), workers, ryser_big, m)
+
+	total := big.NewInt(0)
+	for _, part := range parts {
+		total.Add(total, part)
+	}
+	if len(m)%2 == 1 {
+		total.Neg(total)
+	}
+	return total, nil
+}
+
+// PermanentFloatParallel is like PermanentFloat, but splits the subsets of the columns
+// between the given number of goroutines. If workers <= 0, it uses one per CPU. The
+// result can differ from PermanentFloat's by rounding, as the terms are added up in a
+// different order.
+func PermanentFloatParallel(m [][]float64, workers int) (float64, error) {
+	if err := check_square(m); err != nil {
+		return 0, err
+	}
+	if len(m) == 0 {
+		return 1, nil
+	}
+	parts := split_gray_code(len(m), workers, ryser_float, m)
+
+	total := 0.0
+	for _, part := range parts {
+		total += part
+	}
+	if len(m)%2 == 1 {
+		total = -total
+	}
+	return total, nil
+}
+
+func check_square[T any](m [][]T) error {
+	if len(m) > max_permanent_size {
+		return errors.New("matrix must have at most 62 rows")
+	}
+	for _, row := range m {
+		if len(row) != len(m) {
+			return errors.New("matrix must be square")
+		}
+	}
+	return nil
+}
+
+// split_gray_code splits the steps 1, ..., 2^n - 1 of the Gray code into one range per
+// worker, and runs ryser on each range of m in its own goroutine. It returns the result
+// from each range, in order.
+func split_gray_code[T, R any](n, workers int, ryser func(m [][]T, start, end uint64) R, m [][]T) []R {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	steps := uint64(1)<<uint(n) - 1
+	if uint64(workers) > steps {
+		workers = int(steps)
+	}
+
+	parts := make([]R, workers)
+	var wg sync.WaitGroup
+	start := uint64(1)
+	for w := 0; w < workers; w++ {
+		// Share out the steps as evenly as possible
+		end := 1 + steps*uint64(w+1)/uint64(workers)
+		wg.Add(1)
+		go func(w int, start, end uint64) {
+			defer wg.Done()
+			parts[w] = ryser(m, start, end)
+		}(w, start, end)
+		start = end
+	}
+	wg.Wait()
+	return parts
+}
+
+// gray is the subset of columns visited at step k of the Gray code
+func gray(k uint64) uint64 {
+	return k ^ (k >> 1)
+}
+
+// ryser_big adds up the terms of Ryser's formula, without the overall sign, for the
+// steps start, ..., end-1 of the Gray code
+func ryser_big(m [][]*big.Int, start, end uint64) *big.Int {
+	n := len(m)
+	// The row sums over the columns in the subset before the first step
+	sums := make([]*big.Int, n)
+	before := gray(start - 1)
+	for i := range sums {
+		sums[i] = big.NewInt(0)
+		for j := 0; j < n; j++ {
+			if before&(1<<uint(j)) != 0 {
+				sums[i].Add(sums[i], m[i][j])
+			}
+		}
+	}
+
+	total := big.NewInt(0)
+	product := new(big.Int)
+	for k := start; k < end; k++ {
+		// Step k adds or removes the column of its lowest set bit
+		j := bits.TrailingZeros64(k)
+		subset := gray(k)
+		adding := subset&(1<<uint(j)) != 0
+		for i := range sums {
+			if adding {
+				sums[i].Add(sums[i], m[i][j])
+			} else {
+				sums[i].Sub(sums[i], m[i][j])
+			}
+		}
+
+		product.SetInt64(1)
+		for _, s := range sums {
+			product.Mul(product, s)
+			if product.Sign() == 0 {
+				break
+			}
+		}
+		if bits.OnesCount64(subset)%2 == 1 {
+			total.Sub(total, product)
+		} else {
+			total.Add(total, product)
+		}
+	}
+	return total
+}
+
+// ryser_float is ryser_big for float64s
+func ryser_float(m [][]float64, start, end uint64) float64 {
+	n := len(m)
+	sums := make([]float64, n)
+	before := gray(start - 1)
+	for i := range sums {
+		for j := 0; j < n; j++ {
+			if before&(1<<uint(j)) != 0 {
+				sums[i] += m[i][j]
+			}
+		}
+	}
+
+	total := 0.0
+	for k := start; k < end; k++ {
+		j := bits.TrailingZeros64(k)
+		subset := gray(k)
+		adding := subset&(1<<uint(j)) != 0
+		for i := range sums {
+			if adding {
+				sums[i] += m[i][j]
+			} else {
+				sums[i] -= m[i][j]
+			}
+		}
+
+		product := 1.0
+		for _, s := range sums {
+			product *= s
+		}
+		if bits.OnesCount64(subset)%2 == 1 {
+			total -= product
+		} else {
+			total += product
+		}
+	}
+	return total
+}
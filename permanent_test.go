@@ -0,0 +1,159 @@
+package gocombinatorics
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// permanent_by_brute_force adds up the product for every permutation of the columns
+func permanent_by_brute_force(m [][]*big.Int) *big.Int {
+	total := big.NewInt(0)
+	if len(m) == 0 {
+		return total.SetInt64(1)
+	}
+	p, _ := NewPermutations(stepped_range(0, len(m), 1), len(m))
+	product := new(big.Int)
+	for p.Next() {
+		product.SetInt64(1)
+		for i, j := range p.Indices() {
+			product.Mul(product, m[i][j])
+		}
+		total.Add(total, product)
+	}
+	return total
+}
+
+func random_matrix(rng *rand.Rand, n int) [][]*big.Int {
+	m := make([][]*big.Int, n)
+	for i := range m {
+		m[i] = make([]*big.Int, n)
+		for j := range m[i] {
+			m[i][j] = big.NewInt(int64(rng.Intn(21) - 10))
+		}
+	}
+	return m
+}
+
+func to_float(m [][]*big.Int) [][]float64 {
+	result := make([][]float64, len(m))
+	for i, row := range m {
+		result[i] = make([]float64, len(row))
+		for j, v := range row {
+			result[i][j] = float64(v.Int64())
+		}
+	}
+	return result
+}
+
+func TestPermanentAgainstBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(38))
+	for n := 0; n <= 7; n++ {
+		for trial := 0; trial < 5; trial++ {
+			m := random_matrix(rng, n)
+			want := permanent_by_brute_force(m)
+
+			got, err := Permanent(m)
+			if err != nil {
+				t.Fatalf("Permanent() = %v, want nil", err)
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("Permanent(%v) = %v, want %v", m, got, want)
+			}
+
+			for _, workers := range []int{0, 2, 3, 1000} {
+				if got, _ := PermanentParallel(m, workers); got.Cmp(want) != 0 {
+					t.Errorf("PermanentParallel(%v, %v) = %v, want %v", m, workers, got, want)
+				}
+			}
+
+			want_float, _ := new(big.Float).SetInt(want).Float64()
+			got_float, _ := PermanentFloat(to_float(m))
+			if math.Abs(got_float-want_float) > 1e-9*math.Max(1, math.Abs(want_float)) {
+				t.Errorf("PermanentFloat(%v) = %v, want %v", m, got_float, want_float)
+			}
+			got_float, _ = PermanentFloatParallel(to_float(m), 3)
+			if math.Abs(got_float-want_float) > 1e-9*math.Max(1, math.Abs(want_float)) {
+				t.Errorf("PermanentFloatParallel(%v) = %v, want %v", m, got_float, want_float)
+			}
+		}
+	}
+}
+
+func TestPermanentKnownValues(t *testing.T) {
+	ones := func(n int) [][]*big.Int {
+		m := make([][]*big.Int, n)
+		for i := range m {
+			m[i] = make([]*big.Int, n)
+			for j := range m[i] {
+				m[i][j] = big.NewInt(1)
+			}
+		}
+		return m
+	}
+	derangements := ones(12)
+	for i := range derangements {
+		derangements[i][i] = big.NewInt(0)
+	}
+	testCases := []struct {
+		desc string
+		m    [][]*big.Int
+		want string
+	}{
+		{desc: "all ones counts permutations", m: ones(12), want: "479001600"},
+		{desc: "zero diagonal counts derangements", m: derangements, want: "176214841"},
+		{desc: "empty", m: ones(0), want: "1"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, _ := PermanentParallel(tC.m, 0)
+			if got.String() != tC.want {
+				t.Errorf("PermanentParallel() = %v, want %v", got, tC.want)
+			}
+		})
+	}
+}
+
+func TestPermanentMatchesDistinctRepresentatives(t *testing.T) {
+	sets := [][]int{{0, 1, 2}, {1, 2}, {0, 3}, {2, 3, 4}, {0, 4}}
+	m := make([][]*big.Int, len(sets))
+	for i, set := range sets {
+		m[i] = make([]*big.Int, len(sets))
+		for j := range m[i] {
+			m[i][j] = big.NewInt(0)
+		}
+		for _, j := range set {
+			m[i][j].SetInt64(1)
+		}
+	}
+	want, _ := CountDistinctRepresentatives(sets)
+	if got, _ := Permanent(m); got.Cmp(want) != 0 {
+		t.Errorf("Permanent() = %v, want %v", got, want)
+	}
+}
+
+func TestPermanentErrors(t *testing.T) {
+	if _, err := Permanent([][]*big.Int{{big.NewInt(1), big.NewInt(2)}}); err == nil {
+		t.Errorf("Permanent() of a non-square matrix did not return an error")
+	}
+	if _, err := PermanentFloat([][]float64{{1, 2}, {3}}); err == nil {
+		t.Errorf("PermanentFloat() of a ragged matrix did not return an error")
+	}
+}
+
+func BenchmarkPermanentFloat20(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	m := to_float(random_matrix(rng, 20))
+	for i := 0; i < b.N; i++ {
+		PermanentFloat(m)
+	}
+}
+
+func BenchmarkPermanentFloatParallel20(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	m := to_float(random_matrix(rng, 20))
+	for i := 0; i < b.N; i++ {
+		PermanentFloatParallel(m, 0)
+	}
+}
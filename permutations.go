@@ -2,6 +2,7 @@ package gocombinatorics
 
 import (
 	"errors"
+	"iter"
 	"math/big"
 )
 
@@ -10,24 +11,52 @@ import (
 // method, and access the data with the `.Items()` method.
 // Permutations meets the `CombinationLike` interface
 type Permutations[T any] struct {
-	data    []T
-	n, k    int
-	Length  *big.Int
+	data source[T]
+	n, k int
+	length
 	inds    []int
 	cycles  []int
 	isfirst bool
 	buffer  []T
 }
 
-// NewPermutations will return an instance of the `Permutations` struct
-func NewPermutations[T any](input_data []T, k int) (*Permutations[T], error) {
-	data := make([]T, len(input_data))
-	copy(data, input_data)
-	n := len(input_data)
+// NewPermutations will return an instance of the `Permutations` struct. It makes its own
+// copy of input_data, unless you pass `WithNoCopy()`.
+func NewPermutations[T any](input_data []T, k int, opts ...Option) (*Permutations[T], error) {
+	return new_permutations(source_from_slice(input_data, opts), k)
+}
+
+// NewPermutationsFromFunc is like NewPermutations, for n items where at(i) gives the item
+// at index i. Nothing is stored for the items, and at is called for each item every time
+// `Items()` is. Permutations still keeps n indices of its own, so n has to fit in memory
+// as ints.
+func NewPermutationsFromFunc[T any](n int, at func(int) T, k int) (*Permutations[T], error) {
+	src, err := source_from_func(n, at)
+	if err != nil {
+		return nil, err
+	}
+	return new_permutations(src, k)
+}
+
+// NewPermutationsFromSeq is like NewPermutations, for the first n items that seq gives.
+// seq is only read the first time `Items()` is called, which panics if seq gives fewer
+// than n items.
+func NewPermutationsFromSeq[T any](seq iter.Seq[T], n, k int) (*Permutations[T], error) {
+	src, err := source_from_seq(seq, n)
+	if err != nil {
+		return nil, err
+	}
+	return new_permutations(src, k)
+}
+
+func new_permutations[T any](data source[T], k int) (*Permutations[T], error) {
+	n := data.n
 	if k > n {
 		return nil, errors.New("k must be less than or equal to len(input_data)")
+	} else if k < 0 {
+		return nil, errors.New("k must be greater than or equal to 0")
 	}
-	Len := n_permutations(n, k)
+	length := falling_factorial_length(uint64(n), uint64(k))
 	inds := make([]int, n)
 	for i := 0; i < n; i++ {
 		inds[i] = i
@@ -39,14 +68,13 @@ func NewPermutations[T any](input_data []T, k int) (*Permutations[T], error) {
 
 	// The buffer slice
 	buffer := make([]T, len(inds))
-	fill_buffer(buffer, data, inds)
 
 	// Return the Permutations struct
 	return &Permutations[T]{
 		data:    data,
 		n:       n,
 		k:       k,
-		Length:  Len,
+		length:  length,
 		inds:    inds,
 		cycles:  cycles,
 		isfirst: isfirst,
@@ -113,25 +141,34 @@ func (p *Permutations[T]) LenInds() int {
 // overwritten every iteration. If you need to keep the data from each iteration, be
 // sure to make a copy.
 func (p *Permutations[T]) Items() []T {
-	fill_buffer(p.buffer, p.data, p.inds)
+	p.data.fill(p.buffer, p.inds)
 	return p.buffer
 }
 
-func n_permutations(n, k int) *big.Int {
-	numerator := factorial(int64(n))
-	denominator := factorial(int64(n - k))
-	result := new(big.Int).Div(numerator, denominator)
-	return result
+// Rank returns the position of the current permutation in the order that `Next()`
+// generates them, starting at 0.
+func (p *Permutations[T]) Rank() *big.Int {
+	if p.isfirst {
+		return big.NewInt(0)
+	}
+	return rank_permutation(p.Indices(), p.n)
 }
 
-func elts_in_permutations(n, k int) *big.Int {
-	if n == k {
-		return n_permutations(n, k)
+// Unrank moves the Permutations to the permutation at position `rank`, so that
+// `Indices()` and `Items()` reflect it. Calling `Next()` afterwards carries on from
+// there.
+func (p *Permutations[T]) Unrank(rank *big.Int) error {
+	if rank.Sign() < 0 || p.cmp(rank) <= 0 {
+		return errRankOutOfRange
 	}
+	prefix := make([]int, p.k)
+	unrank_permutation(rank, p.n, prefix)
+	p.set_prefix(prefix)
+	return nil
+}
 
-	total_perms := n_permutations(int(n), int(k))
-	n_minus_1_perms := n_permutations(int(n-1), int(k))
-	return big.NewInt(0).Sub(total_perms, n_minus_1_perms)
+func n_permutations(n, k int) *big.Int {
+	return falling_factorial_length(uint64(n), uint64(k)).BigLen()
 }
 
 // Mimics python's range() with a step argument
@@ -155,3 +192,85 @@ func stepped_range(start int, stop int, step int) []int {
 	}
 	return result
 }
+
+// set_prefix puts the Permutations into the state it would be in right after
+// generating `prefix`, so that `Next()` carries on from there. While generating, the
+// indices not in the prefix are always kept in sorted order, and cycles[i] counts how
+// many choices are left for position i.
+func (p *Permutations[T]) set_prefix(prefix []int) {
+	used := make([]bool, p.n)
+	for i, v := range prefix {
+		// How many unused indices are smaller than this one
+		smaller := 0
+		for u := 0; u < v; u++ {
+			if !used[u] {
+				smaller++
+			}
+		}
+		p.cycles[i] = p.n - i - smaller
+		p.inds[i] = v
+		used[v] = true
+	}
+
+	// The rest go at the end, in order
+	next := len(prefix)
+	for u := 0; u < p.n; u++ {
+		if !used[u] {
+			p.inds[next] = u
+			next++
+		}
+	}
+	p.isfirst = false
+}
+
+// reset puts the Permutations into the state it would be in right after generating the
+// first permutation, 0, ..., k-1, without allocating. It is set_prefix of that prefix.
+func (p *Permutations[T]) reset() {
+	for i := range p.inds {
+		p.inds[i] = i
+	}
+	for i := range p.cycles {
+		p.cycles[i] = p.n - i
+	}
+	p.isfirst = false
+}
+
+// rank_permutation returns the position of `prefix` among all permutations of n things
+// taken len(prefix) at a time, in the order that `Permutations.Next()` produces them.
+func rank_permutation(prefix []int, n int) *big.Int {
+	k := len(prefix)
+	used := make([]bool, n)
+	rank := big.NewInt(0)
+	for i, v := range prefix {
+		smaller := 0
+		for u := 0; u < v; u++ {
+			if !used[u] {
+				smaller++
+			}
+		}
+		used[v] = true
+		// Each smaller choice at position i skips over all the ways to fill the rest
+		skipped := n_permutations(n-i-1, k-i-1)
+		rank.Add(rank, skipped.Mul(skipped, big.NewInt(int64(smaller))))
+	}
+	return rank
+}
+
+// unrank_permutation is the inverse of rank_permutation. It fills `prefix` with the
+// permutation of n things taken len(prefix) at a time found at position `rank`. The
+// rank is assumed to be in [0, n_permutations(n, len(prefix))).
+func unrank_permutation(rank *big.Int, n int, prefix []int) {
+	k := len(prefix)
+	unused := stepped_range(0, n, 1)
+	r := new(big.Int).Set(rank)
+	choice, rem := new(big.Int), new(big.Int)
+	for i := 0; i < k; i++ {
+		// Each block of this size shares the same index at position i
+		block := n_permutations(n-i-1, k-i-1)
+		choice.DivMod(r, block, rem)
+		r.Set(rem)
+		c := int(choice.Int64())
+		prefix[i] = unused[c]
+		unused = append(unused[:c], unused[c+1:]...)
+	}
+}
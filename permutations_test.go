@@ -2,6 +2,8 @@ package gocombinatorics
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 	"reflect"
 	"testing"
 )
@@ -158,3 +160,65 @@ func TestPermutationsNext(t *testing.T) {
 		})
 	}
 }
+
+func TestRankPermutation(t *testing.T) {
+	for n := 1; n <= 6; n++ {
+		for k := 1; k <= n; k++ {
+			p, _ := NewPermutations(stepped_range(0, n, 1), k)
+			prefix := make([]int, k)
+			for rank := int64(0); p.Next(); rank++ {
+				if got := rank_permutation(p.Indices(), n); got.Cmp(big.NewInt(rank)) != 0 {
+					t.Errorf("rank_permutation(%v, %v) = %v, want %v", p.Indices(), n, got, rank)
+				}
+				unrank_permutation(big.NewInt(rank), n, prefix)
+				if !reflect.DeepEqual(prefix, p.Indices()) {
+					t.Errorf("unrank_permutation(%v, %v) = %v, want %v", rank, n, prefix, p.Indices())
+				}
+			}
+		}
+	}
+}
+
+func TestPermutationsSetPrefix(t *testing.T) {
+	for n := 1; n <= 6; n++ {
+		for k := 1; k <= n; k++ {
+			// Collect everything, then restart from each permutation and check that we
+			// see the same rest of the sequence
+			all := make([][]int, 0)
+			p, _ := NewPermutations(stepped_range(0, n, 1), k)
+			for p.Next() {
+				next_set_of_indices := make([]int, k)
+				copy(next_set_of_indices, p.Indices())
+				all = append(all, next_set_of_indices)
+			}
+
+			for start := range all {
+				q, _ := NewPermutations(stepped_range(0, n, 1), k)
+				q.set_prefix(all[start])
+				got := start + 1
+				for q.Next() {
+					if got >= len(all) || !reflect.DeepEqual(q.Indices(), all[got]) {
+						t.Fatalf("n=%v, k=%v: after set_prefix(%v) got %v at %v", n, k, all[start], q.Indices(), got)
+					}
+					got++
+				}
+				if got != len(all) {
+					t.Errorf("n=%v, k=%v: after set_prefix(%v) stopped at %v, want %v", n, k, all[start], got, len(all))
+				}
+			}
+		}
+	}
+}
+
+func TestPermutationsRankAndUnrank(t *testing.T) {
+	for n := 1; n <= 6; n++ {
+		for k := 1; k <= n; k++ {
+			t.Run(fmt.Sprintf("n=%v, k=%v", n, k), func(t *testing.T) {
+				check_rank_and_unrank(t, func() Rankable[int] {
+					g, _ := NewPermutations(stepped_range(0, n, 1), k)
+					return g
+				})
+			})
+		}
+	}
+}
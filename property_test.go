@@ -104,9 +104,7 @@ func Test100RandomCombinations(t *testing.T) {
 		k := rand.Int63n(n) + 1
 
 		// If any index should appear more than 10_000_000 times, skip this iteration
-		total_times := nchoosek(uint64(n), uint64(k))
-		one_less := nchoosek(uint64(n-1), uint64(k))
-		times_we_see_each_index := big.NewInt(0).Sub(total_times, one_less)
+		times_we_see_each_index, _ := OccurrenceCount(KindCombinations, int(n), int(k))
 		if times_we_see_each_index.Cmp(big.NewInt(10000000)) > 0 {
 			t.Logf("Skipping test because we see each index more than 10_000_000 times")
 			continue
@@ -144,7 +142,7 @@ func Test100RandomCombinationsWithReplacement(t *testing.T) {
 		k := rand.Int63n(n) + 1
 
 		// If any index should appear more than 10_000_000 times, skip this iteration
-		times_we_see_each_index := elts_in_combo_w_replacement(int(n), int(k))
+		times_we_see_each_index, _ := OccurrenceCount(KindCombinationsWithReplacement, int(n), int(k))
 		if times_we_see_each_index.Cmp(big.NewInt(10000000)) > 0 {
 			t.Logf("Skipping test because we see each index more than 10_000_000 times")
 			continue
@@ -183,7 +181,7 @@ func Test100RandomPermutations(t *testing.T) {
 		data := stepped_range(0, int(n), 1)
 
 		// If any index should appear more than 10_000_000 times, skip this iteration
-		times_we_see_each_index := elts_in_permutations(int(n), int(k))
+		times_we_see_each_index, _ := OccurrenceCount(KindPermutations, int(n), int(k))
 		if times_we_see_each_index.Cmp(big.NewInt(10000000)) > 0 {
 			t.Logf("Skipping test because we see each index more than 10_000_000 times")
 			continue
@@ -210,3 +208,62 @@ func Test100RandomPermutations(t *testing.T) {
 		})
 	}
 }
+
+// permutationStatisticCounter goes through every permutation of n things, and counts
+// how many give each value of stat
+func permutationStatisticCounter(n int, stat func([]int) int) map[int]int64 {
+	result := make(map[int]int64)
+	p, _ := NewPermutations(stepped_range(0, n, 1), n)
+	for p.Next() {
+		result[stat(p.Indices())]++
+	}
+	return result
+}
+
+func TestPermutationStatisticDistributions(t *testing.T) {
+	testCases := []struct {
+		desc string
+		stat func([]int) int
+		want func(n, k int) *big.Int
+	}{
+		{
+			desc: "inversions are Mahonian",
+			stat: func(p []int) int { return int(Inversions(p)) },
+			want: Mahonian,
+		},
+		{
+			desc: "major index is Mahonian",
+			stat: MajorIndex,
+			want: Mahonian,
+		},
+		{
+			desc: "descents are Eulerian",
+			stat: Descents,
+			want: Eulerian,
+		},
+		{
+			desc: "cycles are Stirling numbers of the first kind",
+			stat: NumCycles,
+			want: Stirling1,
+		},
+	}
+	for _, tC := range testCases {
+		for n := 1; n <= 7; n++ {
+			run_name := fmt.Sprintf("%v, n=%v", tC.desc, n)
+			t.Run(run_name, func(t *testing.T) {
+				counts := permutationStatisticCounter(n, tC.stat)
+				total := big.NewInt(0)
+				for k := 0; k <= n*n; k++ {
+					want := tC.want(n, k)
+					total.Add(total, want)
+					if want.Cmp(big.NewInt(counts[k])) != 0 {
+						t.Errorf("Expected %v permutations with statistic %v, but saw %v", want, k, counts[k])
+					}
+				}
+				if total.Cmp(factorial(int64(n))) != 0 {
+					t.Errorf("Distribution adds up to %v, want %v", total, factorial(int64(n)))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+)
+
+// This file picks a single combination or permutation uniformly at random, without
+// making the generator. Each function takes the same arguments as the generator's
+// constructor, plus somewhere to get randomness from. The ...Into versions fill a slice
+// you pass in, and do not allocate at all for k up to max_stack_k.
+
+// max_stack_k is the largest k whose indices are kept on the stack
+const max_stack_k = 64
+
+// RandomCombination returns k of the items in data, chosen uniformly at random from the
+// combinations `NewCombinations(data, k)` would give, using randomness from src. Like
+// that generator, the items keep the order they have in data.
+func RandomCombination[T any](data []T, k int, src rand.Source) ([]T, error) {
+	if k <= 0 {
+		return nil, errors.New("k must be greater than 0")
+	}
+	out := make([]T, k)
+	if err := RandomCombinationInto(out, data, src); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RandomCombinationInto is like RandomCombination, but fills out with len(out) items
+// instead of making a new slice
+func RandomCombinationInto[T any](out, data []T, src rand.Source) error {
+	n, k := len(data), len(out)
+	if k > n {
+		return errors.New("k must be less than or equal to len(input_data)")
+	} else if n <= 0 {
+		return errors.New("len(input_data) must be greater than 0")
+	} else if k <= 0 {
+		return errors.New("k must be greater than 0")
+	}
+	var stack [max_stack_k]int
+	inds := random_subset(rand.New(src), n, k, stack[:0])
+	fill_buffer(out, data, inds)
+	return nil
+}
+
+// RandomPermutation returns k of the items in data, in an order chosen uniformly at
+// random from the permutations `NewPermutations(data, k)` would give, using randomness
+// from src
+func RandomPermutation[T any](data []T, k int, src rand.Source) ([]T, error) {
+	if k < 0 {
+		return nil, errors.New("k must be greater than or equal to 0")
+	}
+	out := make([]T, k)
+	if err := RandomPermutationInto(out, data, src); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RandomPermutationInto is like RandomPermutation, but fills out with len(out) items
+// instead of making a new slice
+func RandomPermutationInto[T any](out, data []T, src rand.Source) error {
+	n, k := len(data), len(out)
+	if k > n {
+		return errors.New("k must be less than or equal to len(input_data)")
+	}
+	rng := rand.New(src)
+	var stack [max_stack_k]int
+	inds := random_subset(rng, n, k, stack[:0])
+	fill_buffer(out, data, inds)
+	// A random set of k items in a random order is a random k-permutation. Finish with a
+	// Fisher-Yates shuffle.
+	for i := k - 1; i > 0; i-- {
+		j := rng.IntN(i + 1)
+		out[i], out[j] = out[j], out[i]
+	}
+	return nil
+}
+
+// RandomCombinationWithReplacement returns k of the items in data, where an item can be
+// picked more than once, chosen uniformly at random from the combinations
+// `NewCombinationsWithReplacement(data, k)` would give, using randomness from src. Each
+// combination is equally likely, which is not the same as picking k items one at a time:
+// that makes combinations with more distinct items more likely.
+func RandomCombinationWithReplacement[T any](data []T, k int, src rand.Source) ([]T, error) {
+	if k <= 0 {
+		return nil, errors.New("k must be greater than 0")
+	}
+	out := make([]T, k)
+	if err := RandomCombinationWithReplacementInto(out, data, src); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RandomCombinationWithReplacementInto is like RandomCombinationWithReplacement, but
+// fills out with len(out) items instead of making a new slice
+func RandomCombinationWithReplacementInto[T any](out, data []T, src rand.Source) error {
+	n, k := len(data), len(out)
+	if n <= 0 {
+		return errors.New("len(input_data) must be greater than 0")
+	} else if k <= 0 {
+		return errors.New("k must be greater than 0")
+	}
+	// Stars and bars: the combinations with replacement of k from n match up one to one
+	// with the combinations of k from n+k-1, by taking i away from the ith index
+	var stack [max_stack_k]int
+	inds := random_subset(rand.New(src), n+k-1, k, stack[:0])
+	for i := range inds {
+		inds[i] -= i
+	}
+	fill_buffer(out, data, inds)
+	return nil
+}
+
+// random_subset appends k distinct indices in [0, n), chosen uniformly at random, to
+// inds, and returns them in increasing order. It uses Floyd's algorithm, which takes k
+// random numbers. inds must be empty, but may have room for k indices already.
+func random_subset(rng *rand.Rand, n, k int, inds []int) []int {
+	// After the step for j, inds holds a uniformly random subset of [0, j] of size
+	// j-(n-k)+1: pick t in [0, j], and take t if it is new, or j if it is not
+	for j := n - k; j < n; j++ {
+		t := rng.IntN(j + 1)
+		if pos, found := slices.BinarySearch(inds, t); found {
+			// j is bigger than everything so far, so it goes on the end
+			inds = append(inds, j)
+		} else {
+			inds = slices.Insert(inds, pos, t)
+		}
+	}
+	return inds
+}
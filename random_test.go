@@ -0,0 +1,188 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// check_uniform draws samples from draw, and checks that it only gives what the
+// generator g over data gives, each about as often as the others
+func check_uniform(t *testing.T, g combinationLike, data []int, draw func() []int, samples int) {
+	t.Helper()
+	seen := make(map[string]int)
+	for g.Next() {
+		items := make([]int, g.LenInds())
+		fill_buffer(items, data, g.Indices())
+		seen[fmt.Sprint(items)] = 0
+	}
+	for i := 0; i < samples; i++ {
+		key := fmt.Sprint(draw())
+		if _, ok := seen[key]; !ok {
+			t.Fatalf("drew %v, which the generator never gives", key)
+		}
+		seen[key]++
+	}
+	want := float64(samples) / float64(len(seen))
+	for key, got := range seen {
+		// Allow 15%, which is more than 5 standard deviations with these sample sizes
+		if float64(got) < 0.85*want || float64(got) > 1.15*want {
+			t.Errorf("%v drawn %v times, want about %v", key, got, want)
+		}
+	}
+}
+
+func TestRandomIsUniform(t *testing.T) {
+	data := []int{10, 20, 30, 40, 50}
+	src := rand.NewPCG(46, 1)
+	for k := 1; k <= 3; k++ {
+		t.Run(fmt.Sprintf("Combination k=%v", k), func(t *testing.T) {
+			g, _ := NewCombinations(data, k)
+			check_uniform(t, g, data, func() []int {
+				out, err := RandomCombination(data, k, src)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return out
+			}, 20_000)
+		})
+		t.Run(fmt.Sprintf("Permutation k=%v", k), func(t *testing.T) {
+			g, _ := NewPermutations(data, k)
+			check_uniform(t, g, data, func() []int {
+				out, err := RandomPermutation(data, k, src)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return out
+			}, 30_000)
+		})
+		t.Run(fmt.Sprintf("CombinationWithReplacement k=%v", k), func(t *testing.T) {
+			g, _ := NewCombinationsWithReplacement(data, k)
+			check_uniform(t, g, data, func() []int {
+				out, err := RandomCombinationWithReplacement(data, k, src)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return out
+			}, 20_000)
+		})
+	}
+}
+
+func TestRandomEdgeCases(t *testing.T) {
+	data := []int{1, 2, 3, 4}
+	src := rand.NewPCG(1, 2)
+	if got, _ := RandomCombination(data, 4, src); !slices.Equal(got, data) {
+		t.Errorf("RandomCombination() of everything = %v, want %v", got, data)
+	}
+	got, _ := RandomPermutation(data, 4, src)
+	sorted := slices.Clone(got)
+	slices.Sort(sorted)
+	if !slices.Equal(sorted, data) {
+		t.Errorf("RandomPermutation() of everything = %v, want a reordering of %v", got, data)
+	}
+	if got, err := RandomPermutation(data, 0, src); err != nil || len(got) != 0 {
+		t.Errorf("RandomPermutation() of nothing = %v, %v, want [], nil", got, err)
+	}
+	// More picks than items are fine with replacement
+	if got, err := RandomCombinationWithReplacement([]int{7}, 3, src); err != nil || !slices.Equal(got, []int{7, 7, 7}) {
+		t.Errorf("RandomCombinationWithReplacement() = %v, %v, want [7 7 7], nil", got, err)
+	}
+
+	// Larger than max_stack_k still works
+	big_data := stepped_range(0, 1000, 1)
+	combo, err := RandomCombination(big_data, 500, src)
+	if err != nil || len(combo) != 500 || !slices.IsSorted(combo) || len(slices.Compact(slices.Clone(combo))) != 500 {
+		t.Errorf("RandomCombination(1000 items, 500) did not give 500 distinct sorted items")
+	}
+}
+
+func TestRandomErrors(t *testing.T) {
+	data := []int{1, 2, 3}
+	src := rand.NewPCG(1, 2)
+	testCases := []struct {
+		desc string
+		err  error
+	}{
+		{desc: "combination k > n", err: second(RandomCombination(data, 4, src))},
+		{desc: "combination k = 0", err: second(RandomCombination(data, 0, src))},
+		{desc: "combination k < 0", err: second(RandomCombination(data, -1, src))},
+		{desc: "combination no data", err: second(RandomCombination([]int{}, 1, src))},
+		{desc: "permutation k > n", err: second(RandomPermutation(data, 4, src))},
+		{desc: "permutation k < 0", err: second(RandomPermutation(data, -1, src))},
+		{desc: "with replacement k = 0", err: second(RandomCombinationWithReplacement(data, 0, src))},
+		{desc: "with replacement k < 0", err: second(RandomCombinationWithReplacement(data, -1, src))},
+		{desc: "with replacement no data", err: second(RandomCombinationWithReplacement([]int{}, 2, src))},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if tC.err == nil {
+				t.Errorf("got no error")
+			}
+		})
+	}
+}
+
+func TestRandomKMatchesConstructors(t *testing.T) {
+	// Each function takes the same k as the generator's constructor, with the same error
+	data := []int{1, 2, 3}
+	src := rand.NewPCG(1, 2)
+	for _, k := range []int{-1, 0} {
+		testCases := []struct {
+			desc      string
+			got, want error
+		}{
+			{desc: "combination", got: second(RandomCombination(data, k, src)), want: second(NewCombinations(data, k))},
+			{desc: "permutation", got: second(RandomPermutation(data, k, src)), want: second(NewPermutations(data, k))},
+			{
+				desc: "with replacement",
+				got:  second(RandomCombinationWithReplacement(data, k, src)),
+				want: second(NewCombinationsWithReplacement(data, k)),
+			},
+		}
+		for _, tC := range testCases {
+			t.Run(fmt.Sprintf("%v k=%v", tC.desc, k), func(t *testing.T) {
+				if fmt.Sprint(tC.got) != fmt.Sprint(tC.want) {
+					t.Errorf("got error %v, want %v", tC.got, tC.want)
+				}
+			})
+		}
+	}
+}
+
+func second[T any](_ T, err error) error {
+	return err
+}
+
+func TestRandomIsReproducible(t *testing.T) {
+	data := stepped_range(0, 100, 1)
+	a, _ := RandomPermutation(data, 10, rand.NewPCG(5, 6))
+	b, _ := RandomPermutation(data, 10, rand.NewPCG(5, 6))
+	if !slices.Equal(a, b) {
+		t.Errorf("the same seed gave %v and %v", a, b)
+	}
+}
+
+func TestRandomIntoDoesNotAllocate(t *testing.T) {
+	data := stepped_range(0, 1000, 1)
+	out := make([]int, 10)
+	src := rand.NewPCG(1, 2)
+	allocs := testing.AllocsPerRun(100, func() {
+		RandomCombinationInto(out, data, src)
+		RandomPermutationInto(out, data, src)
+		RandomCombinationWithReplacementInto(out, data, src)
+	})
+	if allocs > 0 {
+		t.Errorf("the Into functions made %v allocations, want 0", allocs)
+	}
+}
+
+func BenchmarkRandomCombinationInto(b *testing.B) {
+	data := stepped_range(0, 1_000_000, 1)
+	out := make([]int, 20)
+	src := rand.NewPCG(1, 2)
+	for i := 0; i < b.N; i++ {
+		RandomCombinationInto(out, data, src)
+	}
+}
@@ -0,0 +1,196 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"iter"
+	"math"
+	"math/rand/v2"
+)
+
+// Reservoir keeps a random sample of k of the items added to it, without knowing ahead
+// of time how many there will be. Every item gets a priority E/w, where E is exponential
+// with rate 1 and w is the item's weight, and the reservoir keeps the k items with the
+// smallest priorities. With every weight 1 that is a uniformly random k-subset; with
+// weights, it is the same as `SuccessiveSampling` in `NewWeightedSample`.
+//
+// Once the reservoir is full, it does not draw a priority for every item. The chance
+// that an item with weight w beats the largest priority kept, t, is 1 - exp(-w*t), so
+// the total weight passed over before the next item gets in is exponential with rate t.
+// The reservoir draws that once, skips items until their weight adds up to it, and only
+// then draws a priority, below t, for the item that gets in. With every weight 1 this is
+// Li's Algorithm L, taking O(k * (1 + log(n/k))) random numbers for n items.
+//
+// As the sample only depends on the priorities, reservoirs filled from different parts
+// of a stream, with different sources of randomness, can be combined with `Merge()` into
+// a sample of the whole stream.
+type Reservoir[T any] struct {
+	k   int
+	rng *rand.Rand
+	// items and priorities are a max-heap on the priorities
+	items      []T
+	priorities []float64
+	// skip is how much more weight to pass over before the next item gets in
+	skip float64
+}
+
+// NewReservoir creates a new, empty Reservoir that keeps k items, using randomness from
+// src
+func NewReservoir[T any](k int, src rand.Source) (*Reservoir[T], error) {
+	if k <= 0 {
+		return nil, errors.New("k must be greater than 0")
+	}
+	return &Reservoir[T]{
+		k:          k,
+		rng:        rand.New(src),
+		items:      make([]T, 0, k),
+		priorities: make([]float64, 0, k),
+	}, nil
+}
+
+// Add adds item, with weight 1, to the stream being sampled
+func (r *Reservoir[T]) Add(item T) {
+	r.add(item, 1)
+}
+
+// AddWeighted adds item, with the given weight, to the stream being sampled. weight must
+// be finite and not negative. An item with weight 0 is only kept while there are not k
+// items with more weight.
+func (r *Reservoir[T]) AddWeighted(item T, weight float64) error {
+	if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return errors.New("weight must be finite and greater than or equal to 0")
+	}
+	r.add(item, weight)
+	return nil
+}
+
+func (r *Reservoir[T]) add(item T, weight float64) {
+	if len(r.items) < r.k {
+		r.push(item, r.rng.ExpFloat64()/weight)
+		if len(r.items) == r.k {
+			r.draw_skip()
+		}
+		return
+	}
+	if weight <= r.skip {
+		r.skip -= weight
+		return
+	}
+	// The priority of an item that gets in is exponential with rate weight, conditioned
+	// on being below t: -log(1 - v*(1 - exp(-weight*t)))/weight, for v uniform in [0, 1)
+	t := r.priorities[0]
+	priority := -math.Log1p(r.rng.Float64()*math.Expm1(-weight*t)) / weight
+	r.items[0], r.priorities[0] = item, priority
+	r.sift_down(0)
+	r.draw_skip()
+}
+
+// draw_skip draws how much weight to pass over before the next item gets in
+func (r *Reservoir[T]) draw_skip() {
+	r.skip = r.rng.ExpFloat64() / r.priorities[0]
+}
+
+// Merge adds the sample in other to r, so that r holds a sample of everything added to
+// either of them. other must keep the same number of items, should have been filled
+// with a different source of randomness, and is not changed.
+func (r *Reservoir[T]) Merge(other *Reservoir[T]) error {
+	if other.k != r.k {
+		return errors.New("both reservoirs must keep the same number of items")
+	}
+	for i, priority := range other.priorities {
+		if len(r.items) < r.k {
+			r.push(other.items[i], priority)
+		} else if priority < r.priorities[0] {
+			r.items[0], r.priorities[0] = other.items[i], priority
+			r.sift_down(0)
+		}
+	}
+	// The skip is memoryless, so it can be drawn again for the new largest priority
+	if len(r.items) == r.k {
+		r.draw_skip()
+	}
+	return nil
+}
+
+// Items returns the items in the sample, in no particular order. There are fewer than k
+// when fewer than k items have been added. The data in the slice returned will be
+// overwritten as more items are added. If you need to keep it, be sure to make a copy.
+func (r *Reservoir[T]) Items() []T {
+	return r.items
+}
+
+// LenInds returns how many items the reservoir keeps
+func (r *Reservoir[T]) LenInds() int {
+	return r.k
+}
+
+func (r *Reservoir[T]) push(item T, priority float64) {
+	r.items = append(r.items, item)
+	r.priorities = append(r.priorities, priority)
+	for i := len(r.items) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if r.priorities[parent] >= r.priorities[i] {
+			return
+		}
+		r.swap(i, parent)
+		i = parent
+	}
+}
+
+func (r *Reservoir[T]) sift_down(i int) {
+	for {
+		largest := i
+		for _, child := range [2]int{2*i + 1, 2*i + 2} {
+			if child < len(r.priorities) && r.priorities[child] > r.priorities[largest] {
+				largest = child
+			}
+		}
+		if largest == i {
+			return
+		}
+		r.swap(i, largest)
+		i = largest
+	}
+}
+
+func (r *Reservoir[T]) swap(i, j int) {
+	r.priorities[i], r.priorities[j] = r.priorities[j], r.priorities[i]
+	r.items[i], r.items[j] = r.items[j], r.items[i]
+}
+
+// ReservoirSample returns a uniformly random k-subset of the items seq gives, in no
+// particular order, going through seq once without knowing how long it is. If seq gives
+// fewer than k items, it returns all of them. To sample from a channel ch, pass
+//
+//	func(yield func(T) bool) {
+//		for item := range ch {
+//			if !yield(item) {
+//				return
+//			}
+//		}
+//	}
+func ReservoirSample[T any](seq iter.Seq[T], k int, src rand.Source) ([]T, error) {
+	r, err := NewReservoir[T](k, src)
+	if err != nil {
+		return nil, err
+	}
+	for item := range seq {
+		r.Add(item)
+	}
+	return r.Items(), nil
+}
+
+// WeightedReservoirSample is like ReservoirSample, where seq gives each item with its
+// weight, and items with more weight are more likely to be picked, as with
+// `SuccessiveSampling`
+func WeightedReservoirSample[T any](seq iter.Seq2[T, float64], k int, src rand.Source) ([]T, error) {
+	r, err := NewReservoir[T](k, src)
+	if err != nil {
+		return nil, err
+	}
+	for item, weight := range seq {
+		if err := r.AddWeighted(item, weight); err != nil {
+			return nil, err
+		}
+	}
+	return r.Items(), nil
+}
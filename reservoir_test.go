@@ -0,0 +1,224 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// sorted_copy returns the items in increasing order, to compare a reservoir's sample
+// with what `Combinations` gives
+func sorted_copy(items []int) []int {
+	out := slices.Clone(items)
+	slices.Sort(out)
+	return out
+}
+
+func TestReservoirSampleIsUniform(t *testing.T) {
+	data := []int{10, 20, 30, 40, 50, 60}
+	src := rand.NewPCG(49, 1)
+	for k := 1; k <= 4; k++ {
+		t.Run(fmt.Sprintf("k=%v", k), func(t *testing.T) {
+			g, _ := NewCombinations(data, k)
+			check_uniform(t, g, data, func() []int {
+				out, err := ReservoirSample(slices.Values(data), k, src)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return sorted_copy(out)
+			}, 30_000)
+		})
+	}
+}
+
+func TestReservoirMergeIsUniform(t *testing.T) {
+	data := []int{10, 20, 30, 40, 50, 60, 70}
+	shards := [][]int{data[:1], data[1:5], data[5:]}
+	seed := uint64(0)
+	g, _ := NewCombinations(data, 3)
+	check_uniform(t, g, data, func() []int {
+		var merged *Reservoir[int]
+		for _, shard := range shards {
+			seed++
+			r, _ := NewReservoir[int](3, rand.NewPCG(seed, 2))
+			for _, item := range shard {
+				r.Add(item)
+			}
+			if merged == nil {
+				merged = r
+			} else if err := merged.Merge(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return sorted_copy(merged.Items())
+	}, 30_000)
+}
+
+func TestWeightedReservoirSampleInclusionProbabilities(t *testing.T) {
+	weights := []float64{0.5, 1, 1, 2, 5, 0.1}
+	want := exact_inclusion(weights, 3, SuccessiveSampling)
+	pairs := func(yield func(int, float64) bool) {
+		for i, w := range weights {
+			if !yield(i, w) {
+				return
+			}
+		}
+	}
+	const samples = 40_000
+	src := rand.NewPCG(49, 2)
+	t.Run("one stream", func(t *testing.T) {
+		counts := make([]int, len(weights))
+		for i := 0; i < samples; i++ {
+			out, err := WeightedReservoirSample(pairs, 3, src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, item := range out {
+				counts[item]++
+			}
+		}
+		check_inclusion(t, counts, samples, want)
+	})
+	t.Run("merged shards", func(t *testing.T) {
+		counts := make([]int, len(weights))
+		for i := 0; i < samples; i++ {
+			a, _ := NewReservoir[int](3, src)
+			b, _ := NewReservoir[int](3, src)
+			for item, w := range weights {
+				shard := a
+				if item%2 == 1 {
+					shard = b
+				}
+				shard.AddWeighted(item, w)
+			}
+			a.Merge(b)
+			for _, item := range a.Items() {
+				counts[item]++
+			}
+		}
+		check_inclusion(t, counts, samples, want)
+	})
+}
+
+// check_inclusion checks that each item was drawn in about the fraction of samples it
+// should have been
+func check_inclusion(t *testing.T, counts []int, samples int, want []float64) {
+	t.Helper()
+	for i, c := range counts {
+		got := float64(c) / float64(samples)
+		// 0.015 is more than 6 standard deviations with these sample sizes
+		if math.Abs(got-want[i]) > 0.015 {
+			t.Errorf("item %v drawn in %v of samples, want %v", i, got, want[i])
+		}
+	}
+}
+
+// counting_source counts how many random numbers are taken from it
+type counting_source struct {
+	src   rand.Source
+	count int
+}
+
+func (c *counting_source) Uint64() uint64 {
+	c.count++
+	return c.src.Uint64()
+}
+
+func TestReservoirSkipsAhead(t *testing.T) {
+	n, k := 1_000_000, 10
+	src := &counting_source{src: rand.NewPCG(7, 8)}
+	hits := make(map[int]bool)
+	for run := 0; run < 5; run++ {
+		out, err := ReservoirSample(slices.Values(stepped_range(0, n, 1)), k, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range out {
+			hits[item/(n/10)] = true
+		}
+	}
+	// About k * (1 + log(n/k)) items get in, each taking a few random numbers
+	if src.count > 5*10_000 {
+		t.Errorf("took %v random numbers for 5 runs, want far fewer than one per item", src.count)
+	}
+	// The sample should be spread through the stream, not stuck at the start
+	if len(hits) < 8 {
+		t.Errorf("the samples only came from %v of the 10 tenths of the stream", slices.Sorted(maps.Keys(hits)))
+	}
+}
+
+func TestReservoirEdgeCases(t *testing.T) {
+	src := rand.NewPCG(1, 2)
+	if got, err := ReservoirSample(slices.Values([]int{3, 1}), 5, src); err != nil || !slices.Equal(sorted_copy(got), []int{1, 3}) {
+		t.Errorf("ReservoirSample() of fewer than k items = %v, %v, want [1 3], nil", got, err)
+	}
+	ch := make(chan int, 4)
+	for _, v := range []int{1, 2, 3, 4} {
+		ch <- v
+	}
+	close(ch)
+	from_channel := func(yield func(int) bool) {
+		for item := range ch {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+	if got, err := ReservoirSample(from_channel, 4, src); err != nil || !slices.Equal(sorted_copy(got), []int{1, 2, 3, 4}) {
+		t.Errorf("ReservoirSample() of a whole channel = %v, %v, want [1 2 3 4], nil", got, err)
+	}
+
+	// Weight 0 items are only kept when nothing else can be
+	r, _ := NewReservoir[int](2, src)
+	r.AddWeighted(0, 0)
+	r.AddWeighted(1, 0)
+	r.AddWeighted(2, 1)
+	r.AddWeighted(3, 0)
+	r.AddWeighted(4, 1)
+	if got := sorted_copy(r.Items()); !slices.Equal(got, []int{2, 4}) {
+		t.Errorf("Items() = %v, want [2 4]", got)
+	}
+
+	// Merging into an empty reservoir takes the other sample as it is
+	empty, _ := NewReservoir[int](2, src)
+	empty.Merge(r)
+	if got := sorted_copy(empty.Items()); !slices.Equal(got, []int{2, 4}) {
+		t.Errorf("Items() after Merge() = %v, want [2 4]", got)
+	}
+}
+
+func TestReservoirErrors(t *testing.T) {
+	src := rand.NewPCG(1, 2)
+	if _, err := NewReservoir[int](0, src); err == nil {
+		t.Errorf("NewReservoir() with k = 0 gave no error")
+	}
+	if _, err := ReservoirSample(slices.Values([]int{1}), -1, src); err == nil {
+		t.Errorf("ReservoirSample() with k < 0 gave no error")
+	}
+	r, _ := NewReservoir[int](2, src)
+	for _, w := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if err := r.AddWeighted(1, w); err == nil {
+			t.Errorf("AddWeighted() with weight %v gave no error", w)
+		}
+	}
+	other, _ := NewReservoir[int](3, src)
+	if err := r.Merge(other); err == nil {
+		t.Errorf("Merge() of reservoirs with different k gave no error")
+	}
+	bad := func(yield func(int, float64) bool) {
+		yield(1, -2)
+	}
+	if _, err := WeightedReservoirSample(bad, 1, src); err == nil {
+		t.Errorf("WeightedReservoirSample() with a negative weight gave no error")
+	}
+}
+
+func BenchmarkReservoirAdd(b *testing.B) {
+	r, _ := NewReservoir[int](100, rand.NewPCG(1, 2))
+	for i := 0; i < b.N; i++ {
+		r.Add(i)
+	}
+}
@@ -0,0 +1,399 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+// RestrictedPermutations generates, in lexicographic order, every permutation p of
+// 0, ..., n-1 such that forbidden[i][p[i]] is false for every i. Think of i as a person
+// and p[i] as the slot they are given: forbidden marks the slots each person cannot take.
+// With forbidden[i][i] true and everything else false, you get the derangements.
+//
+// Permutations are built up one position at a time, and a partial permutation is
+// abandoned as soon as the later positions can no longer all be given different slots,
+// so the search never goes down a branch with nothing at the end of it.
+// RestrictedPermutations meets the `CombinationLike` interface
+type RestrictedPermutations struct {
+	n         int
+	forbidden [][]bool
+	isfirst   bool
+	done      bool
+	inds      []int
+	used      []bool
+	// owner and seen are scratch space for dead_end: owner[j] is the position matched to
+	// slot j, or -1, and seen[j] == stamp marks the slots visited by this search
+	owner []int
+	seen  []int
+	stamp int
+	// length is nil until Len() or BigLen() first asks for it
+	length *length
+}
+
+// NewRestrictedPermutations creates a new RestrictedPermutations object. forbidden must
+// be an n by n board. How many permutations there are is only worked out when `Len()` or
+// `BigLen()` is first called, as that can take far longer than generating the first few.
+func NewRestrictedPermutations(n int, forbidden [][]bool) (*RestrictedPermutations, error) {
+	if n <= 0 {
+		return nil, errors.New("n must be greater than 0")
+	} else if len(forbidden) != n {
+		return nil, errors.New("forbidden must have n rows")
+	}
+	board := make([][]bool, n)
+	for i, row := range forbidden {
+		if len(row) != n {
+			return nil, errors.New("every row of forbidden must have n columns")
+		}
+		board[i] = make([]bool, n)
+		copy(board[i], row)
+	}
+
+	return &RestrictedPermutations{
+		n:         n,
+		forbidden: board,
+		isfirst:   true,
+		inds:      make([]int, n),
+		used:      make([]bool, n),
+		owner:     make([]int, n),
+		seen:      make([]int, n),
+	}, nil
+}
+
+// Len returns how many permutations there are, and false if that does not fit in a
+// uint64. It is counted with `CountRestrictedPermutations()` the first time it is needed.
+func (r *RestrictedPermutations) Len() (uint64, bool) {
+	return r.count().Len()
+}
+
+// BigLen returns how many permutations there are. It is counted with
+// `CountRestrictedPermutations()` the first time it is needed.
+func (r *RestrictedPermutations) BigLen() *big.Int {
+	return r.count().BigLen()
+}
+
+func (r *RestrictedPermutations) count() *length {
+	if r.length == nil {
+		total, _ := CountRestrictedPermutations(r.forbidden)
+		l := length_from_big(total)
+		r.length = &l
+	}
+	return r.length
+}
+
+// Next will move on to the next permutation that avoids the forbidden positions,
+// returning false when there are none left.
+func (r *RestrictedPermutations) Next() bool {
+	if r.done {
+		return false
+	}
+
+	// t is the position we are currently trying to fill
+	t := r.n - 1
+	if r.isfirst {
+		t = 0
+		r.inds[0] = -1
+		r.isfirst = false
+	} else {
+		r.used[r.inds[t]] = false
+	}
+
+	for t >= 0 {
+		// Try the next free slot that position t is allowed to take
+		v := r.inds[t] + 1
+		for v < r.n && (r.used[v] || r.forbidden[t][v]) {
+			v++
+		}
+		if v == r.n {
+			// Nothing left for this position, so back up
+			t--
+			if t >= 0 {
+				r.used[r.inds[t]] = false
+			}
+			continue
+		}
+		r.inds[t] = v
+		r.used[v] = true
+
+		if t == r.n-1 {
+			return true
+		}
+		if r.dead_end(t + 1) {
+			r.used[v] = false
+			continue
+		}
+		t++
+		r.inds[t] = -1
+	}
+	r.done = true
+	return false
+}
+
+// dead_end reports whether the positions from `from` onwards can no longer all be given
+// different free slots that they are allowed to take, which is exactly when the partial
+// permutation cannot be finished. It looks for a matching of those positions to free
+// slots with augmenting paths (Kuhn's algorithm); by Hall's theorem there is none when
+// some group of positions has fewer allowed free slots between them than its size.
+func (r *RestrictedPermutations) dead_end(from int) bool {
+	for j := range r.owner {
+		r.owner[j] = -1
+	}
+	for i := from; i < r.n; i++ {
+		r.stamp++
+		if !r.augment(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// augment tries to match position i to a free slot, moving the positions already matched
+// along to other slots if it has to
+func (r *RestrictedPermutations) augment(i int) bool {
+	for j := 0; j < r.n; j++ {
+		if r.used[j] || r.forbidden[i][j] || r.seen[j] == r.stamp {
+			continue
+		}
+		r.seen[j] = r.stamp
+		if r.owner[j] == -1 || r.augment(r.owner[j]) {
+			r.owner[j] = i
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RestrictedPermutations) LenInds() int {
+	return r.n
+}
+
+// Indices gives the current permutation: position i gets slot Indices()[i]
+func (r *RestrictedPermutations) Indices() []int {
+	return r.inds
+}
+
+// Items gives the current permutation. As there is no input data, this is the same
+// slice as `Indices()`, and it will be overwritten every iteration.
+func (r *RestrictedPermutations) Items() []int {
+	return r.inds
+}
+
+// RookPolynomial returns the coefficients of the rook polynomial of board: element k
+// is the number of ways to place k rooks on the true cells of board so that no two
+// share a row or a column. The board does not need to be square, but every row must
+// have the same number of columns.
+//
+// The board is first split into blocks that share no rows or columns, as the rook
+// polynomial of the whole is the product of the rook polynomials of the blocks. Each
+// block is then done row by row, keeping track of which columns are taken, which takes
+// time exponential in the number of columns the block uses.
+func RookPolynomial(board [][]bool) ([]*big.Int, error) {
+	n_cols := 0
+	for i, row := range board {
+		if i == 0 {
+			n_cols = len(row)
+		} else if len(row) != n_cols {
+			return nil, errors.New("every row of board must have the same number of columns")
+		}
+	}
+
+	result := []*big.Int{big.NewInt(1)}
+	for _, rows := range board_blocks(board, n_cols) {
+		result = multiply_polynomials(result, block_rook_polynomial(board, rows))
+	}
+	return result, nil
+}
+
+// board_blocks groups the rows of board that are linked through shared columns
+func board_blocks(board [][]bool, n_cols int) [][]int {
+	// Union-find over the rows, joining each row to the first row seen in each column
+	parent := stepped_range(0, len(board), 1)
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	first_in_col := make([]int, n_cols)
+	for j := range first_in_col {
+		first_in_col[j] = -1
+	}
+	for i, row := range board {
+		for j, cell := range row {
+			if !cell {
+				continue
+			}
+			if first_in_col[j] == -1 {
+				first_in_col[j] = i
+			} else {
+				parent[find(i)] = find(first_in_col[j])
+			}
+		}
+	}
+
+	blocks := make([][]int, 0)
+	block_of := make(map[int]int)
+	for i := range board {
+		root := find(i)
+		idx, ok := block_of[root]
+		if !ok {
+			idx = len(blocks)
+			block_of[root] = idx
+			blocks = append(blocks, make([]int, 0))
+		}
+		blocks[idx] = append(blocks[idx], i)
+	}
+	return blocks
+}
+
+// block_rook_polynomial finds the rook polynomial of the given rows of board
+func block_rook_polynomial(board [][]bool, rows []int) []*big.Int {
+	// Number the columns the block uses from 0, so that only the block's columns, not the
+	// whole board's, have to fit in a mask
+	col_index := make(map[int]uint)
+	for _, i := range rows {
+		for j, cell := range board[i] {
+			if _, ok := col_index[j]; cell && !ok {
+				col_index[j] = uint(len(col_index))
+			}
+		}
+	}
+	if len(col_index) > 64 {
+		return wide_block_rook_polynomial(board, rows, col_index)
+	}
+
+	// ways[mask] is how many rook placements use exactly the columns in mask
+	ways := map[uint64]*big.Int{0: big.NewInt(1)}
+	for _, i := range rows {
+		next := make(map[uint64]*big.Int, len(ways))
+		for mask, count := range ways {
+			// Put no rook in this row
+			add_to(next, mask, count)
+			// Or put one in any free cell
+			for j, cell := range board[i] {
+				if bit := uint64(1) << col_index[j]; cell && mask&bit == 0 {
+					add_to(next, mask|bit, count)
+				}
+			}
+		}
+		ways = next
+	}
+
+	result := make([]*big.Int, len(rows)+1)
+	for k := range result {
+		result[k] = big.NewInt(0)
+	}
+	for mask, count := range ways {
+		k := bits.OnesCount64(mask)
+		result[k].Add(result[k], count)
+	}
+	return trim_polynomial(result)
+}
+
+// wide_block_rook_polynomial is block_rook_polynomial for blocks with more than 64
+// columns, keeping the masks as *big.Int
+func wide_block_rook_polynomial(board [][]bool, rows []int, col_index map[int]uint) []*big.Int {
+	type placements struct {
+		mask, count *big.Int
+	}
+	add := func(m map[string]placements, mask, count *big.Int) {
+		key := string(mask.Bytes())
+		if existing, ok := m[key]; ok {
+			existing.count.Add(existing.count, count)
+		} else {
+			m[key] = placements{mask: mask, count: new(big.Int).Set(count)}
+		}
+	}
+
+	ways := map[string]placements{"": {mask: big.NewInt(0), count: big.NewInt(1)}}
+	for _, i := range rows {
+		next := make(map[string]placements, len(ways))
+		for _, p := range ways {
+			add(next, p.mask, p.count)
+			for j, cell := range board[i] {
+				if cell && p.mask.Bit(int(col_index[j])) == 0 {
+					add(next, new(big.Int).SetBit(p.mask, int(col_index[j]), 1), p.count)
+				}
+			}
+		}
+		ways = next
+	}
+
+	result := make([]*big.Int, len(rows)+1)
+	for k := range result {
+		result[k] = big.NewInt(0)
+	}
+	for _, p := range ways {
+		k := 0
+		for _, word := range p.mask.Bits() {
+			k += bits.OnesCount(uint(word))
+		}
+		result[k].Add(result[k], p.count)
+	}
+	return trim_polynomial(result)
+}
+
+func add_to(m map[uint64]*big.Int, key uint64, val *big.Int) {
+	if existing, ok := m[key]; ok {
+		existing.Add(existing, val)
+	} else {
+		m[key] = new(big.Int).Set(val)
+	}
+}
+
+// multiply_polynomials multiplies two polynomials given by their coefficients
+func multiply_polynomials(a, b []*big.Int) []*big.Int {
+	result := make([]*big.Int, len(a)+len(b)-1)
+	for i := range result {
+		result[i] = big.NewInt(0)
+	}
+	term := new(big.Int)
+	for i, x := range a {
+		for j, y := range b {
+			result[i+j].Add(result[i+j], term.Mul(x, y))
+		}
+	}
+	return result
+}
+
+// trim_polynomial drops trailing zero coefficients, keeping at least one
+func trim_polynomial(p []*big.Int) []*big.Int {
+	for len(p) > 1 && p[len(p)-1].Sign() == 0 {
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+// CountRestrictedPermutations returns the number of permutations that
+// `NewRestrictedPermutations` would generate for an n by n board, without generating
+// them. By inclusion-exclusion, it is
+// sum((-1)^k * r_k * (n-k)! for k in range(n+1))
+// where r_k are the coefficients of the rook polynomial of the forbidden board. Like
+// `NewRestrictedPermutations`, every row of forbidden must have n columns.
+func CountRestrictedPermutations(forbidden [][]bool) (*big.Int, error) {
+	n := len(forbidden)
+	for _, row := range forbidden {
+		if len(row) != n {
+			return nil, errors.New("every row of forbidden must have n columns")
+		}
+	}
+	rooks, err := RookPolynomial(forbidden)
+	if err != nil {
+		return nil, err
+	}
+	total := big.NewInt(0)
+	term := new(big.Int)
+	for k, r_k := range rooks {
+		if k > n {
+			break
+		}
+		term.Mul(r_k, factorial(int64(n-k)))
+		if k%2 == 0 {
+			total.Add(total, term)
+		} else {
+			total.Sub(total, term)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,288 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/natemcintosh/gocombinatorics/count"
+)
+
+// diagonal_board is the board that forbids everyone from their own slot
+func diagonal_board(n int) [][]bool {
+	board := make([][]bool, n)
+	for i := range board {
+		board[i] = make([]bool, n)
+		board[i][i] = true
+	}
+	return board
+}
+
+// restricted_by_filtering checks every permutation against the board
+func restricted_by_filtering(forbidden [][]bool) [][]int {
+	n := len(forbidden)
+	p, _ := NewPermutations(stepped_range(0, n, 1), n)
+	result := make([][]int, 0)
+	for p.Next() {
+		ok := true
+		for i, j := range p.Indices() {
+			if forbidden[i][j] {
+				ok = false
+			}
+		}
+		if ok {
+			next_set_of_indices := make([]int, n)
+			copy(next_set_of_indices, p.Indices())
+			result = append(result, next_set_of_indices)
+		}
+	}
+	return result
+}
+
+func TestNewRestrictedPermutationsErrors(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		n         int
+		forbidden [][]bool
+	}{
+		{desc: "n <= 0", n: 0, forbidden: [][]bool{}},
+		{desc: "too few rows", n: 2, forbidden: [][]bool{{false, false}}},
+		{desc: "row too short", n: 2, forbidden: [][]bool{{false, false}, {false}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := NewRestrictedPermutations(tC.n, tC.forbidden); err == nil {
+				t.Errorf("NewRestrictedPermutations() did not return an error")
+			}
+		})
+	}
+}
+
+func TestRookPolynomial(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		board [][]bool
+		want  []int64
+	}{
+		{desc: "empty", board: [][]bool{}, want: []int64{1}},
+		{desc: "one cell", board: [][]bool{{true}}, want: []int64{1, 1}},
+		{desc: "full 2x2", board: [][]bool{{true, true}, {true, true}}, want: []int64{1, 4, 2}},
+		{desc: "full 3x3", board: [][]bool{{true, true, true}, {true, true, true}, {true, true, true}}, want: []int64{1, 9, 18, 6}},
+		{desc: "diagonal 4", board: diagonal_board(4), want: []int64{1, 4, 6, 4, 1}},
+		{desc: "one row", board: [][]bool{{true, false, true, true}}, want: []int64{1, 3}},
+		{
+			desc:  "staircase",
+			board: [][]bool{{true, false, false}, {true, true, false}, {true, true, true}},
+			want:  []int64{1, 6, 7, 1},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := RookPolynomial(tC.board)
+			if err != nil {
+				t.Fatalf("RookPolynomial() = %v, want nil", err)
+			}
+			want := make([]*big.Int, len(tC.want))
+			for i, w := range tC.want {
+				want[i] = big.NewInt(w)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("RookPolynomial() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCountRestrictedPermutationsDerangements(t *testing.T) {
+	derangements := []string{"1", "0", "1", "2", "9", "44", "265", "1854", "14833", "133496", "1334961"}
+	for n, want := range derangements {
+		got, err := CountRestrictedPermutations(diagonal_board(n))
+		if err != nil {
+			t.Fatalf("CountRestrictedPermutations() = %v, want nil", err)
+		}
+		if got.Cmp(bigIntFromString(want)) != 0 {
+			t.Errorf("CountRestrictedPermutations(diagonal %v) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestRookPolynomialErrors(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		count bool
+		board [][]bool
+	}{
+		{desc: "ragged board", board: [][]bool{{true, false}, {true}}},
+		{desc: "ragged board to count", count: true, board: [][]bool{{true, false}, {true}}},
+		{desc: "too many columns to count", count: true, board: [][]bool{{true, false, false}, {false, true, false}}},
+		{desc: "too few columns to count", count: true, board: [][]bool{{true}, {false}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var err error
+			if tC.count {
+				_, err = CountRestrictedPermutations(tC.board)
+			} else {
+				_, err = RookPolynomial(tC.board)
+			}
+			if err == nil {
+				t.Errorf("got no error")
+			}
+		})
+	}
+}
+
+func TestRestrictedPermutationsNext(t *testing.T) {
+	r, err := NewRestrictedPermutations(3, diagonal_board(3))
+	if err != nil {
+		t.Fatalf("NewRestrictedPermutations() = %v, want nil", err)
+	}
+	want := [][]int{{1, 2, 0}, {2, 0, 1}}
+	got := make([][]int, 0)
+	for r.Next() {
+		next_set_of_indices := make([]int, len(r.Items()))
+		copy(next_set_of_indices, r.Items())
+		got = append(got, next_set_of_indices)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RestrictedPermutations = %v, want %v", got, want)
+	}
+	if r.Next() {
+		t.Errorf("Next() returned true after the end")
+	}
+}
+
+func Test100RandomRestrictedPermutations(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		n := rand.Intn(7) + 1
+		density := rand.Float64() * 0.6
+		forbidden := make([][]bool, n)
+		for row := range forbidden {
+			forbidden[row] = make([]bool, n)
+			for col := range forbidden[row] {
+				forbidden[row][col] = rand.Float64() < density
+			}
+		}
+
+		run_name := fmt.Sprintf("%v", forbidden)
+		t.Run(run_name, func(t *testing.T) {
+			want := restricted_by_filtering(forbidden)
+			r, err := NewRestrictedPermutations(n, forbidden)
+			if err != nil {
+				t.Fatalf("NewRestrictedPermutations() = %v, want nil", err)
+			}
+			if r.BigLen().Cmp(big.NewInt(int64(len(want)))) != 0 {
+				t.Errorf("BigLen() = %v, want %v", r.BigLen(), len(want))
+			}
+			got := make([][]int, 0)
+			for r.Next() {
+				next_set_of_indices := make([]int, n)
+				copy(next_set_of_indices, r.Indices())
+				got = append(got, next_set_of_indices)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("RestrictedPermutations = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// check_restricted checks that perm is a permutation that keeps out of forbidden
+func check_restricted(t *testing.T, perm []int, forbidden [][]bool) {
+	t.Helper()
+	seen := make([]bool, len(perm))
+	for i, j := range perm {
+		if seen[j] || forbidden[i][j] {
+			t.Fatalf("%v is not a permutation that keeps out of the forbidden cells", perm)
+		}
+		seen[j] = true
+	}
+}
+
+func TestRestrictedPermutationsLargeBoards(t *testing.T) {
+	// More than 64 columns, which used to be too many to count up front
+	r, err := NewRestrictedPermutations(65, diagonal_board(65))
+	if err != nil {
+		t.Fatalf("NewRestrictedPermutations() = %v, want nil", err)
+	}
+	prev := make([]int, 0)
+	for i := 0; i < 10 && r.Next(); i++ {
+		check_restricted(t, r.Indices(), diagonal_board(65))
+		if slices.Compare(prev, r.Indices()) >= 0 {
+			t.Errorf("%v came after %v, want lexicographic order", r.Indices(), prev)
+		}
+		prev = slices.Clone(r.Indices())
+	}
+	if got, want := r.BigLen(), count.Subfactorial(65); got.Cmp(want) != 0 {
+		t.Errorf("BigLen() = %v, want %v", got, want)
+	}
+
+	// A dense board takes a long time to count, but the first permutations come at once
+	random := rand.New(rand.NewSource(30))
+	forbidden := make([][]bool, 20)
+	for i := range forbidden {
+		forbidden[i] = make([]bool, 20)
+		for j := range forbidden[i] {
+			forbidden[i][j] = random.Float64() < 0.5
+		}
+	}
+	r, err = NewRestrictedPermutations(20, forbidden)
+	if err != nil {
+		t.Fatalf("NewRestrictedPermutations() = %v, want nil", err)
+	}
+	for i := 0; i < 100 && r.Next(); i++ {
+		check_restricted(t, r.Indices(), forbidden)
+	}
+}
+
+func TestRestrictedPermutationsPrunesDeadEnds(t *testing.T) {
+	// The last two people can only take slot 0, so there is nothing to find. Each of them
+	// always has a slot on their own, so only a check of them together finds that out
+	// before going through the 10! ways to seat everyone else.
+	n := 12
+	forbidden := make([][]bool, n)
+	for i := range forbidden {
+		forbidden[i] = make([]bool, n)
+		for j := 1; j < n && i >= n-2; j++ {
+			forbidden[i][j] = true
+		}
+	}
+	r, _ := NewRestrictedPermutations(n, forbidden)
+	if r.Next() {
+		t.Errorf("Next() = true, want false, with %v", r.Indices())
+	}
+	if got, ok := r.Len(); !ok || got != 0 {
+		t.Errorf("Len() = %v, %v, want 0, true", got, ok)
+	}
+}
+
+func TestRookPolynomialWideBlock(t *testing.T) {
+	// Two full rows of 70 columns: 140 ways to place one rook and 70 * 69 to place two
+	board := [][]bool{make([]bool, 70), make([]bool, 70)}
+	for i := range board {
+		for j := range board[i] {
+			board[i][j] = true
+		}
+	}
+	got, err := RookPolynomial(board)
+	if err != nil {
+		t.Fatalf("RookPolynomial() = %v, want nil", err)
+	}
+	want := []*big.Int{big.NewInt(1), big.NewInt(140), big.NewInt(4830)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RookPolynomial() = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkRookPolynomial(b *testing.B) {
+	board := diagonal_board(20)
+	for i := range board {
+		board[i][(i+1)%20] = true
+	}
+	for i := 0; i < b.N; i++ {
+		RookPolynomial(board)
+	}
+}
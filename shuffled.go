@@ -0,0 +1,182 @@
+package gocombinatorics
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// Rankable is a generator that can jump straight to any position in the order it
+// generates things. `Combinations`, `Permutations`, `CombinationsWithReplacement`,
+// `SpacedCombinations` and `GroupedPermutations` all are.
+type Rankable[T any] interface {
+	CombinationLike[T]
+	BigLen() *big.Int
+	Rank() *big.Int
+	Unrank(rank *big.Int) error
+}
+
+// Shuffled steps through everything a generator gives, each exactly once, in an order
+// that looks random but is fixed by a seed. It never holds more than one item at a time,
+// so it works however long the generator is, and stopping after the first m items gives
+// a uniform-looking sample of m of them without repeats. Create it with `NewShuffled`.
+//
+// The order comes from a keyed permutation of the ranks [0, BigLen()): a Feistel cipher
+// on the smallest even number of bits that holds every rank, with cycle walking (apply
+// the cipher again until the result is a rank) to bring it down to exactly that range.
+// Step i unranks the cipher's output for i. This is a pseudo-random order, good for
+// randomized searches, not a cryptographically secure one.
+type Shuffled[T any] struct {
+	gen Rankable[T]
+	length
+	cipher  *feistel
+	counter *big.Int
+}
+
+// NewShuffled creates a new Shuffled that steps through everything gen gives, in the
+// order picked by seed. The same seed always gives the same order. gen is moved around
+// by the Shuffled, so it should not be used for anything else while the Shuffled is.
+func NewShuffled[T any](gen Rankable[T], seed uint64) (*Shuffled[T], error) {
+	if gen == nil {
+		return nil, errors.New("gen must not be nil")
+	}
+	l := gen.BigLen()
+	return &Shuffled[T]{
+		gen:     gen,
+		length:  length_from_big(l),
+		cipher:  new_feistel(l, seed),
+		counter: big.NewInt(0),
+	}, nil
+}
+
+// Next moves on to the next item, returning false when every item has been given
+func (s *Shuffled[T]) Next() bool {
+	if s.cmp(s.counter) <= 0 {
+		return false
+	}
+	s.gen.Unrank(s.cipher.walk(s.counter))
+	s.counter.Add(s.counter, big.NewInt(1))
+	return true
+}
+
+// LenInds returns how many indices each item has
+func (s *Shuffled[T]) LenInds() int {
+	return s.gen.LenInds()
+}
+
+// Indices returns the indices of the current item
+func (s *Shuffled[T]) Indices() []int {
+	return s.gen.Indices()
+}
+
+// Items returns the current item. Like the generator's `Items()`, the slice is
+// overwritten every iteration.
+func (s *Shuffled[T]) Items() []T {
+	return s.gen.Items()
+}
+
+// Rank returns the position of the current item in the generator's own order
+func (s *Shuffled[T]) Rank() *big.Int {
+	return s.gen.Rank()
+}
+
+// feistel_rounds is how many rounds the Feistel cipher has. Four are enough to make a
+// Feistel network a pseudo-random permutation; a couple more cover weak round functions.
+const feistel_rounds = 6
+
+// feistel is a keyed permutation of [0, limit), built from a balanced Feistel network
+// on 2*half bits
+type feistel struct {
+	limit *big.Int
+	half  uint
+	seed  uint64
+	// keys are the round keys for the uint64 round function, used when 2*half <= 64
+	keys [feistel_rounds]uint64
+}
+
+func new_feistel(limit *big.Int, seed uint64) *feistel {
+	// The halves need enough bits between them for limit-1, and at least one each
+	n_bits := uint(new(big.Int).Sub(limit, big.NewInt(1)).BitLen())
+	f := &feistel{limit: new(big.Int).Set(limit), half: max((n_bits+1)/2, 1), seed: seed}
+	state := seed
+	for i := range f.keys {
+		f.keys[i] = splitmix64(&state)
+	}
+	return f
+}
+
+// walk returns where the permutation sends x, for x in [0, limit). The cipher permutes
+// [0, 2^(2*half)), so following it from x must come back into [0, limit), and the first
+// value it reaches there is what x maps to. As 2^(2*half) < 4*limit, that takes fewer
+// than 4 steps on average.
+func (f *feistel) walk(x *big.Int) *big.Int {
+	if 2*f.half <= 64 {
+		limit := f.limit.Uint64()
+		y := f.encrypt64(x.Uint64())
+		for y >= limit {
+			y = f.encrypt64(y)
+		}
+		return new(big.Int).SetUint64(y)
+	}
+	y := f.encrypt_big(x)
+	for y.Cmp(f.limit) >= 0 {
+		y = f.encrypt_big(y)
+	}
+	return y
+}
+
+// encrypt64 applies the Feistel network to x, which has 2*half <= 64 bits
+func (f *feistel) encrypt64(x uint64) uint64 {
+	mask := uint64(1)<<f.half - 1
+	left, right := x>>f.half, x&mask
+	for _, key := range f.keys {
+		left, right = right, left^(mix64(right^key)&mask)
+	}
+	return left<<f.half | right
+}
+
+// encrypt_big applies the Feistel network to x, for when 2*half > 64
+func (f *feistel) encrypt_big(x *big.Int) *big.Int {
+	mask := new(big.Int).Lsh(big.NewInt(1), f.half)
+	mask.Sub(mask, big.NewInt(1))
+	left := new(big.Int).Rsh(x, f.half)
+	right := new(big.Int).And(x, mask)
+	for round := 0; round < feistel_rounds; round++ {
+		left.Xor(left, f.round_big(round, right))
+		left, right = right, left
+	}
+	return left.Lsh(left, f.half).Or(left, right)
+}
+
+// round_big is the round function for encrypt_big: half bits from SHA-256 of the seed,
+// the round and right, in counter mode for when half is more than 256 bits
+func (f *feistel) round_big(round int, right *big.Int) *big.Int {
+	n_bytes := int(f.half+7) / 8
+	out := make([]byte, 0, n_bytes+sha256.Size)
+	var header [16]byte
+	binary.LittleEndian.PutUint64(header[:8], f.seed)
+	header[8] = byte(round)
+	for block := uint32(0); len(out) < n_bytes; block++ {
+		binary.LittleEndian.PutUint32(header[12:], block)
+		h := sha256.New()
+		h.Write(header[:])
+		h.Write(right.Bytes())
+		out = h.Sum(out)
+	}
+	v := new(big.Int).SetBytes(out[:n_bytes])
+	return v.Rsh(v, uint(n_bytes*8)-f.half)
+}
+
+// splitmix64 moves state on and returns the next output of SplitMix64
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	return mix64(*state)
+}
+
+// mix64 is the SplitMix64 finaliser, which scrambles the bits of x
+func mix64(x uint64) uint64 {
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
@@ -0,0 +1,193 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// check_rank_and_unrank walks through a generator from make_gen, checking that Rank()
+// counts up from 0, that a second generator unranked to each rank agrees, and that
+// carrying on with Next() after Unrank() picks up from there
+func check_rank_and_unrank(t *testing.T, make_gen func() Rankable[int]) {
+	t.Helper()
+	g, u := make_gen(), make_gen()
+	rank := int64(0)
+	for ; g.Next(); rank++ {
+		if got := g.Rank(); got.Cmp(big.NewInt(rank)) != 0 {
+			t.Errorf("Rank() of %v = %v, want %v", g.Indices(), got, rank)
+		}
+		if err := u.Unrank(big.NewInt(rank)); err != nil {
+			t.Fatalf("Unrank(%v) = %v, want nil", rank, err)
+		}
+		if !reflect.DeepEqual(u.Indices(), g.Indices()) {
+			t.Errorf("Unrank(%v) = %v, want %v", rank, u.Indices(), g.Indices())
+		}
+	}
+	if g.BigLen().Cmp(big.NewInt(rank)) != 0 {
+		t.Errorf("Saw %v items, want BigLen() = %v", rank, g.BigLen())
+	}
+
+	// Carry on from the middle
+	middle := rank / 2
+	u = make_gen()
+	u.Unrank(big.NewInt(middle))
+	for i := middle + 1; u.Next(); i++ {
+		if got := u.Rank(); got.Cmp(big.NewInt(i)) != 0 {
+			t.Fatalf("Rank() after Unrank(%v) and Next = %v, want %v", middle, got, i)
+		}
+	}
+
+	for _, bad := range []*big.Int{big.NewInt(-1), g.BigLen()} {
+		if err := u.Unrank(bad); err == nil {
+			t.Errorf("Unrank(%v) did not return an error", bad)
+		}
+	}
+}
+
+// shuffled_generators makes one of each Rankable generator over 0, ..., n-1
+func shuffled_generators(n int) map[string]func() Rankable[int] {
+	data := stepped_range(0, n, 1)
+	return map[string]func() Rankable[int]{
+		"Combinations": func() Rankable[int] {
+			g, _ := NewCombinations(data, 3)
+			return g
+		},
+		"Permutations": func() Rankable[int] {
+			g, _ := NewPermutations(data, 3)
+			return g
+		},
+		"CombinationsWithReplacement": func() Rankable[int] {
+			g, _ := NewCombinationsWithReplacement(data, 3)
+			return g
+		},
+		"SpacedCombinations": func() Rankable[int] {
+			g, _ := NewSpacedCombinations(data, 3, 2, true)
+			return g
+		},
+		"GroupedPermutations": func() Rankable[int] {
+			groups := make([]int, n)
+			for i := range groups {
+				groups[i] = i % 2
+			}
+			g, _ := NewGroupedPermutations(data, groups)
+			return g
+		},
+	}
+}
+
+func TestShuffledVisitsEverythingOnce(t *testing.T) {
+	for name, make_gen := range shuffled_generators(8) {
+		t.Run(name, func(t *testing.T) {
+			want := make(map[string]bool)
+			for g := make_gen(); g.Next(); {
+				want[fmt.Sprint(g.Indices())] = true
+			}
+
+			s, err := NewShuffled(make_gen(), 47)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.BigLen(); got.Cmp(big.NewInt(int64(len(want)))) != 0 {
+				t.Errorf("BigLen() = %v, want %v", got, len(want))
+			}
+			seen := make(map[string]bool)
+			in_order := true
+			for i := int64(0); s.Next(); i++ {
+				key := fmt.Sprint(s.Indices())
+				if !want[key] {
+					t.Fatalf("Shuffled gave %v, which the generator does not", key)
+				} else if seen[key] {
+					t.Fatalf("Shuffled gave %v twice", key)
+				}
+				seen[key] = true
+				in_order = in_order && s.Rank().Cmp(big.NewInt(i)) == 0
+			}
+			if len(seen) != len(want) {
+				t.Errorf("Shuffled gave %v items, want %v", len(seen), len(want))
+			}
+			if in_order {
+				t.Errorf("Shuffled gave everything in the generator's own order")
+			}
+			if s.Next() {
+				t.Errorf("Next() = true after the end")
+			}
+		})
+	}
+}
+
+func TestShuffledIsDeterministic(t *testing.T) {
+	order := func(seed uint64) []string {
+		g, _ := NewCombinations(stepped_range(0, 20, 1), 4)
+		s, _ := NewShuffled[int](g, seed)
+		result := make([]string, 0)
+		for i := 0; i < 50 && s.Next(); i++ {
+			result = append(result, fmt.Sprint(s.Items()))
+		}
+		return result
+	}
+	if a, b := order(1), order(1); !reflect.DeepEqual(a, b) {
+		t.Errorf("the same seed gave different orders")
+	}
+	if a, b := order(1), order(2); reflect.DeepEqual(a, b) {
+		t.Errorf("different seeds gave the same order")
+	}
+}
+
+func TestFeistelIsAPermutation(t *testing.T) {
+	for _, limit := range []int64{1, 2, 3, 4, 5, 17, 64, 100, 255, 256, 1000} {
+		for seed := uint64(0); seed < 3; seed++ {
+			f := new_feistel(big.NewInt(limit), seed)
+			seen := make([]bool, limit)
+			for x := int64(0); x < limit; x++ {
+				y := f.walk(big.NewInt(x)).Int64()
+				if y < 0 || y >= limit || seen[y] {
+					t.Fatalf("limit %v, seed %v: walk(%v) = %v, which is out of range or repeated", limit, seed, x, y)
+				}
+				seen[y] = true
+			}
+		}
+	}
+}
+
+func TestFeistelBeyondUint64(t *testing.T) {
+	// 30! is about 2^108, so this uses the big round function. Check the first few
+	// thousand are distinct ranks, and that Permutations can unrank them.
+	p, _ := NewPermutations(stepped_range(0, 30, 1), 30)
+	s, err := NewShuffled[int](p, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Len(); ok {
+		t.Fatalf("30! fits in a uint64, want it not to")
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 2000 && s.Next(); i++ {
+		rank := s.Rank()
+		if rank.Sign() < 0 || rank.Cmp(s.BigLen()) >= 0 || seen[rank.String()] {
+			t.Fatalf("rank %v is out of range or repeated", rank)
+		}
+		seen[rank.String()] = true
+	}
+
+	// The big round function on a small domain, where every value can be checked
+	f := new_feistel(big.NewInt(1024), 4)
+	seen = make(map[string]bool)
+	for x := int64(0); x < 1024; x++ {
+		y := f.encrypt_big(big.NewInt(x))
+		if y.Sign() < 0 || y.Cmp(big.NewInt(1024)) >= 0 || seen[y.String()] {
+			t.Fatalf("encrypt_big(%v) = %v, which is out of range or repeated", x, y)
+		}
+		seen[y.String()] = true
+	}
+}
+
+func BenchmarkShuffledNext(b *testing.B) {
+	million := stepped_range(0, 1_000_000, 1)
+	c, _ := NewCombinations(million, 3)
+	s, _ := NewShuffled[int](c, 1)
+	for i := 0; i < b.N; i++ {
+		s.Next()
+	}
+}
@@ -0,0 +1,99 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"iter"
+)
+
+// Option changes how a generator is made. Pass it as an extra argument to
+// `NewCombinations`, `NewPermutations` or `NewCombinationsWithReplacement`.
+type Option func(*options)
+
+type options struct {
+	no_copy bool
+}
+
+// WithNoCopy makes the generator use input_data as it is, instead of making its own copy
+// of it. That saves memory for large inputs, but you must not change input_data while
+// the generator is in use, or `Items()` will give the changed items.
+func WithNoCopy() Option {
+	return func(o *options) {
+		o.no_copy = true
+	}
+}
+
+// source is where a generator gets its items from: a slice, a function that gives the
+// item at each index, or an iter.Seq that is read the first time an item is needed
+type source[T any] struct {
+	n    int
+	data []T
+	at   func(int) T
+	seq  iter.Seq[T]
+}
+
+// source_from_slice returns a source over input_data, which is copied unless opts
+// contains `WithNoCopy()`
+func source_from_slice[T any](input_data []T, opts []Option) source[T] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	data := input_data
+	if !o.no_copy {
+		data = make([]T, len(input_data))
+		copy(data, input_data)
+	}
+	return source[T]{n: len(input_data), data: data}
+}
+
+// source_from_func returns a source where at(i) is the item at index i, for i in [0, n)
+func source_from_func[T any](n int, at func(int) T) (source[T], error) {
+	if n < 0 {
+		return source[T]{}, errors.New("n must be greater than or equal to 0")
+	} else if at == nil {
+		return source[T]{}, errors.New("at must not be nil")
+	}
+	return source[T]{n: n, at: at}, nil
+}
+
+// source_from_seq returns a source over the first n items of seq
+func source_from_seq[T any](seq iter.Seq[T], n int) (source[T], error) {
+	if n < 0 {
+		return source[T]{}, errors.New("n must be greater than or equal to 0")
+	} else if seq == nil {
+		return source[T]{}, errors.New("seq must not be nil")
+	}
+	return source[T]{n: n, seq: seq}, nil
+}
+
+// fill puts the items at indices into buffer
+func (s *source[T]) fill(buffer []T, indices []int) {
+	if s.at != nil {
+		for buff_idx, data_idx := range indices {
+			buffer[buff_idx] = s.at(data_idx)
+		}
+		return
+	}
+	if s.seq != nil {
+		s.read_seq()
+	}
+	fill_buffer(buffer, s.data, indices)
+}
+
+// read_seq reads the first n items of seq into data. It panics if seq gives fewer than
+// n items, as the generator has already promised that many.
+func (s *source[T]) read_seq() {
+	data := make([]T, 0, s.n)
+	if s.n > 0 {
+		for item := range s.seq {
+			data = append(data, item)
+			if len(data) == s.n {
+				break
+			}
+		}
+	}
+	if len(data) < s.n {
+		panic("gocombinatorics: seq gave fewer items than n")
+	}
+	s.data, s.seq = data, nil
+}
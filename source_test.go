@@ -0,0 +1,163 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+// source_generators makes each of the generators that take an accessor or iter.Seq, over
+// data, in each of the ways it can be made
+func source_generators(data []string, k int) map[string]func() (CombinationLike[string], error) {
+	n := len(data)
+	at := func(i int) string { return data[i] }
+	seq := slices.Values(data)
+	return map[string]func() (CombinationLike[string], error){
+		"Combinations":                        func() (CombinationLike[string], error) { return NewCombinations(data, k) },
+		"Combinations no copy":                func() (CombinationLike[string], error) { return NewCombinations(data, k, WithNoCopy()) },
+		"CombinationsFromFunc":                func() (CombinationLike[string], error) { return NewCombinationsFromFunc(n, at, k) },
+		"CombinationsFromSeq":                 func() (CombinationLike[string], error) { return NewCombinationsFromSeq(seq, n, k) },
+		"Permutations":                        func() (CombinationLike[string], error) { return NewPermutations(data, k) },
+		"Permutations no copy":                func() (CombinationLike[string], error) { return NewPermutations(data, k, WithNoCopy()) },
+		"PermutationsFromFunc":                func() (CombinationLike[string], error) { return NewPermutationsFromFunc(n, at, k) },
+		"PermutationsFromSeq":                 func() (CombinationLike[string], error) { return NewPermutationsFromSeq(seq, n, k) },
+		"CombinationsWithReplacement":         func() (CombinationLike[string], error) { return NewCombinationsWithReplacement(data, k) },
+		"CombinationsWithReplacement no copy": func() (CombinationLike[string], error) { return NewCombinationsWithReplacement(data, k, WithNoCopy()) },
+		"CombinationsWithReplacementFromFunc": func() (CombinationLike[string], error) { return NewCombinationsWithReplacementFromFunc(n, at, k) },
+		"CombinationsWithReplacementFromSeq":  func() (CombinationLike[string], error) { return NewCombinationsWithReplacementFromSeq(seq, n, k) },
+	}
+}
+
+func TestGeneratorsFromFuncAndSeq(t *testing.T) {
+	data := []string{"a", "b", "c", "d", "e", "f"}
+	for k := 1; k <= 4; k++ {
+		for desc, make_gen := range source_generators(data, k) {
+			t.Run(fmt.Sprintf("%v k=%v", desc, k), func(t *testing.T) {
+				g, err := make_gen()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for g.Next() {
+					want := make([]string, k)
+					for i, ind := range g.Indices() {
+						want[i] = data[ind]
+					}
+					// Permutations.Items() gives all n items, with the chosen k first
+					if got := g.Items()[:k]; !slices.Equal(got, want) {
+						t.Fatalf("Items() = %v, want %v", got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestWithNoCopy(t *testing.T) {
+	data := []int{1, 2, 3}
+	copied, _ := NewCombinations(data, 3)
+	shared, _ := NewCombinations(data, 3, WithNoCopy())
+	copied.Next()
+	shared.Next()
+	data[0] = 100
+	if got := copied.Items(); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("Items() = %v, want the copy [1 2 3]", got)
+	}
+	if got := shared.Items(); !slices.Equal(got, []int{100, 2, 3}) {
+		t.Errorf("Items() with WithNoCopy() = %v, want [100 2 3]", got)
+	}
+}
+
+func TestFromSeqReadsLazily(t *testing.T) {
+	reads := 0
+	seq := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			reads++
+			if !yield(i * 10) {
+				return
+			}
+		}
+	}
+	c, err := NewCombinationsFromSeq(seq, 5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for c.Next() {
+	}
+	if reads != 0 {
+		t.Errorf("seq was read %v times going through the indices, want 0", reads)
+	}
+	c.Unrank(big.NewInt(0))
+	c.Items()
+	c.Items()
+	// Only the first n items of a never ending seq are read, and only once
+	if reads != 5 {
+		t.Errorf("seq was read %v times, want 5", reads)
+	}
+	if got := c.Items(); !slices.Equal(got, []int{0, 10}) {
+		t.Errorf("Items() = %v, want [0 10]", got)
+	}
+
+	short, _ := NewPermutationsFromSeq(slices.Values([]int{1, 2}), 3, 2)
+	short.Next()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Items() with a seq shorter than n did not panic")
+		}
+	}()
+	short.Items()
+}
+
+func TestFromFuncHugeVirtualSet(t *testing.T) {
+	n := 1_000_000_000
+	calls := 0
+	at := func(i int) int {
+		calls++
+		return i
+	}
+	c, err := NewCombinationsFromFunc(n, at, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.BigLen(), nchoosek(uint64(n), 2); got.Cmp(want) != 0 {
+		t.Errorf("BigLen() = %v, want %v", got, want)
+	}
+	last := new(big.Int).Sub(c.BigLen(), big.NewInt(1))
+	c.Unrank(last)
+	if got := c.Items(); !slices.Equal(got, []int{n - 2, n - 1}) {
+		t.Errorf("Items() of the last combination = %v, want [%v %v]", got, n-2, n-1)
+	}
+
+	// Permutations keeps n indices of its own, so give it fewer
+	p, _ := NewPermutationsFromFunc(1_000_000, at, 3)
+	p.Next()
+	if got := p.Items()[:3]; !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("Items()[:3] = %v, want [0 1 2]", got)
+	}
+}
+
+func TestFromFuncAndSeqErrors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		err  error
+	}{
+		{desc: "nil at", err: second(NewCombinationsFromFunc[int](3, nil, 2))},
+		{desc: "nil seq", err: second(NewPermutationsFromSeq[int](nil, 3, 2))},
+		{desc: "n = 0", err: second(NewCombinationsWithReplacementFromFunc(0, func(i int) int { return i }, 2))},
+		{desc: "k > n", err: second(NewCombinationsFromSeq(slices.Values([]int{1, 2}), 2, 3))},
+		{desc: "k > n for permutations", err: second(NewPermutationsFromFunc(2, func(i int) int { return i }, 3))},
+		{desc: "n < 0 and k < 0", err: second(NewPermutationsFromFunc(-3, func(i int) int { return i }, -5))},
+		{desc: "n < 0 from a seq", err: second(NewPermutationsFromSeq(slices.Values([]int{1}), -1, 0))},
+		{desc: "k < 0 for permutations", err: second(NewPermutationsFromFunc(3, func(i int) int { return i }, -1))},
+		{desc: "k < 0 for permutations of a slice", err: second(NewPermutations([]int{1, 2}, -1))},
+		{desc: "n < 0 for combinations", err: second(NewCombinationsFromFunc(-1, func(i int) int { return i }, 1))},
+		{desc: "n < 0 with replacement", err: second(NewCombinationsWithReplacementFromSeq(slices.Values([]int{1}), -2, 1))},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if tC.err == nil {
+				t.Errorf("got no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/natemcintosh/gocombinatorics/count"
+)
+
+// SpacedCombinations will give you the indices of all combinations of an input
+// slice/array of length n, choosing k elements, where any two chosen indices differ by at
+// least minGap. If circular is true, the indices are treated as sitting on a circle, so
+// the last chosen index must also be at least minGap away from the first one, going
+// around through n-1 -> 0.
+//
+// A minGap of 1 gives you the same output as `Combinations`. The combinations are
+// generated directly, nothing is filtered out, so this stays cheap even when most
+// combinations would violate the spacing.
+// SpacedCombinations meets the `CombinationLike` interface
+type SpacedCombinations[T any] struct {
+	data     []T
+	n, k     int
+	gap      int
+	circular bool
+	isfirst  bool
+	inds     []int
+	length
+	buffer []T
+}
+
+// NewSpacedCombinations creates a new SpacedCombinations object.
+func NewSpacedCombinations[T any](input_data []T, k int, minGap int, circular bool) (*SpacedCombinations[T], error) {
+	data := make([]T, len(input_data))
+	copy(data, input_data)
+	n := len(input_data)
+
+	// Check for cases where we can't do combinations
+	if k > n {
+		return nil, errors.New("k must be less than or equal to len(input_data)")
+	} else if n <= 0 {
+		return nil, errors.New("len(input_data) must be greater than 0")
+	} else if k <= 0 {
+		return nil, errors.New("k must be greater than 0")
+	} else if minGap <= 0 {
+		return nil, errors.New("minGap must be greater than 0")
+	}
+
+	total := n_spaced_combinations(n, k, minGap, circular)
+	if total.Sign() == 0 {
+		return nil, errors.New("no combinations of length k have every index at least minGap apart")
+	}
+
+	inds := make([]int, k)
+
+	// Make the buffer slice
+	buffer := make([]T, k)
+	fill_buffer(buffer, data, inds)
+
+	return &SpacedCombinations[T]{
+		data:     data,
+		n:        n,
+		k:        k,
+		gap:      minGap,
+		circular: circular,
+		isfirst:  true,
+		inds:     inds,
+		length:   length_from_big(total),
+		buffer:   buffer,
+	}, nil
+}
+
+// Next will move to the next set of spaced indices, in lexicographic order, until it
+// reaches the end, at which point it will return false.
+func (c *SpacedCombinations[T]) Next() bool {
+	// The first combination packs everything as far left as possible
+	if c.isfirst {
+		for i := 0; i < c.k; i++ {
+			c.inds[i] = i * c.gap
+		}
+		c.isfirst = false
+		return true
+	}
+
+	// Find the right-most index that can still be moved to the right
+	what_is_i := -1
+	for i := c.k - 1; i >= 0; i-- {
+		if c.inds[i] < c.max_at(i) {
+			what_is_i = i
+			break
+		}
+	}
+	if what_is_i == -1 {
+		return false
+	}
+
+	// Move it, and pack everything after it as far left as possible
+	c.inds[what_is_i]++
+	for j := what_is_i + 1; j < c.k; j++ {
+		c.inds[j] = c.inds[j-1] + c.gap
+	}
+	return true
+}
+
+// max_at is the largest value the index at position i can take, given the current
+// first index.
+func (c *SpacedCombinations[T]) max_at(i int) int {
+	limit := c.n - 1
+	// Going round the circle, the last index has to stay minGap away from the first
+	if c.circular && c.k > 1 && i > 0 && c.inds[0]+c.n-c.gap < limit {
+		limit = c.inds[0] + c.n - c.gap
+	}
+	return limit - (c.k-1-i)*c.gap
+}
+
+func (c *SpacedCombinations[T]) LenInds() int {
+	return c.k
+}
+
+func (c *SpacedCombinations[T]) Indices() []int {
+	return c.inds
+}
+
+// Items is how you get the items in this combination. You iterate with `c.Next()`, and
+// then get the combination with `c.Items()`. The data in the slice returned will be
+// overwritten every iteration. If you need to keep the data from each iteration, be
+// sure to make a copy.
+func (c *SpacedCombinations[T]) Items() []T {
+	fill_buffer(c.buffer, c.data, c.inds)
+	return c.buffer
+}
+
+// Rank returns the position of the current combination in the order that `Next()`
+// generates them, starting at 0.
+func (c *SpacedCombinations[T]) Rank() *big.Int {
+	if c.isfirst {
+		return big.NewInt(0)
+	}
+	if !c.circular || c.k == 1 {
+		return rank_combination(shift_down(c.inds, 0, c.gap), c.n-(c.k-1)*(c.gap-1))
+	}
+
+	// Count everything that starts with a smaller first index, then rank the rest of
+	// the indices among those that share this first index.
+	first := c.inds[0]
+	rank := c.n_circular_before(first)
+	base, length := c.circular_range(first)
+	rest := shift_down(c.inds[1:], base, c.gap)
+	rank.Add(rank, rank_combination(rest, length-(c.k-2)*(c.gap-1)))
+	return rank
+}
+
+// Unrank moves the SpacedCombinations to the combination at position `rank`, so that
+// `Indices()` and `Items()` reflect it. Calling `Next()` afterwards carries on from
+// there.
+func (c *SpacedCombinations[T]) Unrank(rank *big.Int) error {
+	if rank.Sign() < 0 || c.cmp(rank) <= 0 {
+		return errRankOutOfRange
+	}
+	c.isfirst = false
+
+	if !c.circular || c.k == 1 {
+		unrank_combination(rank, c.n-(c.k-1)*(c.gap-1), c.inds)
+		shift_up(c.inds, 0, c.gap)
+		return nil
+	}
+
+	// Find the largest first index with fewer than `rank` combinations before it
+	lo, hi := 0, c.n-1-(c.k-1)*c.gap
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if c.n_circular_before(mid).Cmp(rank) <= 0 {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	c.inds[0] = lo
+	rest_rank := new(big.Int).Sub(rank, c.n_circular_before(lo))
+	base, length := c.circular_range(lo)
+	unrank_combination(rest_rank, length-(c.k-2)*(c.gap-1), c.inds[1:])
+	shift_up(c.inds[1:], base, c.gap)
+	return nil
+}
+
+// circular_range gives the start and length of the range the 2nd through k-th indices
+// may occupy, when the first index is `first`.
+func (c *SpacedCombinations[T]) circular_range(first int) (int, int) {
+	base := first + c.gap
+	limit := c.n - 1
+	if first+c.n-c.gap < limit {
+		limit = first + c.n - c.gap
+	}
+	return base, limit - base + 1
+}
+
+// n_circular_before counts the circular spaced combinations whose first index is less
+// than `first`.
+func (c *SpacedCombinations[T]) n_circular_before(first int) *big.Int {
+	n, k, d := c.n, c.k, c.gap
+
+	// While the first index is below d-1, the remaining indices are squeezed in from
+	// both ends, and the range they sit in has the same length each time.
+	squeezed := first
+	if squeezed > d-1 {
+		squeezed = d - 1
+	}
+	total := count.Binomial(n-2*d+1-(k-2)*(d-1), k-1)
+	total.Mul(total, big.NewInt(int64(squeezed)))
+
+	// After that the range only shrinks from the left, so the counts telescope
+	// (hockey-stick identity).
+	if first > d-1 {
+		a := n - d - (k-2)*(d-1)
+		total.Add(total, count.Binomial(a-(d-1)+1, k))
+		total.Sub(total, count.Binomial(a-first+1, k))
+	}
+	return total
+}
+
+// shift_down maps indices that are at least gap apart, starting at base, onto plain
+// strictly increasing indices starting at 0. This is the bijection between spaced
+// combinations and ordinary combinations.
+func shift_down(inds []int, base int, gap int) []int {
+	result := make([]int, len(inds))
+	for t, v := range inds {
+		result[t] = v - base - t*(gap-1)
+	}
+	return result
+}
+
+// shift_up is the inverse of shift_down, and works in place.
+func shift_up(inds []int, base int, gap int) {
+	for t := range inds {
+		inds[t] += base + t*(gap-1)
+	}
+}
+
+// n_spaced_combinations returns the number of ways to choose k of n indices such that
+// every chosen pair is at least gap apart.
+// Laid out on a line, squeezing the gaps out gives an ordinary combination:
+// nchoosek(n - (k-1)*(gap-1), k).
+// Laid out on a circle, it is n/k * nchoosek(n - k*(gap-1) - 1, k-1).
+func n_spaced_combinations(n, k, gap int, circular bool) *big.Int {
+	if !circular || k == 1 {
+		return count.Binomial(n-(k-1)*(gap-1), k)
+	}
+	if n < k*gap {
+		return big.NewInt(0)
+	}
+	result := count.Binomial(n-k*(gap-1)-1, k-1)
+	result.Mul(result, big.NewInt(int64(n)))
+	return result.Div(result, big.NewInt(int64(k)))
+}
@@ -0,0 +1,238 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// spaced_by_filtering is the slow way to get spaced combinations: generate every
+// combination and throw away the ones that are too close together.
+func spaced_by_filtering(n, k, gap int, circular bool) [][]int {
+	c, _ := NewCombinations(stepped_range(0, n, 1), k)
+	result := make([][]int, 0)
+	for c.Next() {
+		inds := c.Indices()
+		ok := true
+		for i := 1; i < k; i++ {
+			if inds[i]-inds[i-1] < gap {
+				ok = false
+			}
+		}
+		if circular && k > 1 && inds[0]+n-inds[k-1] < gap {
+			ok = false
+		}
+		if ok {
+			next_set_of_indices := make([]int, k)
+			copy(next_set_of_indices, inds)
+			result = append(result, next_set_of_indices)
+		}
+	}
+	return result
+}
+
+func TestNewSpacedCombinationsErrors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		n        int
+		k        int
+		gap      int
+		circular bool
+	}{
+		{desc: "n <= 0", n: 0, k: 1, gap: 1},
+		{desc: "k <= 0", n: 3, k: 0, gap: 1},
+		{desc: "k > n", n: 3, k: 4, gap: 1},
+		{desc: "gap <= 0", n: 3, k: 2, gap: 0},
+		{desc: "gap too large", n: 5, k: 3, gap: 3},
+		{desc: "gap too large circular", n: 8, k: 3, gap: 3, circular: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			data := stepped_range(0, tC.n, 1)
+			_, got_err := NewSpacedCombinations(data, tC.k, tC.gap, tC.circular)
+			if got_err == nil {
+				t.Errorf("NewSpacedCombinations(%d, %d, %d, %v) did not return an error", tC.n, tC.k, tC.gap, tC.circular)
+			}
+		})
+	}
+}
+
+func TestSpacedCombinationsNext(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		n        int
+		k        int
+		gap      int
+		circular bool
+		want     [][]int
+	}{
+		{
+			desc: "n = 5, k = 2, gap = 2",
+			n:    5,
+			k:    2,
+			gap:  2,
+			want: [][]int{
+				{0, 2},
+				{0, 3},
+				{0, 4},
+				{1, 3},
+				{1, 4},
+				{2, 4},
+			},
+		},
+		{
+			desc:     "n = 5, k = 2, gap = 2, circular",
+			n:        5,
+			k:        2,
+			gap:      2,
+			circular: true,
+			want: [][]int{
+				{0, 2},
+				{0, 3},
+				{1, 3},
+				{1, 4},
+				{2, 4},
+			},
+		},
+		{
+			desc:     "n = 6, k = 3, gap = 2, circular",
+			n:        6,
+			k:        3,
+			gap:      2,
+			circular: true,
+			want: [][]int{
+				{0, 2, 4},
+				{1, 3, 5},
+			},
+		},
+		{
+			desc:     "n = 4, k = 1, gap = 3, circular",
+			n:        4,
+			k:        1,
+			gap:      3,
+			circular: true,
+			want: [][]int{
+				{0},
+				{1},
+				{2},
+				{3},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			data := stepped_range(0, tC.n, 1)
+			c, err := NewSpacedCombinations(data, tC.k, tC.gap, tC.circular)
+			if err != nil {
+				t.Fatalf("NewSpacedCombinations() = %v, want nil", err)
+			}
+			got := make([][]int, 0)
+			for c.Next() {
+				next_set_of_indices := make([]int, len(c.Indices()))
+				copy(next_set_of_indices, c.Items())
+				got = append(got, next_set_of_indices)
+			}
+
+			if !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("SpacedCombinations(%d, %d, %d, %v) = %v, want %v", tC.n, tC.k, tC.gap, tC.circular, got, tC.want)
+			}
+		})
+	}
+}
+
+func TestSpacedCombinationsAgainstFiltering(t *testing.T) {
+	for n := 1; n <= 12; n++ {
+		for k := 1; k <= n; k++ {
+			for gap := 1; gap <= 4; gap++ {
+				for _, circular := range []bool{false, true} {
+					want := spaced_by_filtering(n, k, gap, circular)
+					run_name := fmt.Sprintf("n=%v, k=%v, gap=%v, circular=%v", n, k, gap, circular)
+					t.Run(run_name, func(t *testing.T) {
+						c, err := NewSpacedCombinations(stepped_range(0, n, 1), k, gap, circular)
+						if len(want) == 0 {
+							if err == nil {
+								t.Errorf("Expected an error when there are no valid combinations")
+							}
+							return
+						}
+						if err != nil {
+							t.Fatalf("NewSpacedCombinations() = %v, want nil", err)
+						}
+						if c.BigLen().Cmp(big.NewInt(int64(len(want)))) != 0 {
+							t.Errorf("BigLen() = %v, want %v", c.BigLen(), len(want))
+						}
+
+						// Walk through, checking each rank, and that unranking gets us back
+						// to the same place
+						rank := 0
+						u, _ := NewSpacedCombinations(stepped_range(0, n, 1), k, gap, circular)
+						for c.Next() {
+							if !reflect.DeepEqual(c.Indices(), want[rank]) {
+								t.Fatalf("combination %v = %v, want %v", rank, c.Indices(), want[rank])
+							}
+							if got := c.Rank(); got.Cmp(big.NewInt(int64(rank))) != 0 {
+								t.Errorf("Rank() of %v = %v, want %v", c.Indices(), got, rank)
+							}
+							if err := u.Unrank(big.NewInt(int64(rank))); err != nil {
+								t.Fatalf("Unrank(%v) = %v, want nil", rank, err)
+							}
+							if !reflect.DeepEqual(u.Indices(), want[rank]) {
+								t.Errorf("Unrank(%v) = %v, want %v", rank, u.Indices(), want[rank])
+							}
+							rank++
+						}
+						if rank != len(want) {
+							t.Errorf("Saw %v combinations, want %v", rank, len(want))
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+func TestSpacedCombinationsUnrankThenNext(t *testing.T) {
+	c, err := NewSpacedCombinations(stepped_range(0, 20, 1), 4, 3, true)
+	if err != nil {
+		t.Fatalf("NewSpacedCombinations() = %v, want nil", err)
+	}
+	if err := c.Unrank(big.NewInt(100)); err != nil {
+		t.Fatalf("Unrank() = %v, want nil", err)
+	}
+	for i := int64(101); c.Next(); i++ {
+		if got := c.Rank(); got.Cmp(big.NewInt(i)) != 0 {
+			t.Fatalf("Rank() after Unrank and Next = %v, want %v", got, i)
+		}
+	}
+	if err := c.Unrank(c.BigLen()); err == nil {
+		t.Errorf("Unrank(BigLen()) did not return an error")
+	}
+}
+
+func BenchmarkSpacedCombinationsNext(b *testing.B) {
+	benchmarks := []struct {
+		desc     string
+		n        int
+		k        int
+		gap      int
+		circular bool
+	}{
+		{desc: "n = 100, k = 3, gap = 30", n: 100, k: 3, gap: 30},
+		{desc: "n = 100, k = 3, gap = 30, circular", n: 100, k: 3, gap: 30, circular: true},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.desc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				data := stepped_range(0, bm.n, 1)
+				c, err := NewSpacedCombinations(data, bm.k, bm.gap, bm.circular)
+				if err != nil {
+					b.Errorf("NewSpacedCombinations() = %v, want nil", err)
+				}
+				for c.Next() {
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+package species
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"slices"
+)
+
+// Boltzmann picks random structures of roughly a given size from a species. It uses a
+// Boltzmann sampler: with a parameter x, every structure of size n comes up with
+// probability proportional to x^n / n!. So all structures of the same size are equally
+// likely, and x sets how big they tend to be. Create it with `NewBoltzmann`, which picks
+// x for you.
+//
+// Building each structure only needs random choices at each combinator, weighted by the
+// values of the generating functions at x, rather than the exact counts for every size.
+// This makes it much faster than `Structures.Random()` for big structures, at the cost
+// of only controlling the size approximately.
+type Boltzmann struct {
+	s     *Species
+	x     float64
+	nodes []*Species
+	value map[*Species]float64
+	deriv map[*Species]float64
+	// coeffs holds count / n! for the sizes of each Restrict that need it
+	coeffs map[*Species][]float64
+	t      *table
+}
+
+// max_oracle_iterations limits how long the values of recursive species are iterated
+// for. Close to the largest x a species allows, they converge slowly.
+const max_oracle_iterations = 10_000
+
+// NewBoltzmann creates a Boltzmann sampler for s, with x chosen so that the average size
+// of the structures it picks is size. If no x gives an average that big, x is set as
+// close as it can be to the largest x that works.
+func NewBoltzmann(s *Species, size float64) (*Boltzmann, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	if !(size > 0) {
+		return nil, errors.New("size must be greater than 0")
+	}
+	b := &Boltzmann{
+		s:      s,
+		value:  make(map[*Species]float64),
+		deriv:  make(map[*Species]float64),
+		coeffs: make(map[*Species][]float64),
+		t:      new_table(),
+	}
+	b.collect_nodes()
+	if err := b.restrict_coeffs(); err != nil {
+		return nil, err
+	}
+
+	// The average size goes up with x, so search for the x that gives size. Double x from
+	// 1 until it is too big or the values become infinite, then bisect.
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 1000 && b.evaluate(hi) && b.expected_size(hi) <= size; i++ {
+		lo, hi = hi, 2*hi
+	}
+	for i := 0; i < 64; i++ {
+		x := (lo + hi) / 2
+		if b.evaluate(x) && b.expected_size(x) <= size {
+			lo = x
+		} else {
+			hi = x
+		}
+	}
+	// Leave the values at x in place for sampling
+	if lo == 0 || !b.evaluate(lo) {
+		return nil, errors.New("could not find a value of x that works for this species")
+	}
+	b.x = lo
+	return b, nil
+}
+
+// collect_nodes lists every part of the species, children before their parents where
+// possible, so evaluate converges quickly when there is no recursion
+func (b *Boltzmann) collect_nodes() {
+	seen := make(map[*Species]bool)
+	var walk func(s *Species)
+	walk = func(s *Species) {
+		if seen[s] {
+			return
+		}
+		seen[s] = true
+		for _, child := range s.children {
+			walk(child)
+		}
+		if s.rest != nil {
+			walk(s.rest)
+		}
+		if s.seq != nil {
+			walk(s.seq)
+		}
+		b.nodes = append(b.nodes, s)
+	}
+	walk(b.s)
+}
+
+// restrict_coeffs works out the exact coefficients each Restrict needs: all of the ones
+// in its range if it has an upper limit, or the ones below its range if it does not.
+func (b *Boltzmann) restrict_coeffs() error {
+	for _, s := range b.nodes {
+		if s.kind != kind_restrict {
+			continue
+		}
+		top := s.hi
+		if s.hi == math.MaxInt {
+			top = s.lo - 1
+		}
+		coeffs := make([]float64, top+1)
+		factorial := big.NewInt(1)
+		for n := 0; n <= top; n++ {
+			if n > 0 {
+				factorial.Mul(factorial, big.NewInt(int64(n)))
+			}
+			c := b.t.count(s.children[0], n)
+			if b.t.err != nil {
+				return b.t.err
+			}
+			coeffs[n], _ = new(big.Float).Quo(new(big.Float).SetInt(c), new(big.Float).SetInt(factorial)).Float64()
+		}
+		b.coeffs[s] = coeffs
+	}
+	return nil
+}
+
+// evaluate works out the value of the exponential generating function of every part of
+// the species at x, along with its derivative. Recursive species are solved by
+// iterating from 0 until nothing changes. It returns false if x is too big for the
+// species, so that some value is infinite.
+func (b *Boltzmann) evaluate(x float64) bool {
+	for _, s := range b.nodes {
+		b.value[s], b.deriv[s] = 0, 0
+	}
+	for iter := 0; iter < max_oracle_iterations; iter++ {
+		changed := false
+		for _, s := range b.nodes {
+			v, d := b.evaluate_node(s, x)
+			if math.IsNaN(v) || math.IsInf(v, 0) || math.IsNaN(d) || math.IsInf(d, 0) {
+				return false
+			}
+			if math.Abs(v-b.value[s]) > 1e-14*math.Abs(v) || math.Abs(d-b.deriv[s]) > 1e-14*math.Abs(d) {
+				changed = true
+			}
+			b.value[s], b.deriv[s] = v, d
+		}
+		if !changed {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate_node works out the value and derivative of one part of the species at x,
+// from the current values of its children
+func (b *Boltzmann) evaluate_node(s *Species, x float64) (float64, float64) {
+	switch s.kind {
+	case kind_atom:
+		return x, 1
+	case kind_empty:
+		return 1, 0
+	case kind_union:
+		v, d := 0.0, 0.0
+		for _, alternative := range s.children {
+			v += b.value[alternative]
+			d += b.deriv[alternative]
+		}
+		return v, d
+	case kind_product:
+		if len(s.children) == 0 {
+			return 1, 0
+		}
+		first, rest := s.children[0], s.rest
+		return b.value[first] * b.value[rest], b.deriv[first]*b.value[rest] + b.value[first]*b.deriv[rest]
+	case kind_seq, kind_cycle:
+		a, da := b.value[s.children[0]], b.deriv[s.children[0]]
+		if a >= 1 {
+			return math.Inf(1), math.Inf(1)
+		}
+		if s.kind == kind_seq {
+			return 1 / (1 - a), da / ((1 - a) * (1 - a))
+		}
+		return -math.Log1p(-a), da / (1 - a)
+	case kind_set:
+		a, da := b.value[s.children[0]], b.deriv[s.children[0]]
+		return math.Exp(a), da * math.Exp(a)
+	case kind_restrict:
+		v, d := 0.0, 0.0
+		if s.hi == math.MaxInt {
+			// Everything, less what is below the range
+			v, d = b.value[s.children[0]], b.deriv[s.children[0]]
+		}
+		for n, c := range b.coeffs[s] {
+			if n < s.lo && s.hi != math.MaxInt {
+				continue
+			}
+			term, dterm := c*math.Pow(x, float64(n)), 0.0
+			if n > 0 {
+				dterm = c * float64(n) * math.Pow(x, float64(n-1))
+			}
+			if s.hi == math.MaxInt {
+				v, d = v-term, d-dterm
+			} else {
+				v, d = v+term, d+dterm
+			}
+		}
+		return math.Max(v, 0), math.Max(d, 0)
+	case kind_recursive:
+		return b.value[s.children[0]], b.deriv[s.children[0]]
+	}
+	return 0, 0
+}
+
+// expected_size is the average size of a structure at x, x A'(x) / A(x). evaluate(x)
+// must have been called first.
+func (b *Boltzmann) expected_size(x float64) float64 {
+	return x * b.deriv[b.s] / b.value[b.s]
+}
+
+// X is the parameter of the sampler
+func (b *Boltzmann) X() float64 {
+	return b.x
+}
+
+// ExpectedSize is the average size of the structures the sampler picks, before any
+// limits on their size
+func (b *Boltzmann) ExpectedSize() float64 {
+	return b.expected_size(b.x)
+}
+
+// Sample picks random structures until it finds one with at least lo and at most hi
+// labels, and returns it. Every structure of the same size is equally likely. A
+// structure is given up on as soon as it gets too big, so a bad guess is cheap, but if
+// lo and hi are far from `ExpectedSize()` it may take many tries.
+func (b *Boltzmann) Sample(lo, hi int, src rand.Source) (*Structure, error) {
+	if lo < 0 || hi < lo {
+		return nil, errors.New("Sample needs 0 <= lo <= hi")
+	}
+	// Make sure there is something to find
+	found := false
+	for n := lo; n <= hi && !found; n++ {
+		found = b.t.count(b.s, n).Sign() > 0
+		if b.t.err != nil {
+			return nil, b.t.err
+		}
+	}
+	if !found {
+		return nil, errors.New("there are no structures with a size in the range [lo, hi]")
+	}
+
+	rng := rand.New(src)
+	for {
+		g := &generator{b: b, rng: rng, limit: hi}
+		st := g.generate(b.s)
+		if st == nil || g.size < lo {
+			continue
+		}
+		// Give the labels out at random, then put the parts back in order
+		perm := rng.Perm(g.size)
+		relabel(st, perm)
+		return st, nil
+	}
+}
+
+// generator builds one structure for a Boltzmann sampler. Atoms are labelled in the
+// order they are made, and size is how many have been made so far.
+type generator struct {
+	b     *Boltzmann
+	rng   *rand.Rand
+	limit int
+	size  int
+}
+
+// generate builds a random structure from s, returning nil if it gets too big
+func (g *generator) generate(s *Species) *Structure {
+	if g.size > g.limit {
+		return nil
+	}
+	b := g.b
+	switch s.kind {
+	case kind_atom:
+		g.size++
+		if g.size > g.limit {
+			return nil
+		}
+		return &Structure{Species: s, Label: g.size - 1}
+	case kind_empty:
+		return &Structure{Species: s}
+	case kind_union:
+		u := g.rng.Float64() * b.value[s]
+		for _, alternative := range s.children {
+			u -= b.value[alternative]
+			if u < 0 {
+				return g.generate(alternative)
+			}
+		}
+		return g.generate(s.children[len(s.children)-1])
+	case kind_product:
+		st := &Structure{Species: s}
+		for _, factor := range s.children {
+			part := g.generate(factor)
+			if part == nil {
+				return nil
+			}
+			st.Parts = append(st.Parts, part)
+		}
+		return st
+	case kind_seq, kind_set, kind_cycle:
+		a := b.value[s.children[0]]
+		var k int
+		switch s.kind {
+		case kind_seq:
+			// Geometric: k components with probability a^k (1 - a)
+			for g.rng.Float64() < a {
+				k++
+			}
+		case kind_set:
+			// Poisson with mean a
+			k = g.draw(math.Exp(-a), func(k int) float64 { return a / float64(k) }, 0)
+		case kind_cycle:
+			// Logarithmic: k >= 1 components with probability a^k / (k log(1/(1-a)))
+			k = g.draw(a/-math.Log1p(-a), func(k int) float64 { return a * float64(k-1) / float64(k) }, 1)
+		}
+		st := &Structure{Species: s}
+		for i := 0; i < k; i++ {
+			part := g.generate(s.children[0])
+			if part == nil {
+				return nil
+			}
+			st.Parts = append(st.Parts, part)
+		}
+		return st
+	case kind_restrict:
+		if s.hi != math.MaxInt {
+			return g.generate_exact(s)
+		}
+		// Try again until it is big enough
+		for {
+			start := g.size
+			st := g.generate(s.children[0])
+			if st == nil || g.size-start >= s.lo {
+				return st
+			}
+			g.size = start
+		}
+	case kind_recursive:
+		return g.generate(s.children[0])
+	}
+	return nil
+}
+
+// draw picks k >= from, where the probability of from is first, and the probability of
+// each k after that is ratio(k) times the one before
+func (g *generator) draw(first float64, ratio func(k int) float64, from int) int {
+	u := g.rng.Float64()
+	k, p := from, first
+	for u >= p && p > 0 {
+		u -= p
+		k++
+		p *= ratio(k)
+	}
+	return k
+}
+
+// generate_exact builds a structure for a Restrict with an upper limit. Its size is
+// picked using the exact coefficients, and then one of the structures of that size is
+// picked uniformly.
+func (g *generator) generate_exact(s *Species) *Structure {
+	weights := make([]float64, 0)
+	total := 0.0
+	for n := s.lo; n <= s.hi; n++ {
+		w := g.b.coeffs[s][n] * math.Pow(g.b.x, float64(n))
+		weights = append(weights, w)
+		total += w
+	}
+	u := g.rng.Float64() * total
+	n := s.lo
+	for i, w := range weights {
+		if u < w {
+			n = s.lo + i
+			break
+		}
+		u -= w
+	}
+	if g.size+n > g.limit {
+		g.size += n
+		return nil
+	}
+
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = g.size + i
+	}
+	g.size += n
+	rank := random_below(g.rng, g.b.t.count(s.children[0], n))
+	return g.b.t.unrank(s.children[0], labels, rank)
+}
+
+// relabel sends each label l to perm[l], then puts the components of Sets and Cycles
+// back in order by their smallest labels. It returns the smallest label in st.
+func relabel(st *Structure, perm []int) int {
+	if st.Species.kind == kind_atom {
+		st.Label = perm[st.Label]
+		return st.Label
+	}
+	smallest := math.MaxInt
+	first := 0
+	mins := make([]int, len(st.Parts))
+	for i, part := range st.Parts {
+		mins[i] = relabel(part, perm)
+		if mins[i] < smallest {
+			smallest, first = mins[i], i
+		}
+	}
+	switch st.Species.kind {
+	case kind_set:
+		// Sort the components by their smallest labels
+		for i := 1; i < len(st.Parts); i++ {
+			for j := i; j > 0 && mins[j] < mins[j-1]; j-- {
+				mins[j], mins[j-1] = mins[j-1], mins[j]
+				st.Parts[j], st.Parts[j-1] = st.Parts[j-1], st.Parts[j]
+			}
+		}
+	case kind_cycle:
+		st.Parts = slices.Concat(st.Parts[first:], st.Parts[:first])
+	}
+	return smallest
+}
@@ -0,0 +1,134 @@
+package species
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestNewBoltzmannExpectedSize(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		species *Species
+		size    float64
+	}{
+		{desc: "permutations", species: Set(Cycle(Atom())), size: 50},
+		{desc: "set partitions", species: Set(nonEmptySets()), size: 20},
+		{desc: "rooted trees", species: rootedTrees(), size: 30},
+		{desc: "perfect matchings", species: Set(Restrict(Set(Atom()), 2, 2)), size: 10},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			b, err := NewBoltzmann(tC.species, tC.size)
+			if err != nil {
+				t.Fatalf("NewBoltzmann() = %v, want nil", err)
+			}
+			if got := b.ExpectedSize(); math.Abs(got-tC.size) > 1e-3*tC.size {
+				t.Errorf("ExpectedSize() = %v, want %v", got, tC.size)
+			}
+		})
+	}
+}
+
+func TestNewBoltzmannKnownX(t *testing.T) {
+	// Permutations have generating function 1/(1-x), with average size x/(1-x)
+	b, _ := NewBoltzmann(Set(Cycle(Atom())), 9)
+	if got := b.X(); math.Abs(got-0.9) > 1e-9 {
+		t.Errorf("X() = %v, want 0.9", got)
+	}
+	// Rooted trees have T = x e^T, with their singularity at x = 1/e
+	b, _ = NewBoltzmann(rootedTrees(), 1e9)
+	if got := b.X(); got > 1/math.E || got < 1/math.E-1e-4 {
+		t.Errorf("X() = %v, want just under %v", got, 1/math.E)
+	}
+}
+
+func TestBoltzmannSample(t *testing.T) {
+	pairs := Restrict(Set(Atom()), 2, 2)
+	testCases := []struct {
+		desc    string
+		species *Species
+		lo, hi  int
+	}{
+		{desc: "rooted trees", species: rootedTrees(), lo: 90, hi: 110},
+		{desc: "sequences of sets of pairs", species: Seq(Restrict(Set(pairs), 1, math.MaxInt)), lo: 40, hi: 60},
+		{desc: "derangements", species: Set(Restrict(Cycle(Atom()), 2, math.MaxInt)), lo: 100, hi: 100},
+		{desc: "cycles of sequences", species: Cycle(Restrict(Seq(Atom()), 1, math.MaxInt)), lo: 20, hi: 30},
+	}
+	src := rand.NewPCG(3, 4)
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			b, err := NewBoltzmann(tC.species, float64(tC.lo+tC.hi)/2)
+			if err != nil {
+				t.Fatalf("NewBoltzmann() = %v, want nil", err)
+			}
+			for i := 0; i < 20; i++ {
+				st, err := b.Sample(tC.lo, tC.hi, src)
+				if err != nil {
+					t.Fatalf("Sample() = %v, want nil", err)
+				}
+				size := st.Size()
+				if size < tC.lo || size > tC.hi {
+					t.Fatalf("Sample() has size %v, want between %v and %v", size, tC.lo, tC.hi)
+				}
+				got := labels(st)
+				slices.Sort(got)
+				for j, l := range got {
+					if j != l {
+						t.Fatalf("Sample() = %v does not use the labels 0, ..., %v", st, size-1)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBoltzmannSampleIsUniform(t *testing.T) {
+	// The structures of each size should be equally likely, and in the same canonical
+	// form as NewStructures gives
+	species := Set(Union(Cycle(Atom()), Restrict(Seq(Atom()), 2, 2)))
+	b, _ := NewBoltzmann(species, 4)
+	s, _ := NewStructures(species, 4)
+	want := make(map[string]bool)
+	for s.Next() {
+		want[s.Structure().String()] = true
+	}
+
+	src := rand.NewPCG(5, 6)
+	counts := make(map[string]int)
+	n_samples := 400 * len(want)
+	for i := 0; i < n_samples; i++ {
+		st, _ := b.Sample(4, 4, src)
+		counts[st.String()]++
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("got %v different structures, want %v", len(counts), len(want))
+	}
+	expected := float64(n_samples) / float64(len(want))
+	for st, count := range counts {
+		if !want[st] {
+			t.Errorf("%v is not a structure NewStructures gives", st)
+		}
+		if math.Abs(float64(count)-expected) > 0.2*expected {
+			t.Errorf("%v came up %v times, want about %v", st, count, expected)
+		}
+	}
+}
+
+func TestBoltzmannErrors(t *testing.T) {
+	if _, err := NewBoltzmann(Atom(), 0); err == nil {
+		t.Errorf("NewBoltzmann() with size 0 did not return an error")
+	}
+	if _, err := NewBoltzmann(Recursive(), 5); err == nil {
+		t.Errorf("NewBoltzmann() with an undefined species did not return an error")
+	}
+	b, _ := NewBoltzmann(Set(Restrict(Set(Atom()), 2, 2)), 5)
+	src := rand.NewPCG(1, 1)
+	if _, err := b.Sample(5, 5, src); err == nil {
+		t.Errorf("Sample() with only odd sizes did not return an error")
+	}
+	if _, err := b.Sample(5, 4, src); err == nil {
+		t.Errorf("Sample() with lo > hi did not return an error")
+	}
+}
@@ -0,0 +1,372 @@
+package species
+
+import (
+	"errors"
+	"math/big"
+	"math/rand/v2"
+
+	"github.com/natemcintosh/gocombinatorics/count"
+)
+
+// table remembers how many structures each part of a species has of each size. It also
+// keeps the first error found while counting, so the counting code does not need to
+// pass errors around.
+type table struct {
+	counts map[table_key]*big.Int
+	busy   map[table_key]bool
+	err    error
+}
+
+type table_key struct {
+	s *Species
+	n int
+}
+
+var errNotWellFounded = errors.New("species is not well founded: counting its structures of some size needs that same count")
+var errEmptyComponents = errors.New("the species in a Seq, Set or Cycle must have no structures of size 0")
+
+func new_table() *table {
+	return &table{counts: make(map[table_key]*big.Int), busy: make(map[table_key]bool)}
+}
+
+// count returns how many structures of size n s has. The result must not be changed.
+func (t *table) count(s *Species, n int) *big.Int {
+	if t.err != nil {
+		return big.NewInt(0)
+	}
+	key := table_key{s, n}
+	if c, ok := t.counts[key]; ok {
+		return c
+	}
+	// If we are already in the middle of working out this count, it depends on itself
+	if t.busy[key] {
+		t.err = errNotWellFounded
+		return big.NewInt(0)
+	}
+	t.busy[key] = true
+	c := t.compute(s, n)
+	delete(t.busy, key)
+	if t.err != nil {
+		return big.NewInt(0)
+	}
+	t.counts[key] = c
+	return c
+}
+
+func (t *table) compute(s *Species, n int) *big.Int {
+	total := big.NewInt(0)
+	switch s.kind {
+	case kind_atom:
+		if n == 1 {
+			total.SetInt64(1)
+		}
+	case kind_empty:
+		if n == 0 {
+			total.SetInt64(1)
+		}
+	case kind_union:
+		for _, alternative := range s.children {
+			total.Add(total, t.count(alternative, n))
+		}
+	case kind_product:
+		if len(s.children) == 0 {
+			if n == 0 {
+				total.SetInt64(1)
+			}
+			break
+		}
+		// The first factor gets k of the labels, and the rest get the others
+		for k := 0; k <= n; k++ {
+			total.Add(total, t.term(count.Binomial(n, k), s.children[0], k, s.rest, n-k))
+		}
+	case kind_seq:
+		if n == 0 {
+			return total.SetInt64(1)
+		}
+		// The first component gets k of the labels, and the rest of the sequence the others
+		child := t.non_empty(s.children[0])
+		for k := 1; k <= n; k++ {
+			total.Add(total, t.term(count.Binomial(n, k), child, k, s, n-k))
+		}
+	case kind_set:
+		if n == 0 {
+			return total.SetInt64(1)
+		}
+		// The component holding the smallest label gets k-1 of the other labels
+		child := t.non_empty(s.children[0])
+		for k := 1; k <= n; k++ {
+			total.Add(total, t.term(count.Binomial(n-1, k-1), child, k, s, n-k))
+		}
+	case kind_cycle:
+		if n == 0 {
+			return total
+		}
+		// Start the cycle at the component holding the smallest label. What follows it is
+		// a sequence.
+		child := t.non_empty(s.children[0])
+		for k := 1; k <= n; k++ {
+			total.Add(total, t.term(count.Binomial(n-1, k-1), child, k, s.seq, n-k))
+		}
+	case kind_restrict:
+		if s.lo <= n && n <= s.hi {
+			total.Set(t.count(s.children[0], n))
+		}
+	case kind_recursive:
+		total.Set(t.count(s.children[0], n))
+	}
+	return total
+}
+
+// term returns ways * count(a, i) * count(b, j). The smaller of the two sizes is
+// counted first, and if there are none of those, the other is never counted. This is
+// what lets recursive species such as Product(Atom(), tree) be counted without needing
+// the count for tree that is being worked out.
+func (t *table) term(ways *big.Int, a *Species, i int, b *Species, j int) *big.Int {
+	if j < i {
+		a, i, b, j = b, j, a, i
+	}
+	first := t.count(a, i)
+	if first.Sign() == 0 {
+		return big.NewInt(0)
+	}
+	result := new(big.Int).Mul(ways, first)
+	return result.Mul(result, t.count(b, j))
+}
+
+// non_empty checks that s has no structures of size 0, and returns it
+func (t *table) non_empty(s *Species) *Species {
+	if t.count(s, 0).Sign() != 0 && t.err == nil {
+		t.err = errEmptyComponents
+	}
+	return s
+}
+
+// Count returns how many structures of size n s has
+func (s *Species) Count(n int) (*big.Int, error) {
+	if n < 0 {
+		return nil, errors.New("n must be greater than or equal to 0")
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	t := new_table()
+	c := t.count(s, n)
+	if t.err != nil {
+		return nil, t.err
+	}
+	return new(big.Int).Set(c), nil
+}
+
+// unrank builds structure number rank (counting from 0) of all the structures from s on
+// the given sorted labels. It takes the structures in the same order the counting adds
+// them up in: by the sizes of the parts first, then which labels go to each part, then
+// the structures on each part.
+func (t *table) unrank(s *Species, labels []int, rank *big.Int) *Structure {
+	n := len(labels)
+	rank = new(big.Int).Set(rank)
+	switch s.kind {
+	case kind_atom:
+		return &Structure{Species: s, Label: labels[0]}
+	case kind_empty:
+		return &Structure{Species: s}
+	case kind_union:
+		for _, alternative := range s.children {
+			c := t.count(alternative, n)
+			if rank.Cmp(c) < 0 {
+				return t.unrank(alternative, labels, rank)
+			}
+			rank.Sub(rank, c)
+		}
+	case kind_product:
+		return &Structure{Species: s, Parts: t.unrank_product(s, labels, rank)}
+	case kind_seq, kind_set, kind_cycle:
+		st := &Structure{Species: s}
+		child := s.children[0]
+		for len(labels) > 0 {
+			m := len(labels)
+			for k := 1; k <= m; k++ {
+				// A Seq gives any k labels to its first component. A Set or Cycle always
+				// gives the smallest label to its first component, and picks k-1 more.
+				fixed, pick, from := labels[:0], k, labels
+				if s.kind != kind_seq {
+					fixed, pick, from = labels[:1], k-1, labels[1:]
+				}
+				ways := count.Binomial(len(from), pick)
+				rest := s
+				if s.kind == kind_cycle {
+					rest = s.seq
+				}
+				term := t.term(ways, child, k, rest, m-k)
+				if rank.Cmp(term) >= 0 {
+					rank.Sub(rank, term)
+					continue
+				}
+
+				subset, child_rank, rest_rank := split_rank(rank, t.count(child, k), t.count(rest, m-k))
+				chosen, others := unrank_subset(subset, from, pick)
+				chosen = append(append([]int{}, fixed...), chosen...)
+				st.Parts = append(st.Parts, t.unrank(child, chosen, child_rank))
+				if s.kind == kind_cycle {
+					// The rest of the cycle is a sequence
+					st.Parts = append(st.Parts, t.unrank(s.seq, others, rest_rank).Parts...)
+					return st
+				}
+				labels, rank = others, rest_rank
+				break
+			}
+		}
+		return st
+	case kind_restrict, kind_recursive:
+		return t.unrank(s.children[0], labels, rank)
+	}
+	return nil
+}
+
+// unrank_product builds the parts of structure number rank of the product s
+func (t *table) unrank_product(s *Species, labels []int, rank *big.Int) []*Structure {
+	if len(s.children) == 0 {
+		return nil
+	}
+	n := len(labels)
+	for k := 0; k <= n; k++ {
+		term := t.term(count.Binomial(n, k), s.children[0], k, s.rest, n-k)
+		if rank.Cmp(term) >= 0 {
+			rank.Sub(rank, term)
+			continue
+		}
+		subset, first_rank, rest_rank := split_rank(rank, t.count(s.children[0], k), t.count(s.rest, n-k))
+		chosen, others := unrank_subset(subset, labels, k)
+		first := t.unrank(s.children[0], chosen, first_rank)
+		return append([]*Structure{first}, t.unrank_product(s.rest, others, rest_rank)...)
+	}
+	return nil
+}
+
+// split_rank splits rank into which subset of labels to use, and the ranks of the two
+// structures that go on them: rank = (subset*a + a_rank)*b + b_rank
+func split_rank(rank, a, b *big.Int) (*big.Int, *big.Int, *big.Int) {
+	rest, b_rank := new(big.Int).QuoRem(rank, b, new(big.Int))
+	subset, a_rank := new(big.Int).QuoRem(rest, a, new(big.Int))
+	return subset, a_rank, b_rank
+}
+
+// unrank_subset picks subset number rank of the k element subsets of labels, in
+// lexicographic order. It returns the chosen labels and the others, both still sorted.
+func unrank_subset(rank *big.Int, labels []int, k int) ([]int, []int) {
+	rank = new(big.Int).Set(rank)
+	chosen := make([]int, 0, k)
+	others := make([]int, 0, len(labels)-k)
+	for i, label := range labels {
+		if len(chosen) == k {
+			others = append(others, labels[i:]...)
+			break
+		}
+		// How many subsets start by choosing this label
+		with := count.Binomial(len(labels)-i-1, k-len(chosen)-1)
+		if rank.Cmp(with) < 0 {
+			chosen = append(chosen, label)
+		} else {
+			rank.Sub(rank, with)
+			others = append(others, label)
+		}
+	}
+	return chosen, others
+}
+
+// random_below returns a uniformly random integer in [0, max)
+func random_below(rng *rand.Rand, max *big.Int) *big.Int {
+	bits := max.BitLen()
+	buf := make([]byte, (bits+7)/8)
+	r := new(big.Int)
+	for {
+		for i := range buf {
+			buf[i] = byte(rng.Uint32())
+		}
+		// Clear the bits above the top bit of max, so at least half the tries succeed
+		if extra := len(buf)*8 - bits; extra > 0 {
+			buf[0] &= 0xff >> extra
+		}
+		if r.SetBytes(buf).Cmp(max) < 0 {
+			return r
+		}
+	}
+}
+
+// Structures steps through every structure of size n from a species, on the labels
+// 0, ..., n-1. Create it with `NewStructures`, iterate with `Next()`, and get each
+// structure with `Structure()`.
+type Structures struct {
+	s       *Species
+	t       *table
+	labels  []int
+	rank    *big.Int
+	isfirst bool
+	current *Structure
+	length  *big.Int
+}
+
+// NewStructures creates a new Structures object for the structures of size n from s
+func NewStructures(s *Species, n int) (*Structures, error) {
+	length, err := s.Count(n)
+	if err != nil {
+		return nil, err
+	}
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = i
+	}
+	return &Structures{
+		s:       s,
+		t:       new_table(),
+		labels:  labels,
+		rank:    big.NewInt(0),
+		isfirst: true,
+		length:  length,
+	}, nil
+}
+
+// Len returns how many structures there are, and true, if that fits in a uint64.
+// Otherwise it returns 0 and false, and you need `BigLen()`.
+func (it *Structures) Len() (uint64, bool) {
+	if !it.length.IsUint64() {
+		return 0, false
+	}
+	return it.length.Uint64(), true
+}
+
+// BigLen returns how many structures there are. It is a new *big.Int every time, so it
+// is yours to change.
+func (it *Structures) BigLen() *big.Int {
+	return new(big.Int).Set(it.length)
+}
+
+// Next moves on to the next structure, returning false when there are none left
+func (it *Structures) Next() bool {
+	if it.isfirst {
+		it.isfirst = false
+	} else if it.rank.Cmp(it.length) < 0 {
+		it.rank.Add(it.rank, big.NewInt(1))
+	}
+	if it.rank.Cmp(it.length) >= 0 {
+		return false
+	}
+	it.current = it.t.unrank(it.s, it.labels, it.rank)
+	return true
+}
+
+// Structure returns the current structure. Every structure is newly built, so it is safe
+// to keep.
+func (it *Structures) Structure() *Structure {
+	return it.current
+}
+
+// Random returns a structure of size n chosen uniformly at random, using randomness
+// from src. It does not change where the iteration is up to. It returns nil if there are
+// no structures of size n.
+func (it *Structures) Random(src rand.Source) *Structure {
+	if it.length.Sign() == 0 {
+		return nil
+	}
+	rank := random_below(rand.New(src), it.length)
+	return it.t.unrank(it.s, it.labels, rank)
+}
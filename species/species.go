@@ -0,0 +1,226 @@
+// Package species builds families of labelled combinatorial structures out of a few
+// combinators, in the style of combinatorial species. A structure of size n is built on
+// the labels 0, ..., n-1, and each combinator says how bigger structures are put together
+// from smaller ones:
+//
+//   - `Atom()` is a single label, and `Empty()` is the structure with no labels
+//   - `Union(a, b, ...)` is a structure from any one of a, b, ...
+//   - `Product(a, b, ...)` splits the labels between a structure from each of a, b, ...
+//   - `Seq(a)`, `Set(a)` and `Cycle(a)` split the labels between some number of
+//     structures from a, arranged in a row, with no order, or around a circle
+//   - `Restrict(a, lo, hi)` keeps the structures from a with between lo and hi labels
+//
+// `Recursive()` makes a placeholder that can be used before it is defined, which gives
+// recursive families such as trees.
+//
+// For example, set partitions are sets of non-empty sets of atoms:
+//
+//	partitions := species.Set(species.Restrict(species.Set(species.Atom()), 1, math.MaxInt))
+//
+// and the k-combinations of n things are a set of k atoms (the chosen labels) next to a
+// set of the rest:
+//
+//	combinations := species.Product(species.Restrict(species.Set(species.Atom()), k, k), species.Set(species.Atom()))
+//
+// Once built, a species can count its structures of each size exactly, step through
+// them all with `NewStructures`, pick one of a given size uniformly at random, or pick
+// one of roughly a given size with a Boltzmann sampler from `NewBoltzmann`.
+package species
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Species describes a family of labelled structures. Build one with the functions in
+// this package, and do not change it (other than with `Define`) once it is in use.
+type Species struct {
+	kind     kind
+	children []*Species
+	lo, hi   int
+	// rest is the product of all but the first factor of a Product, and seq is the Seq
+	// of the components of a Cycle, used for the components after the first
+	rest *Species
+	seq  *Species
+}
+
+type kind int
+
+const (
+	kind_atom kind = iota
+	kind_empty
+	kind_union
+	kind_product
+	kind_seq
+	kind_set
+	kind_cycle
+	kind_restrict
+	kind_recursive
+)
+
+// Atom is the species with a single structure, made of one label
+func Atom() *Species {
+	return &Species{kind: kind_atom}
+}
+
+// Empty is the species with a single structure, made of no labels
+func Empty() *Species {
+	return &Species{kind: kind_empty}
+}
+
+// Union is the species whose structures are those of any one of alternatives. The
+// alternatives are counted separately, so a species that appears twice has its
+// structures counted twice.
+func Union(alternatives ...*Species) *Species {
+	return &Species{kind: kind_union, children: alternatives}
+}
+
+// Product is the species whose structures split the labels into one part per factor,
+// and put a structure from that factor on each part
+func Product(factors ...*Species) *Species {
+	s := &Species{kind: kind_product, children: factors}
+	if len(factors) > 0 {
+		s.rest = Product(factors[1:]...)
+	}
+	return s
+}
+
+// Seq is the species of sequences of structures from s. s must have no structures of
+// size 0, or there would be infinitely many sequences of each size.
+func Seq(s *Species) *Species {
+	return &Species{kind: kind_seq, children: []*Species{s}}
+}
+
+// Set is the species of sets of structures from s. s must have no structures of size 0.
+func Set(s *Species) *Species {
+	return &Species{kind: kind_set, children: []*Species{s}}
+}
+
+// Cycle is the species of cycles of structures from s: sequences where rotations are
+// counted as the same. There are no empty cycles. s must have no structures of size 0.
+func Cycle(s *Species) *Species {
+	return &Species{kind: kind_cycle, children: []*Species{s}, seq: Seq(s)}
+}
+
+// Restrict keeps the structures from s with at least lo and at most hi labels. Use
+// math.MaxInt for hi to have no upper limit.
+func Restrict(s *Species, lo, hi int) *Species {
+	return &Species{kind: kind_restrict, children: []*Species{s}, lo: lo, hi: hi}
+}
+
+// Recursive returns a placeholder species that can be used in building its own
+// definition, which is then given with `Define`. For example, rooted trees are an atom
+// (the root) with a set of subtrees:
+//
+//	tree := species.Recursive()
+//	tree.Define(species.Product(species.Atom(), species.Set(tree)))
+func Recursive() *Species {
+	return &Species{kind: kind_recursive}
+}
+
+// Define sets what a species from `Recursive` stands for. It can only be called once.
+func (s *Species) Define(definition *Species) error {
+	if s.kind != kind_recursive {
+		return errors.New("only a species from Recursive can be defined")
+	} else if len(s.children) > 0 {
+		return errors.New("species has already been defined")
+	} else if definition == nil {
+		return errNilSpecies
+	}
+	s.children = []*Species{definition}
+	return nil
+}
+
+var errNilSpecies = errors.New("species must not be nil")
+
+// validate checks s and everything it is built from, before any counting is done
+func (s *Species) validate() error {
+	seen := make(map[*Species]bool)
+	var walk func(s *Species) error
+	walk = func(s *Species) error {
+		if s == nil {
+			return errNilSpecies
+		} else if seen[s] {
+			return nil
+		}
+		seen[s] = true
+
+		switch s.kind {
+		case kind_restrict:
+			if s.lo < 0 || s.hi < s.lo {
+				return errors.New("Restrict needs 0 <= lo <= hi")
+			}
+		case kind_recursive:
+			if len(s.children) == 0 {
+				return errors.New("a Recursive species was never defined")
+			}
+		}
+		for _, child := range s.children {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		if s.rest != nil {
+			return walk(s.rest)
+		}
+		return nil
+	}
+	return walk(s)
+}
+
+// Structure is one structure from a species. Species is the part of the species it was
+// built from, Label is the label of an atom, and Parts are the factors of a product or
+// the components of a Seq, Set or Cycle.
+//
+// Union, Restrict and Recursive do not show up in structures: you get the structure from
+// the alternative or species inside them, and can tell which alternative it came from by
+// its Species. The components of a Set are ordered by their smallest label, and the
+// components of a Cycle start with the one holding its smallest label.
+type Structure struct {
+	Species *Species
+	Label   int
+	Parts   []*Structure
+}
+
+// Size is the number of labels in the structure
+func (st *Structure) Size() int {
+	if st.Species.kind == kind_atom {
+		return 1
+	}
+	size := 0
+	for _, part := range st.Parts {
+		size += part.Size()
+	}
+	return size
+}
+
+// String writes out the structure, e.g. "Set(Cycle(0 2) Cycle(1))". Products are written
+// in brackets, and atoms as their labels.
+func (st *Structure) String() string {
+	var sb strings.Builder
+	st.write(&sb)
+	return sb.String()
+}
+
+func (st *Structure) write(sb *strings.Builder) {
+	switch st.Species.kind {
+	case kind_atom:
+		sb.WriteString(strconv.Itoa(st.Label))
+		return
+	case kind_seq:
+		sb.WriteString("Seq")
+	case kind_set:
+		sb.WriteString("Set")
+	case kind_cycle:
+		sb.WriteString("Cycle")
+	}
+	sb.WriteString("(")
+	for i, part := range st.Parts {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		part.write(sb)
+	}
+	sb.WriteString(")")
+}
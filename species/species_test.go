@@ -0,0 +1,218 @@
+package species
+
+import (
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func nonEmptySets() *Species {
+	return Restrict(Set(Atom()), 1, math.MaxInt)
+}
+
+func rootedTrees() *Species {
+	tree := Recursive()
+	tree.Define(Product(Atom(), Set(tree)))
+	return tree
+}
+
+// labels lists the labels of st in the order they appear
+func labels(st *Structure) []int {
+	if st.Species.kind == kind_atom {
+		return []int{st.Label}
+	}
+	result := make([]int, 0)
+	for _, part := range st.Parts {
+		result = append(result, labels(part)...)
+	}
+	return result
+}
+
+func TestCount(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		species *Species
+		want    []int64
+	}{
+		{desc: "atom", species: Atom(), want: []int64{0, 1, 0, 0}},
+		{desc: "empty", species: Empty(), want: []int64{1, 0, 0}},
+		{desc: "sets", species: Set(Atom()), want: []int64{1, 1, 1, 1, 1}},
+		{desc: "permutations as sequences", species: Seq(Atom()), want: []int64{1, 1, 2, 6, 24, 120}},
+		{desc: "cycles", species: Cycle(Atom()), want: []int64{0, 1, 1, 2, 6, 24}},
+		{desc: "permutations as sets of cycles", species: Set(Cycle(Atom())), want: []int64{1, 1, 2, 6, 24, 120}},
+		{desc: "set partitions", species: Set(nonEmptySets()), want: []int64{1, 1, 2, 5, 15, 52, 203}},
+		{desc: "ordered set partitions", species: Seq(nonEmptySets()), want: []int64{1, 1, 3, 13, 75, 541}},
+		{desc: "perfect matchings", species: Set(Restrict(Set(Atom()), 2, 2)), want: []int64{1, 0, 1, 0, 3, 0, 15}},
+		{desc: "involutions", species: Set(Union(Atom(), Restrict(Set(Atom()), 2, 2))), want: []int64{1, 1, 2, 4, 10, 26}},
+		{desc: "derangements", species: Set(Restrict(Cycle(Atom()), 2, math.MaxInt)), want: []int64{1, 0, 1, 2, 9, 44}},
+		{desc: "rooted trees", species: rootedTrees(), want: []int64{0, 1, 2, 9, 64, 625}},
+		{desc: "empty product", species: Product(), want: []int64{1, 0, 0}},
+		{desc: "empty union", species: Union(), want: []int64{0, 0, 0}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			for n, want := range tC.want {
+				got, err := tC.species.Count(n)
+				if err != nil {
+					t.Fatalf("Count(%v) = %v, want nil", n, err)
+				}
+				if got.Cmp(big.NewInt(want)) != 0 {
+					t.Errorf("Count(%v) = %v, want %v", n, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCountCombinations(t *testing.T) {
+	for k := 0; k <= 6; k++ {
+		combinations := Product(Restrict(Set(Atom()), k, k), Set(Atom()))
+		for n := 0; n <= 12; n++ {
+			got, _ := combinations.Count(n)
+			if want := new(big.Int).Binomial(int64(n), int64(k)); n >= k && got.Cmp(want) != 0 {
+				t.Errorf("Count(%v) with k = %v: %v, want %v", n, k, got, want)
+			}
+		}
+	}
+}
+
+func TestCountErrors(t *testing.T) {
+	undefined := Recursive()
+	loop := Recursive()
+	loop.Define(Union(Atom(), loop))
+	testCases := []struct {
+		desc    string
+		species *Species
+	}{
+		{desc: "undefined", species: Set(undefined)},
+		{desc: "not well founded", species: loop},
+		{desc: "sequences of empty structures", species: Seq(Set(Atom()))},
+		{desc: "sets of empty structures", species: Set(Empty())},
+		{desc: "backwards restriction", species: Restrict(Atom(), 2, 1)},
+		{desc: "nil", species: Product(Atom(), nil)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := tC.species.Count(3); err == nil {
+				t.Errorf("Count() did not return an error")
+			}
+		})
+	}
+	if err := Atom().Define(Atom()); err == nil {
+		t.Errorf("Define() on an atom did not return an error")
+	}
+	if err := loop.Define(Atom()); err == nil {
+		t.Errorf("Define() twice did not return an error")
+	}
+}
+
+func TestStructures(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		species *Species
+		n       int
+		want    []string
+	}{
+		{
+			desc:    "cycles of 4",
+			species: Cycle(Atom()),
+			n:       4,
+			want:    []string{"Cycle(0 1 2 3)", "Cycle(0 1 3 2)", "Cycle(0 2 1 3)", "Cycle(0 2 3 1)", "Cycle(0 3 1 2)", "Cycle(0 3 2 1)"},
+		},
+		{
+			desc:    "set partitions of 3",
+			species: Set(nonEmptySets()),
+			n:       3,
+			want:    []string{"Set(Set(0) Set(1) Set(2))", "Set(Set(0) Set(1 2))", "Set(Set(0 1) Set(2))", "Set(Set(0 2) Set(1))", "Set(Set(0 1 2))"},
+		},
+		{
+			desc:    "combinations of 2 from 4",
+			species: Product(Restrict(Set(Atom()), 2, 2), Set(Atom())),
+			n:       4,
+			want:    []string{"(Set(0 1) Set(2 3))", "(Set(0 2) Set(1 3))", "(Set(0 3) Set(1 2))", "(Set(1 2) Set(0 3))", "(Set(1 3) Set(0 2))", "(Set(2 3) Set(0 1))"},
+		},
+		{desc: "nothing", species: Cycle(Atom()), n: 0, want: []string{}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s, err := NewStructures(tC.species, tC.n)
+			if err != nil {
+				t.Fatalf("NewStructures() = %v, want nil", err)
+			}
+			got := make([]string, 0)
+			for s.Next() {
+				got = append(got, s.Structure().String())
+			}
+			if !slices.Equal(got, tC.want) {
+				t.Errorf("Structures = %v, want %v", got, tC.want)
+			}
+			if s.Next() {
+				t.Errorf("Next() = true after the end")
+			}
+		})
+	}
+}
+
+func TestStructuresAreDistinctAndLabelled(t *testing.T) {
+	pairs := Restrict(Set(Atom()), 2, 2)
+	testCases := []struct {
+		desc    string
+		species *Species
+		n       int
+	}{
+		{desc: "rooted trees", species: rootedTrees(), n: 5},
+		{desc: "sequences of sets of pairs", species: Seq(Restrict(Set(pairs), 1, math.MaxInt)), n: 6},
+		{desc: "permutations", species: Set(Cycle(Atom())), n: 5},
+		{desc: "cycles of sequences", species: Cycle(Restrict(Seq(Atom()), 1, math.MaxInt)), n: 5},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s, _ := NewStructures(tC.species, tC.n)
+			seen := make(map[string]bool)
+			for s.Next() {
+				st := s.Structure()
+				seen[st.String()] = true
+				got := labels(st)
+				slices.Sort(got)
+				if len(got) != tC.n || st.Size() != tC.n || got[0] != 0 || got[len(got)-1] != tC.n-1 {
+					t.Fatalf("structure %v does not use the labels 0, ..., %v", st, tC.n-1)
+				}
+			}
+			if n, _ := s.Len(); uint64(len(seen)) != n {
+				t.Errorf("got %v distinct structures, want %v", len(seen), n)
+			}
+		})
+	}
+}
+
+func TestStructuresRandom(t *testing.T) {
+	s, _ := NewStructures(Set(Cycle(Atom())), 4)
+	src := rand.NewPCG(1, 2)
+	counts := make(map[string]int)
+	n_samples := 24_000
+	for i := 0; i < n_samples; i++ {
+		counts[s.Random(src).String()]++
+	}
+	if len(counts) != 24 {
+		t.Fatalf("got %v different permutations, want 24", len(counts))
+	}
+	for st, count := range counts {
+		if count < 850 || count > 1150 {
+			t.Errorf("%v came up %v times, want about 1000", st, count)
+		}
+	}
+
+	empty, _ := NewStructures(Cycle(Atom()), 0)
+	if got := empty.Random(src); got != nil {
+		t.Errorf("Random() = %v, want nil", got)
+	}
+}
+
+func BenchmarkCountRootedTrees(b *testing.B) {
+	tree := rootedTrees()
+	for i := 0; i < b.N; i++ {
+		tree.Count(100)
+	}
+}
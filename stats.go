@@ -0,0 +1,225 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/natemcintosh/gocombinatorics/count"
+)
+
+// This file holds statistics of single permutations (inversions, descents, ...), and the
+// numbers that count how many permutations have each value of those statistics. The
+// permutations are plain []int holding 0, ..., n-1, such as `Permutations.Indices()`
+// when k == n, or a `Perm`.
+
+// Inversions counts the pairs i < j with p[i] > p[j]. It works by merge sort, so it
+// takes O(n log n) time. p only needs to hold distinct values.
+func Inversions(p []int) int64 {
+	work := make([]int, len(p))
+	copy(work, p)
+	return count_inversions(work, make([]int, len(p)))
+}
+
+// count_inversions merge sorts s, using scratch as somewhere to merge into, and counts
+// the inversions along the way
+func count_inversions(s, scratch []int) int64 {
+	if len(s) < 2 {
+		return 0
+	}
+	mid := len(s) / 2
+	count := count_inversions(s[:mid], scratch[:mid]) + count_inversions(s[mid:], scratch[mid:])
+
+	i, j, out := 0, mid, 0
+	for i < mid && j < len(s) {
+		if s[j] < s[i] {
+			// s[j] jumps ahead of everything left in the first half
+			count += int64(mid - i)
+			scratch[out] = s[j]
+			j++
+		} else {
+			scratch[out] = s[i]
+			i++
+		}
+		out++
+	}
+	out += copy(scratch[out:], s[i:mid])
+	copy(scratch[out:], s[j:])
+	copy(s, scratch[:len(s)])
+	return count
+}
+
+// Descents counts the positions i with p[i] > p[i+1]
+func Descents(p []int) int {
+	count := 0
+	for i := 0; i+1 < len(p); i++ {
+		if p[i] > p[i+1] {
+			count++
+		}
+	}
+	return count
+}
+
+// MajorIndex is the sum of the positions of the descents, counting positions from 1 (so
+// a descent between p[0] and p[1] adds 1).
+func MajorIndex(p []int) int {
+	sum := 0
+	for i := 0; i+1 < len(p); i++ {
+		if p[i] > p[i+1] {
+			sum += i + 1
+		}
+	}
+	return sum
+}
+
+// FixedPoints counts the positions i with p[i] == i
+func FixedPoints(p []int) int {
+	count := 0
+	for i, v := range p {
+		if v == i {
+			count++
+		}
+	}
+	return count
+}
+
+// NumCycles counts the cycles of p, including fixed points
+func NumCycles(p []int) int {
+	return len(Perm(p).cycle_lengths())
+}
+
+// fenwick is a binary indexed tree over 0, ..., n-1, used to count how many values
+// below some point are still present.
+type fenwick []int
+
+func new_fenwick_full(n int) fenwick {
+	f := make(fenwick, n+1)
+	for i := 1; i <= n; i++ {
+		f[i]++
+		if parent := i + (i & -i); parent <= n {
+			f[parent] += f[i]
+		}
+	}
+	return f
+}
+
+// add adds delta at position i
+func (f fenwick) add(i, delta int) {
+	for i++; i < len(f); i += i & -i {
+		f[i] += delta
+	}
+}
+
+// prefix returns the total over positions 0, ..., i-1
+func (f fenwick) prefix(i int) int {
+	total := 0
+	for ; i > 0; i -= i & -i {
+		total += f[i]
+	}
+	return total
+}
+
+// find returns the smallest position where the running total reaches rank+1, i.e. the
+// position of the (rank)th present value counting from 0
+func (f fenwick) find(rank int) int {
+	pos := 0
+	step := 1
+	for step*2 < len(f) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if pos+step < len(f) && f[pos+step] <= rank {
+			pos += step
+			rank -= f[pos]
+		}
+	}
+	return pos
+}
+
+// LehmerCode returns the Lehmer code of p: code[i] is how many entries after position i
+// are smaller than p[i]. Its entries add up to the number of inversions, and read as
+// digits in the factorial number system, it is the position of p in lexicographic
+// order. p must hold each of 0, ..., n-1 exactly once. It takes O(n log n) time.
+func LehmerCode(p []int) ([]int, error) {
+	if _, err := NewPerm(p); err != nil {
+		return nil, err
+	}
+	// Keep track of which values have not been seen yet
+	remaining := new_fenwick_full(len(p))
+	code := make([]int, len(p))
+	for i, v := range p {
+		code[i] = remaining.prefix(v)
+		remaining.add(v, -1)
+	}
+	return code, nil
+}
+
+// FromLehmerCode is the inverse of LehmerCode. code[i] must be in [0, n-1-i].
+func FromLehmerCode(code []int) ([]int, error) {
+	n := len(code)
+	remaining := new_fenwick_full(n)
+	p := make([]int, n)
+	for i, c := range code {
+		if c < 0 || c > n-1-i {
+			return nil, errors.New("code[i] must be in the range [0, len(code)-1-i]")
+		}
+		p[i] = remaining.find(c)
+		remaining.add(p[i], -1)
+	}
+	return p, nil
+}
+
+// Mahonian returns how many permutations of n things have exactly k inversions. By
+// MacMahon's theorem, this is also how many have major index k.
+func Mahonian(n, k int) *big.Int {
+	max_inversions := n * (n - 1) / 2
+	if n < 0 || k < 0 || k > max_inversions {
+		return big.NewInt(0)
+	}
+	// row[j] is the number of permutations of i things with j inversions. Going from i-1
+	// to i things, the new largest value can be put anywhere, adding 0 to i-1 inversions.
+	row := []*big.Int{big.NewInt(1)}
+	for i := 1; i <= n; i++ {
+		next := make([]*big.Int, len(row)+i-1)
+		for j := range next {
+			next[j] = big.NewInt(0)
+		}
+		for j, count := range row {
+			for extra := 0; extra < i; extra++ {
+				next[j+extra].Add(next[j+extra], count)
+			}
+		}
+		row = next
+	}
+	return row[k]
+}
+
+// Eulerian returns how many permutations of n things have exactly k descents
+func Eulerian(n, k int) *big.Int {
+	if n < 0 || k < 0 || (n > 0 && k >= n) || (n == 0 && k > 0) {
+		return big.NewInt(0)
+	}
+	// A(i, j) = (j+1) A(i-1, j) + (i-j) A(i-1, j-1)
+	row := []*big.Int{big.NewInt(1)}
+	for i := 1; i <= n; i++ {
+		next := make([]*big.Int, i)
+		for j := range next {
+			next[j] = big.NewInt(0)
+			if j < len(row) {
+				next[j].Mul(big.NewInt(int64(j+1)), row[j])
+			}
+			if j >= 1 && j-1 < len(row) {
+				term := new(big.Int).Mul(big.NewInt(int64(i-j)), row[j-1])
+				next[j].Add(next[j], term)
+			}
+		}
+		row = next
+	}
+	return row[k]
+}
+
+// Stirling1 returns the unsigned Stirling number of the first kind: how many
+// permutations of n things have exactly k cycles. It is `count.Stirling1`, kept here
+// next to the other permutation statistics.
+func Stirling1(n, k int) *big.Int {
+	return count.Stirling1(n, k)
+}
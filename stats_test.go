@@ -0,0 +1,175 @@
+package gocombinatorics
+
+import (
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestPermutationStatistics(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		p                []int
+		want_inversions  int64
+		want_descents    int
+		want_major_index int
+		want_fixed       int
+		want_cycles      int
+		want_lehmer      []int
+	}{
+		{
+			desc:        "empty",
+			p:           []int{},
+			want_cycles: 0,
+			want_lehmer: []int{},
+		},
+		{
+			desc:        "identity",
+			p:           []int{0, 1, 2, 3},
+			want_fixed:  4,
+			want_cycles: 4,
+			want_lehmer: []int{0, 0, 0, 0},
+		},
+		{
+			desc:             "reversed",
+			p:                []int{3, 2, 1, 0},
+			want_inversions:  6,
+			want_descents:    3,
+			want_major_index: 6,
+			want_cycles:      2,
+			want_lehmer:      []int{3, 2, 1, 0},
+		},
+		{
+			desc:             "mixed",
+			p:                []int{2, 0, 3, 4, 1},
+			want_inversions:  4,
+			want_descents:    2,
+			want_major_index: 5,
+			want_cycles:      1,
+			want_lehmer:      []int{2, 0, 1, 1, 0},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if got := Inversions(tC.p); got != tC.want_inversions {
+				t.Errorf("Inversions() = %v, want %v", got, tC.want_inversions)
+			}
+			if got := Descents(tC.p); got != tC.want_descents {
+				t.Errorf("Descents() = %v, want %v", got, tC.want_descents)
+			}
+			if got := MajorIndex(tC.p); got != tC.want_major_index {
+				t.Errorf("MajorIndex() = %v, want %v", got, tC.want_major_index)
+			}
+			if got := FixedPoints(tC.p); got != tC.want_fixed {
+				t.Errorf("FixedPoints() = %v, want %v", got, tC.want_fixed)
+			}
+			if got := NumCycles(tC.p); got != tC.want_cycles {
+				t.Errorf("NumCycles() = %v, want %v", got, tC.want_cycles)
+			}
+			code, err := LehmerCode(tC.p)
+			if err != nil {
+				t.Fatalf("LehmerCode() = %v, want nil", err)
+			}
+			if !reflect.DeepEqual(code, tC.want_lehmer) {
+				t.Errorf("LehmerCode() = %v, want %v", code, tC.want_lehmer)
+			}
+			back, err := FromLehmerCode(code)
+			if err != nil {
+				t.Fatalf("FromLehmerCode() = %v, want nil", err)
+			}
+			if !reflect.DeepEqual(back, tC.p) {
+				t.Errorf("FromLehmerCode() = %v, want %v", back, tC.p)
+			}
+		})
+	}
+}
+
+func TestLehmerCodeErrors(t *testing.T) {
+	if _, err := LehmerCode([]int{0, 2}); err == nil {
+		t.Errorf("LehmerCode() of a non-permutation did not return an error")
+	}
+	if _, err := FromLehmerCode([]int{0, 1}); err == nil {
+		t.Errorf("FromLehmerCode() of an invalid code did not return an error")
+	}
+}
+
+func TestInversionsAgainstBruteForce(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		p := rand.Perm(rand.Intn(200))
+		var want int64
+		for a := range p {
+			for b := a + 1; b < len(p); b++ {
+				if p[a] > p[b] {
+					want++
+				}
+			}
+		}
+		if got := Inversions(p); got != want {
+			t.Errorf("Inversions(%v) = %v, want %v", p, got, want)
+		}
+		code, _ := LehmerCode(p)
+		var sum int64
+		for _, c := range code {
+			sum += int64(c)
+		}
+		if sum != want {
+			t.Errorf("sum(LehmerCode(%v)) = %v, want %v", p, sum, want)
+		}
+	}
+}
+
+func TestLehmerCodeIsRank(t *testing.T) {
+	// The Lehmer code is the lexicographic rank written in the factorial number system
+	n := 5
+	p, _ := NewPermutations(stepped_range(0, n, 1), n)
+	for rank := int64(0); p.Next(); rank++ {
+		code, _ := LehmerCode(p.Indices())
+		got := int64(0)
+		for i, c := range code {
+			got = got*int64(n-i) + int64(c)
+		}
+		if got != rank {
+			t.Errorf("LehmerCode(%v) = %v reads as %v, want %v", p.Indices(), code, got, rank)
+		}
+	}
+}
+
+func TestPermutationCountingNumbers(t *testing.T) {
+	testCases := []struct {
+		desc string
+		f    func(n, k int) *big.Int
+		n    int
+		want []int64
+	}{
+		{desc: "Mahonian 4", f: Mahonian, n: 4, want: []int64{1, 3, 5, 6, 5, 3, 1}},
+		{desc: "Mahonian 0", f: Mahonian, n: 0, want: []int64{1}},
+		{desc: "Eulerian 5", f: Eulerian, n: 5, want: []int64{1, 26, 66, 26, 1}},
+		{desc: "Eulerian 0", f: Eulerian, n: 0, want: []int64{1}},
+		{desc: "Stirling1 5", f: Stirling1, n: 5, want: []int64{0, 24, 50, 35, 10, 1}},
+		{desc: "Stirling1 0", f: Stirling1, n: 0, want: []int64{1}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			for k, want := range tC.want {
+				if got := tC.f(tC.n, k); got.Cmp(big.NewInt(want)) != 0 {
+					t.Errorf("f(%v, %v) = %v, want %v", tC.n, k, got, want)
+				}
+			}
+			// Out of range is 0
+			if got := tC.f(tC.n, len(tC.want)); got.Sign() != 0 {
+				t.Errorf("f(%v, %v) = %v, want 0", tC.n, len(tC.want), got)
+			}
+			if got := tC.f(tC.n, -1); got.Sign() != 0 {
+				t.Errorf("f(%v, -1) = %v, want 0", tC.n, got)
+			}
+		})
+	}
+}
+
+func BenchmarkInversions(b *testing.B) {
+	p := rand.Perm(100000)
+	for i := 0; i < b.N; i++ {
+		Inversions(p)
+	}
+}
@@ -0,0 +1,310 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// SumCombinations will give you all combinations of length k of an input slice of
+// numbers whose sum lies in [lo, hi]. Create it with `SubsetsWithSum`.
+//
+// Rather than checking every combination, the numbers are sorted and the search is cut
+// short as soon as the partial sum shows that no completion can land in [lo, hi].
+// Combinations are found in lexicographic order of the sorted data, while `Indices()`
+// always refers to positions in the original input, in increasing order.
+// SumCombinations meets the `CombinationLike` interface
+type SumCombinations[T Number] struct {
+	data  []T
+	order []int
+	k     int
+	// search finds the next combination, as positions in the sorted data, in pos
+	search func() bool
+	pos    []int
+	inds   []int
+	buffer []T
+}
+
+// SubsetsWithSum creates a new SumCombinations object, which will iterate over every
+// combination of k elements of input_data whose sum is in [lo, hi]. The sums are worked
+// out in an int64 for integer data, however small T is, so the sum of the positive items,
+// and the sum of the negative items, must each fit in an int64. Float data is added up
+// in a float64.
+func SubsetsWithSum[T Number](input_data []T, k int, lo, hi T) (*SumCombinations[T], error) {
+	data := make([]T, len(input_data))
+	copy(data, input_data)
+	n := len(input_data)
+
+	// Check for cases where we can't do combinations
+	if k > n {
+		return nil, errors.New("k must be less than or equal to len(input_data)")
+	} else if n <= 0 {
+		return nil, errors.New("len(input_data) must be greater than 0")
+	} else if k <= 0 {
+		return nil, errors.New("k must be greater than 0")
+	}
+
+	// Sort the data, remembering where each item came from
+	order := stepped_range(0, n, 1)
+	sort.SliceStable(order, func(i, j int) bool {
+		return data[order[i]] < data[order[j]]
+	})
+	sorted := make([]T, n)
+	for i, idx := range order {
+		sorted[i] = data[idx]
+	}
+
+	pos := make([]int, k)
+	var search func() bool
+	if is_float[T]() {
+		values := make([]float64, n)
+		for i, v := range sorted {
+			values[i] = float64(v)
+		}
+		search = new_sum_search(values, k, float64(lo), float64(hi), pos).next
+	} else {
+		values, err := int64_values(sorted)
+		if err != nil {
+			return nil, err
+		}
+		lo64, lo_ok := to_int64(lo)
+		hi64, hi_ok := to_int64(hi)
+		if !hi_ok {
+			hi64 = math.MaxInt64
+		}
+		if lo_ok {
+			search = new_sum_search(values, k, lo64, hi64, pos).next
+		} else {
+			// lo is above every int64, so no sum reaches it
+			search = func() bool { return false }
+		}
+	}
+
+	return &SumCombinations[T]{
+		data:   data,
+		order:  order,
+		k:      k,
+		search: search,
+		pos:    pos,
+		inds:   make([]int, k),
+		buffer: make([]T, k),
+	}, nil
+}
+
+// Next will find the next combination whose sum is in [lo, hi], returning false once
+// there are none left.
+func (s *SumCombinations[T]) Next() bool {
+	if !s.search() {
+		return false
+	}
+	s.fill_inds()
+	return true
+}
+
+// sum_search is the search behind SumCombinations, over the sorted data converted to a
+// type that its sums fit in
+type sum_search[W int64 | float64] struct {
+	sorted  []W
+	n, k    int
+	lo, hi  W
+	isfirst bool
+	pos     []int
+	psum    []W
+}
+
+func new_sum_search[W int64 | float64](sorted []W, k int, lo, hi W, pos []int) *sum_search[W] {
+	return &sum_search[W]{
+		sorted:  sorted,
+		n:       len(sorted),
+		k:       k,
+		lo:      lo,
+		hi:      hi,
+		isfirst: true,
+		pos:     pos,
+		psum:    make([]W, k+1),
+	}
+}
+
+// next moves pos on to the next combination whose sum is in [lo, hi]
+func (s *sum_search[W]) next() bool {
+	// t is the position in the combination we are currently trying to fill
+	t := s.k - 1
+	if s.isfirst {
+		t = 0
+		s.pos[0] = -1
+		s.isfirst = false
+	}
+
+	for t >= 0 {
+		s.pos[t]++
+		v := s.pos[t]
+		remaining := s.k - t - 1
+
+		// Not enough items left to fill the rest of the combination
+		if v > s.n-1-remaining {
+			t--
+			continue
+		}
+
+		cur := s.psum[t] + s.sorted[v]
+
+		// The smallest we can possibly get from here is by taking the next items. If even
+		// that is too big, then so is everything else at this depth.
+		min_sum := cur
+		for i := v + 1; i <= v+remaining; i++ {
+			min_sum += s.sorted[i]
+		}
+		if min_sum > s.hi {
+			t--
+			continue
+		}
+
+		// The largest we can possibly get is by taking the last items. If that is too
+		// small, try a bigger item at this position.
+		max_sum := cur
+		for i := s.n - remaining; i < s.n; i++ {
+			max_sum += s.sorted[i]
+		}
+		if max_sum < s.lo {
+			continue
+		}
+
+		s.psum[t+1] = cur
+		if remaining == 0 {
+			return true
+		}
+		t++
+		s.pos[t] = v
+	}
+	return false
+}
+
+// fill_inds translates the positions in the sorted data back to the original indices
+func (s *SumCombinations[T]) fill_inds() {
+	for i, p := range s.pos {
+		s.inds[i] = s.order[p]
+	}
+	sort.Ints(s.inds)
+}
+
+func (s *SumCombinations[T]) LenInds() int {
+	return s.k
+}
+
+// Indices gives the indices in the original input of the items in this combination
+func (s *SumCombinations[T]) Indices() []int {
+	return s.inds
+}
+
+// Items is how you get the items in this combination. You iterate with `s.Next()`, and
+// then get the combination with `s.Items()`. The data in the slice returned will be
+// overwritten every iteration. If you need to keep the data from each iteration, be
+// sure to make a copy.
+func (s *SumCombinations[T]) Items() []T {
+	fill_buffer(s.buffer, s.data, s.inds)
+	return s.buffer
+}
+
+// CountSubsetsWithSum returns the number of combinations of length k of input_data whose
+// sum is in [lo, hi], without generating them. It works by dynamic programming over
+// (number of items chosen, sum so far), keeping only the sums that some subset reaches,
+// so it takes time and memory proportional to k * len(input_data) * (the number of
+// different subset sums). That is small for integer data with modest values, or few
+// items. The sum of the positive items, and the sum of the negative items, must each fit
+// in an int64.
+func CountSubsetsWithSum[T Integer](input_data []T, k int, lo, hi T) (*big.Int, error) {
+	n := len(input_data)
+	if k > n {
+		return nil, errors.New("k must be less than or equal to len(input_data)")
+	} else if n <= 0 {
+		return nil, errors.New("len(input_data) must be greater than 0")
+	} else if k <= 0 {
+		return nil, errors.New("k must be greater than 0")
+	}
+
+	values, err := int64_values(input_data)
+	if err != nil {
+		return nil, err
+	}
+	lo64, lo_ok := to_int64(lo)
+	hi64, hi_ok := to_int64(hi)
+	if !lo_ok {
+		// lo is above every int64, so no sum reaches it
+		return big.NewInt(0), nil
+	} else if !hi_ok {
+		hi64 = math.MaxInt64
+	}
+
+	// counts[j][s] is how many j item subsets (of the items seen so far) sum to s
+	counts := make([]map[int64]*big.Int, k+1)
+	for j := range counts {
+		counts[j] = make(map[int64]*big.Int)
+	}
+	counts[0][0] = big.NewInt(1)
+
+	for i, v := range values {
+		// Go down in j so that each item is used at most once
+		for j := min(i+1, k); j >= 1; j-- {
+			for s, c := range counts[j-1] {
+				dest := counts[j][s+v]
+				if dest == nil {
+					dest = new(big.Int)
+					counts[j][s+v] = dest
+				}
+				dest.Add(dest, c)
+			}
+		}
+	}
+
+	total := big.NewInt(0)
+	for sum, c := range counts[k] {
+		if sum >= lo64 && sum <= hi64 {
+			total.Add(total, c)
+		}
+	}
+	return total, nil
+}
+
+// int64_values converts integer data to int64s. Every subset sum lies between the sum of
+// the negative items and the sum of the positive items, so it checks that both of those
+// fit in an int64, and then so does every sum on the way.
+func int64_values[T Number](input_data []T) ([]int64, error) {
+	values := make([]int64, len(input_data))
+	var neg_sum, pos_sum int64
+	for i, v := range input_data {
+		x, ok := to_int64(v)
+		if !ok {
+			return nil, errors.New("every item must fit in an int64")
+		}
+		if x < 0 && neg_sum < math.MinInt64-x {
+			return nil, errors.New("the sum of the negative items must fit in an int64")
+		} else if x > 0 && pos_sum > math.MaxInt64-x {
+			return nil, errors.New("the sum of the positive items must fit in an int64")
+		}
+		if x < 0 {
+			neg_sum += x
+		} else {
+			pos_sum += x
+		}
+		values[i] = x
+	}
+	return values, nil
+}
+
+// is_float returns whether T is a floating point type
+func is_float[T Number]() bool {
+	half := T(1) / T(2)
+	return half != 0
+}
+
+// to_int64 converts the integer v to an int64, returning false if it is an unsigned
+// value too big to fit
+func to_int64[T Number](v T) (int64, bool) {
+	x := int64(v)
+	// Only an unsigned value of 2^63 or more turns negative
+	if v > 0 && x < 0 {
+		return 0, false
+	}
+	return x, true
+}
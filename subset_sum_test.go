@@ -0,0 +1,295 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// sum_subsets_by_filtering finds the combinations of data with a sum in [lo, hi] by
+// checking every combination
+func sum_subsets_by_filtering[T Number](data []T, k int, lo, hi T) [][]int {
+	c, _ := NewCombinations(stepped_range(0, len(data), 1), k)
+	result := make([][]int, 0)
+	for c.Next() {
+		var sum T
+		for _, idx := range c.Indices() {
+			sum += data[idx]
+		}
+		if sum >= lo && sum <= hi {
+			next_set_of_indices := make([]int, k)
+			copy(next_set_of_indices, c.Indices())
+			result = append(result, next_set_of_indices)
+		}
+	}
+	return result
+}
+
+// collect_sum_subsets gathers all the index sets from a SumCombinations, checking the
+// items along the way
+func collect_sum_subsets[T Number](t *testing.T, s *SumCombinations[T], data []T) map[string]bool {
+	got := make(map[string]bool)
+	for s.Next() {
+		items := s.Items()
+		for i, idx := range s.Indices() {
+			if items[i] != data[idx] {
+				t.Errorf("Items()[%v] = %v, want %v", i, items[i], data[idx])
+			}
+		}
+		key := fmt.Sprint(s.Indices())
+		if got[key] {
+			t.Errorf("Saw %v twice", key)
+		}
+		got[key] = true
+	}
+	return got
+}
+
+func TestSubsetsWithSumErrors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		n    int
+		k    int
+	}{
+		{desc: "n <= 0", n: 0, k: 1},
+		{desc: "k <= 0", n: 3, k: 0},
+		{desc: "k > n", n: 3, k: 4},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			data := stepped_range(0, tC.n, 1)
+			if _, err := SubsetsWithSum(data, tC.k, 0, 10); err == nil {
+				t.Errorf("SubsetsWithSum() did not return an error")
+			}
+			if _, err := CountSubsetsWithSum(data, tC.k, 0, 10); err == nil {
+				t.Errorf("CountSubsetsWithSum() did not return an error")
+			}
+		})
+	}
+}
+
+func TestCountSubsetsWithSumOverflow(t *testing.T) {
+	testCases := []struct {
+		desc string
+		err  error
+	}{
+		{desc: "positive sum overflows", err: second(CountSubsetsWithSum([]int64{1 << 62, 1 << 62, 3}, 2, 3, 5))},
+		{desc: "negative sum overflows", err: second(CountSubsetsWithSum([]int64{-1 << 62, -1 << 62, -1 << 62}, 2, -5, 5))},
+		{desc: "item above MaxInt64", err: second(CountSubsetsWithSum([]uint64{1 << 63, 1}, 1, 0, 5))},
+		{desc: "positive sum overflows to generate", err: second(SubsetsWithSum([]int64{1 << 62, 1 << 62, 3}, 2, 3, 5))},
+		{desc: "item above MaxInt64 to generate", err: second(SubsetsWithSum([]uint64{1 << 63, 1}, 1, 0, 5))},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if tC.err == nil {
+				t.Errorf("got no error")
+			}
+		})
+	}
+}
+
+func TestCountSubsetsWithSumLargeValues(t *testing.T) {
+	// Only the sums some subset reaches are kept, so values far apart are cheap
+	data := []int64{1 << 40, 1 << 50, 3, -(1 << 45), 1 << 61}
+	testCases := []struct {
+		desc   string
+		k      int
+		lo, hi int64
+		want   int64
+	}{
+		{desc: "everything", k: 2, lo: math.MinInt64, hi: math.MaxInt64, want: 10},
+		{desc: "exactly one pair", k: 2, lo: 1<<40 + 3, hi: 1<<40 + 3, want: 1},
+		{desc: "negative pairs", k: 2, lo: math.MinInt64, hi: -1, want: 2},
+		{desc: "top of the range", k: 3, lo: 1 << 61, hi: math.MaxInt64, want: 4},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := CountSubsetsWithSum(data, tC.k, tC.lo, tC.hi)
+			if err != nil {
+				t.Fatalf("CountSubsetsWithSum() = %v, want nil", err)
+			}
+			if got.Int64() != tC.want {
+				t.Errorf("CountSubsetsWithSum() = %v, want %v", got, tC.want)
+			}
+		})
+	}
+
+	// Unsigned bounds above MaxInt64 still work
+	got, err := CountSubsetsWithSum([]uint64{1, 2, 3}, 2, 0, math.MaxUint64)
+	if err != nil || got.Int64() != 3 {
+		t.Errorf("CountSubsetsWithSum() = %v, %v, want 3, nil", got, err)
+	}
+	got, err = CountSubsetsWithSum([]uint64{1, 2, 3}, 2, 1<<63, math.MaxUint64)
+	if err != nil || got.Int64() != 0 {
+		t.Errorf("CountSubsetsWithSum() = %v, %v, want 0, nil", got, err)
+	}
+}
+
+func TestSubsetsWithSum(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		data   []int
+		k      int
+		lo, hi int
+		want   [][]int
+	}{
+		{
+			desc: "sum to exactly 10",
+			data: []int{5, 1, 4, 6, 9, 3},
+			k:    2,
+			lo:   10,
+			hi:   10,
+			want: [][]int{{1, 4}, {2, 3}},
+		},
+		{
+			desc: "nothing fits",
+			data: []int{1, 2, 3},
+			k:    2,
+			lo:   100,
+			hi:   200,
+			want: [][]int{},
+		},
+		{
+			desc: "negative numbers",
+			data: []int{-3, 2, -1, 4},
+			k:    3,
+			lo:   -2,
+			hi:   0,
+			want: [][]int{{0, 1, 2}, {0, 2, 3}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s, err := SubsetsWithSum(tC.data, tC.k, tC.lo, tC.hi)
+			if err != nil {
+				t.Fatalf("SubsetsWithSum() = %v, want nil", err)
+			}
+			got := collect_sum_subsets(t, s, tC.data)
+			want := make(map[string]bool)
+			for _, inds := range tC.want {
+				want[fmt.Sprint(inds)] = true
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("SubsetsWithSum() = %v, want %v", got, want)
+			}
+
+			count, err := CountSubsetsWithSum(tC.data, tC.k, tC.lo, tC.hi)
+			if err != nil {
+				t.Fatalf("CountSubsetsWithSum() = %v, want nil", err)
+			}
+			if count.Cmp(big.NewInt(int64(len(tC.want)))) != 0 {
+				t.Errorf("CountSubsetsWithSum() = %v, want %v", count, len(tC.want))
+			}
+		})
+	}
+}
+
+func Test100RandomSubsetsWithSum(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		n := rand.Intn(12) + 1
+		k := rand.Intn(n) + 1
+		data := make([]int, n)
+		for j := range data {
+			data[j] = rand.Intn(41) - 20
+		}
+		lo := rand.Intn(41) - 20
+		hi := lo + rand.Intn(20)
+
+		run_name := fmt.Sprintf("data=%v, k=%v, lo=%v, hi=%v", data, k, lo, hi)
+		t.Run(run_name, func(t *testing.T) {
+			want := sum_subsets_by_filtering(data, k, lo, hi)
+			s, err := SubsetsWithSum(data, k, lo, hi)
+			if err != nil {
+				t.Fatalf("SubsetsWithSum() = %v, want nil", err)
+			}
+			got := collect_sum_subsets(t, s, data)
+			if len(got) != len(want) {
+				t.Errorf("SubsetsWithSum() found %v combinations, want %v", len(got), len(want))
+			}
+			for _, inds := range want {
+				if !got[fmt.Sprint(inds)] {
+					t.Errorf("SubsetsWithSum() did not find %v", inds)
+				}
+			}
+
+			count, err := CountSubsetsWithSum(data, k, lo, hi)
+			if err != nil {
+				t.Fatalf("CountSubsetsWithSum() = %v, want nil", err)
+			}
+			if count.Cmp(big.NewInt(int64(len(want)))) != 0 {
+				t.Errorf("CountSubsetsWithSum() = %v, want %v", count, len(want))
+			}
+		})
+	}
+}
+
+func TestSubsetsWithSumFloat(t *testing.T) {
+	data := []float64{0.5, 2.25, 1.5, 3.0, 0.75}
+	want := sum_subsets_by_filtering(data, 3, 3.0, 5.0)
+	s, err := SubsetsWithSum(data, 3, 3.0, 5.0)
+	if err != nil {
+		t.Fatalf("SubsetsWithSum() = %v, want nil", err)
+	}
+	got := collect_sum_subsets(t, s, data)
+	if len(got) != len(want) {
+		t.Errorf("SubsetsWithSum() found %v combinations, want %v", len(got), len(want))
+	}
+	for _, inds := range want {
+		if !got[fmt.Sprint(inds)] {
+			t.Errorf("SubsetsWithSum() did not find %v", inds)
+		}
+	}
+}
+
+func TestSubsetsWithSumSmallType(t *testing.T) {
+	// The sums on the way go well past what an int8 holds, but every subset found must
+	// still be one whose real sum is in [lo, hi]
+	data := []int8{100, 90, -120, 80, -100, 70, 60}
+	wide := make([]int, len(data))
+	for i, v := range data {
+		wide[i] = int(v)
+	}
+	for _, lohi := range [][2]int8{{100, 127}, {-128, -90}, {0, 50}} {
+		t.Run(fmt.Sprint(lohi), func(t *testing.T) {
+			lo, hi := lohi[0], lohi[1]
+			want := sum_subsets_by_filtering(wide, 4, int(lo), int(hi))
+			s, err := SubsetsWithSum(data, 4, lo, hi)
+			if err != nil {
+				t.Fatalf("SubsetsWithSum() = %v, want nil", err)
+			}
+			got := collect_sum_subsets(t, s, data)
+			if len(got) != len(want) {
+				t.Errorf("SubsetsWithSum() found %v combinations, want %v", len(got), len(want))
+			}
+			for _, inds := range want {
+				if !got[fmt.Sprint(inds)] {
+					t.Errorf("SubsetsWithSum() did not find %v", inds)
+				}
+			}
+		})
+	}
+
+	// The same for unsigned data, where the sums go past 255
+	udata := []uint8{200, 150, 100, 50, 10}
+	s, _ := SubsetsWithSum(udata, 2, 240, 255)
+	got := collect_sum_subsets(t, s, udata)
+	want := map[string]bool{"[0 3]": true, "[1 2]": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SubsetsWithSum() = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkSubsetsWithSum(b *testing.B) {
+	data := stepped_range(0, 60, 1)
+	for i := 0; i < b.N; i++ {
+		s, err := SubsetsWithSum(data, 4, 20, 25)
+		if err != nil {
+			b.Errorf("SubsetsWithSum() = %v, want nil", err)
+		}
+		for s.Next() {
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package gocombinatorics
+
+import (
+	"math/big"
+
+	"github.com/natemcintosh/gocombinatorics/count"
+)
+
+// This file holds sums over every combination of some items, worked out without going
+// through the combinations. Going through them with `Combinations` takes
+// nchoosek(n, k) steps, while these take O(n * k) steps or fewer.
+
+// ElementarySymmetric returns the elementary symmetric polynomial e_k of the weights: the
+// sum, over every combination of k of the weights (as `Combinations` would give them), of
+// their product. e_0 is 1, and e_k is 0 for k < 0 or k > len(weights).
+//
+// For example, if item i works with probability p[i], independently of the others, the
+// probability that exactly k of them work (the Poisson binomial distribution) is
+// product(1 - p[i]) * e_k(p[i] / (1 - p[i])).
+//
+// It builds up e_0, ..., e_k of the first i weights, one weight at a time, using
+// e_j(w_1, ..., w_i) = e_j(w_1, ..., w_{i-1}) + w_i * e_{j-1}(w_1, ..., w_{i-1}). This
+// takes O(n * k) steps.
+func ElementarySymmetric(weights []*big.Int, k int) *big.Int {
+	if k < 0 || k > len(weights) {
+		return big.NewInt(0)
+	}
+	e := make([]*big.Int, k+1)
+	e[0] = big.NewInt(1)
+	for j := 1; j <= k; j++ {
+		e[j] = big.NewInt(0)
+	}
+	term := new(big.Int)
+	for i, w := range weights {
+		// Go down, so e[j-1] is still the value from before this weight. Only the first
+		// i+1 of them can be above 0 yet.
+		for j := min(k, i+1); j >= 1; j-- {
+			e[j].Add(e[j], term.Mul(w, e[j-1]))
+		}
+	}
+	return e[k]
+}
+
+// ElementarySymmetricFloat is like ElementarySymmetric, for float64 weights
+func ElementarySymmetricFloat(weights []float64, k int) float64 {
+	if k < 0 || k > len(weights) {
+		return 0
+	}
+	e := make([]float64, k+1)
+	e[0] = 1
+	for i, w := range weights {
+		for j := min(k, i+1); j >= 1; j-- {
+			e[j] += w * e[j-1]
+		}
+	}
+	return e[k]
+}
+
+// CompleteHomogeneous returns the complete homogeneous symmetric polynomial h_k of the
+// weights: the sum, over every combination with replacement of k of the weights (as
+// `CombinationsWithReplacement` would give them), of their product. h_0 is 1, and h_k
+// is 0 for k < 0, or for k > 0 with no weights.
+//
+// It builds up h_0, ..., h_k of the first i weights, one weight at a time, using
+// h_j(w_1, ..., w_i) = h_j(w_1, ..., w_{i-1}) + w_i * h_{j-1}(w_1, ..., w_i). This takes
+// O(n * k) steps.
+func CompleteHomogeneous(weights []*big.Int, k int) *big.Int {
+	if k < 0 {
+		return big.NewInt(0)
+	}
+	h := make([]*big.Int, k+1)
+	h[0] = big.NewInt(1)
+	for j := 1; j <= k; j++ {
+		h[j] = big.NewInt(0)
+	}
+	term := new(big.Int)
+	for _, w := range weights {
+		// Go up, so h[j-1] already includes this weight, which may be used again
+		for j := 1; j <= k; j++ {
+			h[j].Add(h[j], term.Mul(w, h[j-1]))
+		}
+	}
+	return h[k]
+}
+
+// CompleteHomogeneousFloat is like CompleteHomogeneous, for float64 weights
+func CompleteHomogeneousFloat(weights []float64, k int) float64 {
+	if k < 0 {
+		return 0
+	}
+	h := make([]float64, k+1)
+	h[0] = 1
+	for _, w := range weights {
+		for j := 1; j <= k; j++ {
+			h[j] += w * h[j-1]
+		}
+	}
+	return h[k]
+}
+
+// SumOfCombinationSums returns the sum, over every combination of k of the values, of
+// the sum of the values in it. Each value is in nchoosek(n-1, k-1) of the combinations,
+// so this is nchoosek(n-1, k-1) * sum(values).
+func SumOfCombinationSums(values []float64, k int) float64 {
+	n := len(values)
+	if k <= 0 || k > n {
+		return 0
+	}
+	times, _ := new(big.Float).SetInt(count.Binomial(n-1, k-1)).Float64()
+	return times * sum_floats(values)
+}
+
+// SumOfCombinationWithReplacementSums returns the sum, over every combination with
+// replacement of k of the values, of the sum of the values in it, counting a value as
+// many times as it appears. There are k * nchoosek(n+k-1, k) places in all of the
+// combinations, and each value fills as many of them as any other, so this is
+// k * nchoosek(n+k-1, k) / n * sum(values).
+func SumOfCombinationWithReplacementSums(values []float64, k int) float64 {
+	n := len(values)
+	if k <= 0 || n == 0 {
+		return 0
+	}
+	// k * nchoosek(n+k-1, k) / n is the whole number nchoosek(n+k-1, k-1)
+	times, _ := new(big.Float).SetInt(count.Binomial(n+k-1, k-1)).Float64()
+	return times * sum_floats(values)
+}
+
+// MeanCombinationSum returns the mean, over every combination of k of the values, of the
+// sum of the values in it, which is k * mean(values). It returns 0 if there are no such
+// combinations.
+func MeanCombinationSum(values []float64, k int) float64 {
+	n := len(values)
+	if k < 0 || k > n || n == 0 {
+		return 0
+	}
+	return float64(k) * sum_floats(values) / float64(n)
+}
+
+// MeanCombinationProduct returns the mean, over every combination of k of the values,
+// of the product of the values in it: e_k(values) / nchoosek(n, k). It works with the
+// means directly, rather than dividing at the end, so it does not overflow when
+// nchoosek(n, k) is too big for a float64. It returns 0 if there are no such
+// combinations.
+func MeanCombinationProduct(values []float64, k int) float64 {
+	if k < 0 || k > len(values) {
+		return 0
+	}
+	// m[j] is the mean product over the j-subsets of the first i values. A j-subset of
+	// the first i values holds value i with probability j/i.
+	m := make([]float64, k+1)
+	m[0] = 1
+	for idx, v := range values {
+		i := float64(idx + 1)
+		for j := min(k, idx+1); j >= 1; j-- {
+			m[j] = (i-float64(j))/i*m[j] + float64(j)/i*v*m[j-1]
+		}
+	}
+	return m[k]
+}
+
+func sum_floats(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
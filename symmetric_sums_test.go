@@ -0,0 +1,180 @@
+package gocombinatorics
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// combination_sums_by_brute_force goes through every combination (with replacement or
+// not) of k of the values, and adds up the products and sums of the values in each.
+func combination_sums_by_brute_force(values []int64, k int, with_replacement bool) (product_total, sum_total *big.Int, n_combos int) {
+	product_total, sum_total = big.NewInt(0), big.NewInt(0)
+	add := func(items []int64) {
+		product, sum := big.NewInt(1), big.NewInt(0)
+		for _, v := range items {
+			product.Mul(product, big.NewInt(v))
+			sum.Add(sum, big.NewInt(v))
+		}
+		product_total.Add(product_total, product)
+		sum_total.Add(sum_total, sum)
+		n_combos++
+	}
+	if with_replacement {
+		c, _ := NewCombinationsWithReplacement(values, k)
+		for c.Next() {
+			add(c.Items())
+		}
+	} else {
+		c, _ := NewCombinations(values, k)
+		for c.Next() {
+			add(c.Items())
+		}
+	}
+	return product_total, sum_total, n_combos
+}
+
+func to_bigs(values []int64) []*big.Int {
+	result := make([]*big.Int, len(values))
+	for i, v := range values {
+		result[i] = big.NewInt(v)
+	}
+	return result
+}
+
+func to_floats(values []int64) []float64 {
+	result := make([]float64, len(values))
+	for i, v := range values {
+		result[i] = float64(v)
+	}
+	return result
+}
+
+func float_of(b *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(b).Float64()
+	return f
+}
+
+func close_enough(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-9*math.Max(math.Abs(want), 1)
+}
+
+func TestSymmetricSumsAgainstBruteForce(t *testing.T) {
+	values := []int64{3, -1, 4, 1, -5, 9, 2, 6}
+	bigs, floats := to_bigs(values), to_floats(values)
+	for k := 1; k <= len(values); k++ {
+		products, sums, n := combination_sums_by_brute_force(values, k, false)
+		if got := ElementarySymmetric(bigs, k); got.Cmp(products) != 0 {
+			t.Errorf("ElementarySymmetric(%v) = %v, want %v", k, got, products)
+		}
+		if got := ElementarySymmetricFloat(floats, k); !close_enough(got, float_of(products)) {
+			t.Errorf("ElementarySymmetricFloat(%v) = %v, want %v", k, got, products)
+		}
+		if got := SumOfCombinationSums(floats, k); !close_enough(got, float_of(sums)) {
+			t.Errorf("SumOfCombinationSums(%v) = %v, want %v", k, got, sums)
+		}
+		if got, want := MeanCombinationSum(floats, k), float_of(sums)/float64(n); !close_enough(got, want) {
+			t.Errorf("MeanCombinationSum(%v) = %v, want %v", k, got, want)
+		}
+		if got, want := MeanCombinationProduct(floats, k), float_of(products)/float64(n); !close_enough(got, want) {
+			t.Errorf("MeanCombinationProduct(%v) = %v, want %v", k, got, want)
+		}
+	}
+	for k := 1; k <= 6; k++ {
+		products, sums, _ := combination_sums_by_brute_force(values, k, true)
+		if got := CompleteHomogeneous(bigs, k); got.Cmp(products) != 0 {
+			t.Errorf("CompleteHomogeneous(%v) = %v, want %v", k, got, products)
+		}
+		if got := CompleteHomogeneousFloat(floats, k); !close_enough(got, float_of(products)) {
+			t.Errorf("CompleteHomogeneousFloat(%v) = %v, want %v", k, got, products)
+		}
+		if got := SumOfCombinationWithReplacementSums(floats, k); !close_enough(got, float_of(sums)) {
+			t.Errorf("SumOfCombinationWithReplacementSums(%v) = %v, want %v", k, got, sums)
+		}
+	}
+}
+
+func TestSymmetricSumsEdgeCases(t *testing.T) {
+	bigs := to_bigs([]int64{2, 3})
+	testCases := []struct {
+		desc string
+		got  *big.Int
+		want int64
+	}{
+		{desc: "e_0", got: ElementarySymmetric(bigs, 0), want: 1},
+		{desc: "e_0 of nothing", got: ElementarySymmetric(nil, 0), want: 1},
+		{desc: "e_k, k > n", got: ElementarySymmetric(bigs, 3), want: 0},
+		{desc: "e_k, k < 0", got: ElementarySymmetric(bigs, -1), want: 0},
+		{desc: "h_0", got: CompleteHomogeneous(bigs, 0), want: 1},
+		{desc: "h_k, k > n", got: CompleteHomogeneous(bigs, 3), want: 8 + 12 + 18 + 27},
+		{desc: "h_k of nothing", got: CompleteHomogeneous(nil, 2), want: 0},
+		{desc: "h_k, k < 0", got: CompleteHomogeneous(bigs, -1), want: 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if tC.got.Cmp(big.NewInt(tC.want)) != 0 {
+				t.Errorf("got %v, want %v", tC.got, tC.want)
+			}
+		})
+	}
+	if got := MeanCombinationProduct([]float64{1, 2}, 3); got != 0 {
+		t.Errorf("MeanCombinationProduct() with k > n = %v, want 0", got)
+	}
+	if got := SumOfCombinationWithReplacementSums(nil, 2); got != 0 {
+		t.Errorf("SumOfCombinationWithReplacementSums() of nothing = %v, want 0", got)
+	}
+}
+
+func TestPoissonBinomial(t *testing.T) {
+	// The probability that exactly k of some independent events happen, worked out with
+	// ElementarySymmetricFloat, against going through every subset of the events
+	p := []float64{0.1, 0.5, 0.25, 0.9, 0.6}
+	odds := make([]float64, len(p))
+	none := 1.0
+	for i, pi := range p {
+		odds[i] = pi / (1 - pi)
+		none *= 1 - pi
+	}
+	for k := 0; k <= len(p); k++ {
+		want := 0.0
+		for mask := 0; mask < 1<<len(p); mask++ {
+			prob, size := 1.0, 0
+			for i, pi := range p {
+				if mask&(1<<i) != 0 {
+					prob *= pi
+					size++
+				} else {
+					prob *= 1 - pi
+				}
+			}
+			if size == k {
+				want += prob
+			}
+		}
+		if got := none * ElementarySymmetricFloat(odds, k); !close_enough(got, want) {
+			t.Errorf("P(exactly %v) = %v, want %v", k, got, want)
+		}
+	}
+}
+
+func TestMeanCombinationProductLarge(t *testing.T) {
+	// With 2000 values all 1.5, the mean product of 1000 of them is 1.5^1000, although
+	// nchoosek(2000, 1000) is far too big for a float64
+	values := make([]float64, 2000)
+	for i := range values {
+		values[i] = 1.5
+	}
+	if got, want := MeanCombinationProduct(values, 1000), math.Pow(1.5, 1000); math.Abs(got-want) > 1e-9*want {
+		t.Errorf("MeanCombinationProduct() = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkElementarySymmetricFloat(b *testing.B) {
+	weights := make([]float64, 1000)
+	for i := range weights {
+		weights[i] = 1 / float64(i+1)
+	}
+	for i := 0; i < b.N; i++ {
+		ElementarySymmetricFloat(weights, 500)
+	}
+}
@@ -0,0 +1,245 @@
+package gocombinatorics
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// WeightedMethod picks how `WeightedSample` turns weights into a random k-subset
+type WeightedMethod int
+
+const (
+	// SuccessiveSampling draws one item at a time without replacement, each with
+	// probability proportional to its weight among the items not drawn yet. It uses the
+	// A-Res method of Efraimidis and Spirakis: give item i the key u_i^(1/w_i), for u_i
+	// uniform in (0, 1], and take the k largest keys. Items with weight 0 are only taken
+	// when there are not k others.
+	SuccessiveSampling WeightedMethod = iota
+	// ConditionalPoisson picks each k-subset with probability proportional to the product
+	// of its weights. It is what you get from drawing each item independently with odds
+	// w_i (probability w_i/(1+w_i)) and keeping only the draws that took exactly k items,
+	// so the inclusion probabilities can be worked out exactly from the weights.
+	ConditionalPoisson
+)
+
+// String returns the name of the method
+func (method WeightedMethod) String() string {
+	switch method {
+	case SuccessiveSampling:
+		return "SuccessiveSampling"
+	case ConditionalPoisson:
+		return "ConditionalPoisson"
+	}
+	return "WeightedMethod(?)"
+}
+
+// WeightedSample draws k of the items in data at random, over and over, where items with
+// more weight are more likely to be drawn. Create it with `NewWeightedSample`, then call
+// `Draw()` for each new sample. Like the combinations from `NewCombinations`, the items
+// in a sample keep the order they have in data.
+type WeightedSample[T any] struct {
+	data    []T
+	weights []float64
+	k       int
+	method  WeightedMethod
+	rng     *rand.Rand
+	inds    []int
+	buffer  []T
+	// keys are the A-Res keys of inds, kept as a min-heap for SuccessiveSampling
+	keys []float64
+	// log_weights are the logs of the weights, and rows[i][r] is log(e_r(weights[i:])),
+	// for ConditionalPoisson. rows[n] is e_0 of nothing.
+	log_weights []float64
+	rows        [][]float64
+}
+
+// NewWeightedSample creates a new WeightedSample that draws k of the items in data, using
+// randomness from src. weights[i] is the weight of data[i]; weights must be finite and
+// not negative, and at least k of them must be more than 0.
+func NewWeightedSample[T any](data []T, weights []float64, k int, method WeightedMethod, src rand.Source) (*WeightedSample[T], error) {
+	n := len(data)
+	if n <= 0 {
+		return nil, errors.New("len(input_data) must be greater than 0")
+	} else if k <= 0 {
+		return nil, errors.New("k must be greater than 0")
+	} else if k > n {
+		return nil, errors.New("k must be less than or equal to len(input_data)")
+	} else if len(weights) != n {
+		return nil, errors.New("len(weights) must equal len(input_data)")
+	} else if method != SuccessiveSampling && method != ConditionalPoisson {
+		return nil, errors.New("method must be SuccessiveSampling or ConditionalPoisson")
+	}
+	positive := 0
+	for _, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, errors.New("weights must be finite and greater than or equal to 0")
+		} else if w > 0 {
+			positive++
+		}
+	}
+	if positive < k {
+		return nil, errors.New("at least k weights must be greater than 0")
+	}
+
+	data_copy := make([]T, n)
+	copy(data_copy, data)
+	w := &WeightedSample[T]{
+		data:    data_copy,
+		weights: slices.Clone(weights),
+		k:       k,
+		method:  method,
+		rng:     rand.New(src),
+		inds:    make([]int, 0, k),
+		buffer:  make([]T, k),
+	}
+	if method == SuccessiveSampling {
+		w.keys = make([]float64, 0, k)
+	} else {
+		w.log_weights = make([]float64, n)
+		for i, weight := range w.weights {
+			w.log_weights[i] = math.Log(weight)
+		}
+		w.rows = conditional_poisson_rows(w.log_weights, k)
+	}
+	return w, nil
+}
+
+// Draw draws a new sample, and returns its items. The data in the slice returned will be
+// overwritten by the next call to `Draw()`. If you need to keep the data from each
+// sample, be sure to make a copy.
+func (w *WeightedSample[T]) Draw() []T {
+	w.inds = w.inds[:0]
+	if w.method == SuccessiveSampling {
+		w.draw_successive()
+	} else {
+		w.draw_conditional_poisson()
+	}
+	return w.Items()
+}
+
+// LenInds returns how many items are in each sample
+func (w *WeightedSample[T]) LenInds() int {
+	return w.k
+}
+
+// Indices returns the indices of the items in the last sample, in increasing order
+func (w *WeightedSample[T]) Indices() []int {
+	return w.inds
+}
+
+// Items returns the items in the last sample. Like `Draw()`, the slice is overwritten
+// every time a new sample is drawn.
+func (w *WeightedSample[T]) Items() []T {
+	fill_buffer(w.buffer, w.data, w.inds)
+	return w.buffer
+}
+
+// draw_successive keeps the k largest keys seen so far in a min-heap, so each item only
+// has to beat the smallest of them. The keys are compared as log(u_i)/w_i, which is in
+// the same order as u_i^(1/w_i) and does not round to 0 for small weights.
+func (w *WeightedSample[T]) draw_successive() {
+	w.keys = w.keys[:0]
+	for i, weight := range w.weights {
+		key := math.Inf(-1)
+		if weight > 0 {
+			key = math.Log(1-w.rng.Float64()) / weight
+		}
+		if len(w.keys) < w.k {
+			w.inds = append(w.inds, i)
+			w.keys = append(w.keys, key)
+			w.sift_up(len(w.keys) - 1)
+		} else if key > w.keys[0] {
+			w.inds[0], w.keys[0] = i, key
+			w.sift_down(0)
+		}
+	}
+	slices.Sort(w.inds)
+}
+
+func (w *WeightedSample[T]) sift_up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if w.keys[parent] <= w.keys[i] {
+			return
+		}
+		w.swap(i, parent)
+		i = parent
+	}
+}
+
+func (w *WeightedSample[T]) sift_down(i int) {
+	for {
+		smallest := i
+		for _, child := range [2]int{2*i + 1, 2*i + 2} {
+			if child < len(w.keys) && w.keys[child] < w.keys[smallest] {
+				smallest = child
+			}
+		}
+		if smallest == i {
+			return
+		}
+		w.swap(i, smallest)
+		i = smallest
+	}
+}
+
+func (w *WeightedSample[T]) swap(i, j int) {
+	w.keys[i], w.keys[j] = w.keys[j], w.keys[i]
+	w.inds[i], w.inds[j] = w.inds[j], w.inds[i]
+}
+
+// draw_conditional_poisson goes through the items in order. With r items still to take
+// from weights[i:], the subsets that take item i have total weight
+// w_i * e_{r-1}(weights[i+1:]), and the ones that skip it e_r(weights[i+1:]), so item i
+// is taken with probability w_i * e_{r-1} / (e_r + w_i * e_{r-1}).
+func (w *WeightedSample[T]) draw_conditional_poisson() {
+	r := w.k
+	for i, log_weight := range w.log_weights {
+		if r == 0 {
+			break
+		}
+		next := w.rows[i+1]
+		take := log_weight + next[r-1]
+		if w.rng.Float64() < math.Exp(take-log_add_exp(next[r], take)) {
+			w.inds = append(w.inds, i)
+			r--
+		}
+	}
+}
+
+// conditional_poisson_rows returns rows[i][r] = log(e_r(weights[i:])) for r in [0, k],
+// built from the last weight back using e_r(weights[i:]) = e_r(weights[i+1:]) +
+// w_i * e_{r-1}(weights[i+1:]), the same recurrence as `ElementarySymmetricFloat`. The
+// e_r of very small or very large weights do not fit in a float64, even for moderate k,
+// so the rows hold their logs. A weight of 0, and an e_r of fewer than r weights, have
+// log -Inf.
+func conditional_poisson_rows(log_weights []float64, k int) [][]float64 {
+	n := len(log_weights)
+	rows := make([][]float64, n+1)
+	rows[n] = make([]float64, k+1)
+	for r := 1; r <= k; r++ {
+		rows[n][r] = math.Inf(-1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		row := make([]float64, k+1)
+		next := rows[i+1]
+		for r := 1; r <= k; r++ {
+			row[r] = log_add_exp(next[r], log_weights[i]+next[r-1])
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// log_add_exp returns log(exp(a) + exp(b)), without overflowing or underflowing
+func log_add_exp(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	if math.IsInf(b, -1) {
+		return a
+	}
+	return a + math.Log1p(math.Exp(b-a))
+}
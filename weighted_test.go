@@ -0,0 +1,229 @@
+package gocombinatorics
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// exact_inclusion returns the probability that each item is in a sample from method,
+// by going through every combination of k of the n items and adding up the chance of
+// drawing it
+func exact_inclusion(weights []float64, k int, method WeightedMethod) []float64 {
+	n := len(weights)
+	inclusion := make([]float64, n)
+	g, _ := NewCombinations(stepped_range(0, n, 1), k)
+	for g.Next() {
+		subset := g.Indices()
+		var p float64
+		if method == ConditionalPoisson {
+			p = 1
+			for _, i := range subset {
+				p *= weights[i]
+			}
+			p /= ElementarySymmetricFloat(weights, k)
+		} else {
+			// Add up the chance of drawing the subset in each order, one item at a time
+			total := 0.0
+			for _, w := range weights {
+				total += w
+			}
+			orders, _ := NewPermutations(subset, k)
+			for orders.Next() {
+				order_p, left := 1.0, total
+				for _, j := range orders.Indices() {
+					order_p *= weights[subset[j]] / left
+					left -= weights[subset[j]]
+				}
+				p += order_p
+			}
+		}
+		for _, i := range subset {
+			inclusion[i] += p
+		}
+	}
+	return inclusion
+}
+
+func TestWeightedSampleInclusionProbabilities(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		weights []float64
+		k       int
+	}{
+		{desc: "k=1", weights: []float64{1, 2, 3, 4}, k: 1},
+		{desc: "k=2", weights: []float64{1, 2, 3, 4, 10}, k: 2},
+		{desc: "k=3", weights: []float64{0.5, 1, 1, 2, 5, 0.1}, k: 3},
+		{desc: "with a zero weight", weights: []float64{3, 0, 1, 2}, k: 2},
+		{desc: "everything", weights: []float64{1, 2, 3}, k: 3},
+	}
+	const samples = 40_000
+	for _, method := range []WeightedMethod{SuccessiveSampling, ConditionalPoisson} {
+		for _, tC := range testCases {
+			t.Run(fmt.Sprintf("%v %v", method, tC.desc), func(t *testing.T) {
+				data := stepped_range(0, len(tC.weights), 1)
+				w, err := NewWeightedSample(data, tC.weights, tC.k, method, rand.NewPCG(48, 1))
+				if err != nil {
+					t.Fatal(err)
+				}
+				counts := make([]int, len(data))
+				for i := 0; i < samples; i++ {
+					items := w.Draw()
+					if len(items) != tC.k {
+						t.Fatalf("Draw() = %v, want %v items", items, tC.k)
+					}
+					for j, item := range items {
+						if j > 0 && item <= items[j-1] {
+							t.Fatalf("Draw() = %v, want distinct items in increasing order", items)
+						}
+						counts[item]++
+					}
+				}
+				want := exact_inclusion(tC.weights, tC.k, method)
+				for i, c := range counts {
+					got := float64(c) / samples
+					// 0.015 is more than 6 standard deviations with this many samples
+					if math.Abs(got-want[i]) > 0.015 {
+						t.Errorf("item %v drawn in %v of samples, want %v", i, got, want[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestWeightedSampleMethodsDiffer(t *testing.T) {
+	// With one heavy item, successive sampling nearly always takes it, but conditional
+	// Poisson weighs every pair by its product, so the exact probabilities differ
+	weights := []float64{1, 1, 1, 20}
+	successive := exact_inclusion(weights, 2, SuccessiveSampling)
+	poisson := exact_inclusion(weights, 2, ConditionalPoisson)
+	if math.Abs(successive[3]-poisson[3]) < 0.01 {
+		t.Errorf("inclusion of the heavy item is %v and %v, want them to differ", successive[3], poisson[3])
+	}
+	for _, inclusion := range [][]float64{successive, poisson} {
+		total := 0.0
+		for _, p := range inclusion {
+			total += p
+		}
+		if math.Abs(total-2) > 1e-12 {
+			t.Errorf("inclusion probabilities %v add up to %v, want 2", inclusion, total)
+		}
+	}
+}
+
+func TestWeightedSampleReusesBuffer(t *testing.T) {
+	data := []string{"a", "b", "c", "d"}
+	w, _ := NewWeightedSample(data, []float64{1, 2, 3, 4}, 2, SuccessiveSampling, rand.NewPCG(1, 2))
+	first := w.Draw()
+	second := w.Draw()
+	if &first[0] != &second[0] {
+		t.Errorf("Draw() gave a new slice, want the same one overwritten")
+	}
+	if got := w.Items(); &got[0] != &second[0] || w.LenInds() != 2 || len(w.Indices()) != 2 {
+		t.Errorf("Items(), LenInds() and Indices() do not match the last Draw()")
+	}
+	allocs := testing.AllocsPerRun(100, func() { w.Draw() })
+	if allocs > 0 {
+		t.Errorf("Draw() made %v allocations, want 0", allocs)
+	}
+}
+
+func TestWeightedSampleExtremeWeights(t *testing.T) {
+	// e_k of these underflows or overflows a float64, but scaling every weight by the same
+	// amount must not change anything: each item is in half the samples
+	n, k := 100, 50
+	data := stepped_range(0, n, 1)
+	const samples = 4_000
+	for _, weight := range []float64{1e-8, 1e-300, 1e10, 1e300} {
+		t.Run(fmt.Sprint(weight), func(t *testing.T) {
+			weights := make([]float64, n)
+			for i := range weights {
+				weights[i] = weight
+			}
+			w, err := NewWeightedSample(data, weights, k, ConditionalPoisson, rand.NewPCG(3, 4))
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts := make([]int, n)
+			for i := 0; i < samples; i++ {
+				w.Draw()
+				if got := len(w.Indices()); got != k {
+					t.Fatalf("Draw() gave %v items, want %v", got, k)
+				}
+				for _, ind := range w.Indices() {
+					counts[ind]++
+				}
+			}
+			for i, c := range counts {
+				// 0.05 is more than 6 standard deviations with this many samples
+				if got := float64(c) / samples; math.Abs(got-0.5) > 0.05 {
+					t.Errorf("item %v drawn in %v of samples, want 0.5", i, got)
+				}
+			}
+		})
+	}
+
+	// Weights far apart: the 3 heavy items are nearly always taken, and the rest of the
+	// sample is spread evenly over the light ones
+	weights := []float64{1e200, 1e-200, 1e-200, 1e200, 1e-200, 1e200}
+	w, _ := NewWeightedSample(stepped_range(0, 6, 1), weights, 4, ConditionalPoisson, rand.NewPCG(5, 6))
+	counts := make([]int, 6)
+	for i := 0; i < samples; i++ {
+		for _, ind := range w.Draw() {
+			counts[ind]++
+		}
+	}
+	want := []float64{1, 1.0 / 3, 1.0 / 3, 1, 1.0 / 3, 1}
+	for i, c := range counts {
+		if got := float64(c) / samples; math.Abs(got-want[i]) > 0.05 {
+			t.Errorf("item %v drawn in %v of samples, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestWeightedSampleErrors(t *testing.T) {
+	data := []int{1, 2, 3}
+	src := rand.NewPCG(1, 2)
+	testCases := []struct {
+		desc    string
+		data    []int
+		weights []float64
+		k       int
+		method  WeightedMethod
+	}{
+		{desc: "no data", data: []int{}, weights: []float64{}, k: 1},
+		{desc: "k = 0", data: data, weights: []float64{1, 1, 1}, k: 0},
+		{desc: "k > n", data: data, weights: []float64{1, 1, 1}, k: 4},
+		{desc: "too few weights", data: data, weights: []float64{1, 1}, k: 1},
+		{desc: "negative weight", data: data, weights: []float64{1, -1, 1}, k: 1},
+		{desc: "NaN weight", data: data, weights: []float64{1, math.NaN(), 1}, k: 1},
+		{desc: "infinite weight", data: data, weights: []float64{1, math.Inf(1), 1}, k: 1},
+		{desc: "fewer than k positive weights", data: data, weights: []float64{1, 0, 0}, k: 2},
+		{desc: "unknown method", data: data, weights: []float64{1, 1, 1}, k: 1, method: WeightedMethod(5)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := NewWeightedSample(tC.data, tC.weights, tC.k, tC.method, src); err == nil {
+				t.Errorf("got no error")
+			}
+		})
+	}
+}
+
+func BenchmarkWeightedSampleDraw(b *testing.B) {
+	data := stepped_range(0, 10_000, 1)
+	weights := make([]float64, len(data))
+	for i := range weights {
+		weights[i] = float64(i%7 + 1)
+	}
+	for _, method := range []WeightedMethod{SuccessiveSampling, ConditionalPoisson} {
+		w, _ := NewWeightedSample(data, weights, 20, method, rand.NewPCG(1, 2))
+		b.Run(method.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				w.Draw()
+			}
+		})
+	}
+}